@@ -0,0 +1,317 @@
+package analysis
+
+import (
+	"fmt"
+	"github.com/FidelityInternational/virgil/render"
+	"github.com/cloudfoundry/go-cfclient/v3/resource"
+	"io"
+	"strings"
+)
+
+const (
+	// Redundant - the rule is identical to another rule that applies everywhere it does, or to an earlier rule
+	Redundant = "redundant"
+	// Shadowed - everything the rule allows is allowed by a broader rule
+	Shadowed = "shadowed"
+	// Overlapping - the rule allows some of what another rule allows, and each allows something the other does not
+	Overlapping = "overlapping"
+)
+
+// RuleRef - a security group rule and the security group it belongs to
+type RuleRef struct {
+	SecurityGroup string                     `json:"security_group"`
+	Index         int                        `json:"index"`
+	Rule          resource.SecurityGroupRule `json:"rule"`
+}
+
+// String - describes the rule as "security group #index: protocol ports destination"
+func (r RuleRef) String() string {
+	return fmt.Sprintf("%s #%d: %s", r.SecurityGroup, r.Index+1, DescribeRule(r.Rule))
+}
+
+// RuleFinding - a security group rule that is redundant, shadowed or overlapping, with the rule responsible
+type RuleFinding struct {
+	Kind string  `json:"kind"`
+	Rule RuleRef `json:"rule"`
+	By   RuleRef `json:"by"`
+	// SameScope - true when the other rule's group applies to every space and lifecycle this rule's group
+	// does, so removing a redundant or shadowed rule does not change what any app can reach
+	SameScope bool `json:"same_scope"`
+}
+
+// RuleReport - the findings of a rule analysis
+type RuleReport struct {
+	Findings []RuleFinding `json:"findings"`
+}
+
+// entry - one destination and port range of a security group rule
+type entry struct {
+	protocol           string
+	address            render.Address
+	portStart, portEnd int
+	icmpType, icmpCode int
+}
+
+// parsedRule - a security group rule split into entries, invalid rules have no entries
+type parsedRule struct {
+	ref     RuleRef
+	scope   scope
+	entries []entry
+}
+
+// AnalyseRules - compares every rule of the given security groups with every other, reporting rules that are
+// redundant or shadowed by a single other rule, and pairs of rules that partially overlap. Rules that cannot be
+// parsed, such as tcp rules without ports, are left out
+func AnalyseRules(secGroups []resource.SecurityGroup) RuleReport {
+	var rules []parsedRule
+	for _, secGroup := range secGroups {
+		for i, rule := range secGroup.Rules {
+			rules = append(rules, parsedRule{
+				ref:     RuleRef{SecurityGroup: secGroup.Name, Index: i, Rule: rule},
+				scope:   scopeOf(secGroup),
+				entries: parseEntries(rule),
+			})
+		}
+	}
+	var report RuleReport
+	for i, rule := range rules {
+		if len(rule.entries) == 0 {
+			continue
+		}
+		var covering *RuleFinding
+		for j, other := range rules {
+			if i == j || len(other.entries) == 0 {
+				continue
+			}
+			sameScope := other.scope.covers(rule.scope)
+			switch {
+			case identical(rule.entries, other.entries) && duplicates(rule, other, i, j):
+				if covering == nil || covering.Kind != Redundant || (!covering.SameScope && sameScope) {
+					covering = &RuleFinding{Kind: Redundant, Rule: rule.ref, By: other.ref, SameScope: sameScope}
+				}
+			case !identical(rule.entries, other.entries) && coveredBy(rule.entries, other.entries):
+				if covering == nil || (covering.Kind == Shadowed && !covering.SameScope && sameScope) {
+					covering = &RuleFinding{Kind: Shadowed, Rule: rule.ref, By: other.ref, SameScope: sameScope}
+				}
+			case j > i && overlapping(rule.entries, other.entries) && !coveredBy(rule.entries, other.entries) && !coveredBy(other.entries, rule.entries):
+				report.Findings = append(report.Findings, RuleFinding{Kind: Overlapping, Rule: rule.ref, By: other.ref, SameScope: sameScope})
+			}
+		}
+		if covering != nil {
+			report.Findings = append(report.Findings, *covering)
+		}
+	}
+	return report
+}
+
+// duplicates - returns true when rule, at index i, is the one of two identical rules to report as redundant: the
+// rule whose scope is covered by the other's, or the later of the two when the scopes are equal or neither covers
+// the other
+func duplicates(rule, other parsedRule, i, j int) bool {
+	covered, covers := other.scope.covers(rule.scope), rule.scope.covers(other.scope)
+	if covered != covers {
+		return covered
+	}
+	return j < i
+}
+
+// Count - returns the number of findings of the given kind
+func (r RuleReport) Count(kind string) int {
+	count := 0
+	for _, finding := range r.Findings {
+		if finding.Kind == kind {
+			count++
+		}
+	}
+	return count
+}
+
+// WriteTo - writes the findings as text, redundant and shadowed rules first as they can be removed
+func (r RuleReport) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	for _, kind := range []string{Redundant, Shadowed, Overlapping} {
+		fmt.Fprintf(&b, "Virgil\t- %d %s rules\n", r.Count(kind), kind)
+		for _, finding := range r.Findings {
+			if finding.Kind != kind {
+				continue
+			}
+			switch kind {
+			case Redundant:
+				fmt.Fprintf(&b, "  %s duplicates %s", finding.Rule, finding.By)
+			case Shadowed:
+				fmt.Fprintf(&b, "  %s is covered by %s", finding.Rule, finding.By)
+			default:
+				fmt.Fprintf(&b, "  %s overlaps %s", finding.Rule, finding.By)
+			}
+			if kind != Overlapping && !finding.SameScope {
+				fmt.Fprint(&b, " (not applied to every space and lifecycle this rule is)")
+			}
+			fmt.Fprintln(&b)
+		}
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// DescribeRule - returns a security group rule as "protocol ports destination"
+func DescribeRule(rule resource.SecurityGroupRule) string {
+	parts := []string{rule.Protocol}
+	if rule.Ports != nil && *rule.Ports != "" {
+		parts = append(parts, *rule.Ports)
+	}
+	if rule.Type != nil {
+		parts = append(parts, fmt.Sprintf("type %d", *rule.Type))
+	}
+	if rule.Code != nil {
+		parts = append(parts, fmt.Sprintf("code %d", *rule.Code))
+	}
+	return strings.Join(append(parts, rule.Destination), " ")
+}
+
+func parseEntries(rule resource.SecurityGroupRule) []entry {
+	protocol := strings.ToLower(rule.Protocol)
+	var ports [][2]int
+	switch protocol {
+	case "tcp", "udp":
+		if rule.Ports == nil {
+			return nil
+		}
+		for _, port := range strings.Split(*rule.Ports, ",") {
+			start, end, err := render.SplitPortRange(port)
+			if err != nil {
+				return nil
+			}
+			ports = append(ports, [2]int{start, end})
+		}
+	default:
+		ports = [][2]int{{0, 65535}}
+	}
+	icmpType, icmpCode := -1, -1
+	if rule.Type != nil {
+		icmpType = *rule.Type
+	}
+	if rule.Code != nil {
+		icmpCode = *rule.Code
+	}
+	var entries []entry
+	for _, destination := range strings.Split(rule.Destination, ",") {
+		address, err := render.ParseAddress(destination)
+		if err != nil {
+			return nil
+		}
+		for _, port := range ports {
+			entries = append(entries, entry{
+				protocol:  protocol,
+				address:   address,
+				portStart: port[0],
+				portEnd:   port[1],
+				icmpType:  icmpType,
+				icmpCode:  icmpCode,
+			})
+		}
+	}
+	return entries
+}
+
+// covers - returns true when entry a allows everything entry b does
+func (a entry) covers(b entry) bool {
+	if a.protocol != "all" && a.protocol != b.protocol {
+		return false
+	}
+	if a.protocol != "all" && !strings.HasPrefix(a.protocol, "icmp") && (b.portStart < a.portStart || a.portEnd < b.portEnd) {
+		return false
+	}
+	if strings.HasPrefix(a.protocol, "icmp") && ((a.icmpType != -1 && a.icmpType != b.icmpType) || (a.icmpCode != -1 && a.icmpCode != b.icmpCode)) {
+		return false
+	}
+	return a.address.Contains(b.address)
+}
+
+// overlaps - returns true when entry a allows anything entry b does
+func (a entry) overlaps(b entry) bool {
+	if a.protocol != "all" && b.protocol != "all" && a.protocol != b.protocol {
+		return false
+	}
+	if a.protocol == b.protocol && strings.HasPrefix(a.protocol, "icmp") {
+		return a.covers(b) || b.covers(a)
+	}
+	if a.protocol == b.protocol && a.protocol != "all" && (b.portEnd < a.portStart || a.portEnd < b.portStart) {
+		return false
+	}
+	return a.address.Overlaps(b.address)
+}
+
+// coveredBy - returns true when every entry is covered by an entry of other
+func coveredBy(entries, other []entry) bool {
+	for _, e := range entries {
+		covered := false
+		for _, o := range other {
+			if o.covers(e) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return false
+		}
+	}
+	return true
+}
+
+func overlapping(entries, other []entry) bool {
+	for _, e := range entries {
+		for _, o := range other {
+			if o.overlaps(e) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func identical(entries, other []entry) bool {
+	return coveredBy(entries, other) && coveredBy(other, entries)
+}
+
+// scope - where a security group applies, globally or to spaces, for running and staging apps
+type scope struct {
+	runningGlobal, stagingGlobal bool
+	runningSpaces, stagingSpaces map[string]bool
+}
+
+func scopeOf(secGroup resource.SecurityGroup) scope {
+	s := scope{
+		runningGlobal: secGroup.GloballyEnabled.Running != nil && *secGroup.GloballyEnabled.Running,
+		stagingGlobal: secGroup.GloballyEnabled.Staging != nil && *secGroup.GloballyEnabled.Staging,
+		runningSpaces: make(map[string]bool),
+		stagingSpaces: make(map[string]bool),
+	}
+	for _, space := range secGroup.Relationships.RunningSpaces.Data {
+		s.runningSpaces[space.GUID] = true
+	}
+	for _, space := range secGroup.Relationships.StagingSpaces.Data {
+		s.stagingSpaces[space.GUID] = true
+	}
+	return s
+}
+
+// covers - returns true when s applies everywhere other does
+func (s scope) covers(other scope) bool {
+	return lifecycleCovers(s.runningGlobal, s.runningSpaces, other.runningGlobal, other.runningSpaces) &&
+		lifecycleCovers(s.stagingGlobal, s.stagingSpaces, other.stagingGlobal, other.stagingSpaces)
+}
+
+func lifecycleCovers(global bool, spaces map[string]bool, otherGlobal bool, otherSpaces map[string]bool) bool {
+	if global {
+		return true
+	}
+	if otherGlobal {
+		return false
+	}
+	for space := range otherSpaces {
+		if !spaces[space] {
+			return false
+		}
+	}
+	return true
+}
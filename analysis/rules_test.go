@@ -0,0 +1,100 @@
+package analysis_test
+
+import (
+	"bytes"
+	"github.com/FidelityInternational/virgil/analysis"
+	"github.com/FidelityInternational/virgil/fakes"
+	"github.com/cloudfoundry/go-cfclient/v3/resource"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Rules", func() {
+	var secGroups []resource.SecurityGroup
+
+	describe := func(report analysis.RuleReport) []string {
+		var findings []string
+		for _, finding := range report.Findings {
+			findings = append(findings, finding.Kind+": "+finding.Rule.String()+" by "+finding.By.String())
+		}
+		return findings
+	}
+
+	BeforeEach(func() {
+		bound := fakes.SecurityGroup("space-web", fakes.Rule("tcp", "10.1.1.1", "443"))
+		bound.GloballyEnabled.Running = new(bool)
+		bound.Relationships.RunningSpaces.Data = []resource.Relationship{{GUID: "space-guid"}}
+		secGroups = []resource.SecurityGroup{
+			fakes.SecurityGroup("internal", fakes.Rule("all", "10.0.0.0/8", "")),
+			fakes.SecurityGroup("web", fakes.Rule("tcp", "10.1.1.1", "443"), fakes.Rule("tcp", "10.1.1.1", "443")),
+			fakes.SecurityGroup("dns", fakes.Rule("udp", "192.168.0.0/24", "53,8000-8100"), fakes.Rule("udp", "192.168.0.128-192.168.1.10", "8050-9000")),
+			bound,
+		}
+	})
+
+	Describe("#AnalyseRules", func() {
+		It("reports redundant, shadowed and overlapping rules", func() {
+			report := analysis.AnalyseRules(secGroups)
+			Expect(describe(report)).To(Equal([]string{
+				"shadowed: web #1: tcp 443 10.1.1.1 by internal #1: all 10.0.0.0/8",
+				"redundant: web #2: tcp 443 10.1.1.1 by web #1: tcp 443 10.1.1.1",
+				"overlapping: dns #1: udp 53,8000-8100 192.168.0.0/24 by dns #2: udp 8050-9000 192.168.0.128-192.168.1.10",
+				"redundant: space-web #1: tcp 443 10.1.1.1 by web #1: tcp 443 10.1.1.1",
+			}))
+			Expect(report.Findings[0].SameScope).To(BeTrue())
+			Expect(report.Count(analysis.Redundant)).To(Equal(2))
+		})
+
+		It("reports the identical rule with the narrower scope as redundant, whatever the order", func() {
+			report := analysis.AnalyseRules([]resource.SecurityGroup{secGroups[3], secGroups[1]})
+			Expect(describe(report)).To(Equal([]string{
+				"redundant: space-web #1: tcp 443 10.1.1.1 by web #1: tcp 443 10.1.1.1",
+				"redundant: web #2: tcp 443 10.1.1.1 by web #1: tcp 443 10.1.1.1",
+			}))
+			Expect(report.Findings[0].SameScope).To(BeTrue())
+		})
+
+		It("records when the covering rule does not apply everywhere", func() {
+			other := fakes.SecurityGroup("other-web", fakes.Rule("tcp", "10.1.1.1", "443"))
+			other.GloballyEnabled.Running = new(bool)
+			other.Relationships.RunningSpaces.Data = []resource.Relationship{{GUID: "other-space-guid"}}
+			report := analysis.AnalyseRules([]resource.SecurityGroup{secGroups[3], other})
+			Expect(describe(report)).To(Equal([]string{"redundant: other-web #1: tcp 443 10.1.1.1 by space-web #1: tcp 443 10.1.1.1"}))
+			Expect(report.Findings[0].SameScope).To(BeFalse())
+			var output bytes.Buffer
+			_, err := report.WriteTo(&output)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(output.String()).To(ContainSubstring("duplicates space-web #1: tcp 443 10.1.1.1 (not applied to every space and lifecycle this rule is)\n"))
+		})
+
+		It("compares icmp rules by type and code", func() {
+			anyType, echo := -1, 8
+			report := analysis.AnalyseRules([]resource.SecurityGroup{
+				fakes.SecurityGroup("ping", resource.SecurityGroupRule{Protocol: "icmp", Destination: "10.1.0.0/16", Type: &echo, Code: &anyType}),
+				fakes.SecurityGroup("icmp", resource.SecurityGroupRule{Protocol: "icmp", Destination: "10.0.0.0/8", Type: &anyType, Code: &anyType}),
+			})
+			Expect(describe(report)).To(Equal([]string{"shadowed: ping #1: icmp type 8 code -1 10.1.0.0/16 by icmp #1: icmp type -1 code -1 10.0.0.0/8"}))
+		})
+
+		It("leaves out rules that cannot be parsed", func() {
+			report := analysis.AnalyseRules([]resource.SecurityGroup{
+				fakes.SecurityGroup("broken", fakes.Rule("tcp", "10.1.1.1", ""), fakes.Rule("tcp", "not-an-ip", "443")),
+				fakes.SecurityGroup("internal", fakes.Rule("all", "10.0.0.0/8", "")),
+			})
+			Expect(report.Findings).To(BeEmpty())
+		})
+	})
+
+	Describe("#WriteTo", func() {
+		It("groups the findings by kind", func() {
+			var output bytes.Buffer
+			_, err := analysis.AnalyseRules([]resource.SecurityGroup{secGroups[3], secGroups[1]}).WriteTo(&output)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(output.String()).To(Equal("Virgil\t- 2 redundant rules\n" +
+				"  space-web #1: tcp 443 10.1.1.1 duplicates web #1: tcp 443 10.1.1.1\n" +
+				"  web #2: tcp 443 10.1.1.1 duplicates web #1: tcp 443 10.1.1.1\n" +
+				"Virgil\t- 0 shadowed rules\n" +
+				"Virgil\t- 0 overlapping rules\n"))
+		})
+	})
+})
@@ -0,0 +1,145 @@
+package analysis
+
+import (
+	"fmt"
+	"github.com/FidelityInternational/virgil"
+	"github.com/cloudfoundry/go-cfclient/v3/resource"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// Unbound - the security group is not enabled globally or bound to any space
+	Unbound = "unbound"
+	// EmptySpaces - every space the security group is bound to has no apps
+	EmptySpaces = "empty_spaces"
+	// Orphaned - every space the security group is bound to has been deleted or belongs to a deleted org
+	Orphaned = "orphaned"
+)
+
+// BoundSpace - a space an unused security group is bound to, Missing is true when the space no longer exists
+type BoundSpace struct {
+	GUID    string `json:"guid"`
+	Name    string `json:"name,omitempty"`
+	Org     string `json:"org,omitempty"`
+	Apps    int    `json:"apps"`
+	Missing bool   `json:"missing,omitempty"`
+}
+
+// String - describes the space as "org/space", or by GUID when it or its org has been deleted
+func (s BoundSpace) String() string {
+	switch {
+	case s.Missing:
+		return fmt.Sprintf("%s (deleted)", s.GUID)
+	case s.Org == "":
+		return fmt.Sprintf("%s (org deleted)", s.Name)
+	default:
+		return fmt.Sprintf("%s/%s (%d apps)", s.Org, s.Name, s.Apps)
+	}
+}
+
+// UnusedGroup - a security group that applies to no apps, with why and how old it is
+type UnusedGroup struct {
+	Name      string       `json:"name"`
+	GUID      string       `json:"guid"`
+	Reason    string       `json:"reason"`
+	Rules     int          `json:"rules"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+	Spaces    []BoundSpace `json:"spaces,omitempty"`
+}
+
+// UnusedReport - the security groups that can be garbage collected, oldest change first
+type UnusedReport struct {
+	Groups []UnusedGroup `json:"groups"`
+	// Now - the time ages are measured from
+	Now time.Time `json:"-"`
+}
+
+// FindUnusedGroups - returns the security groups that are not bound anywhere, bound only to spaces without apps,
+// or bound only to spaces or orgs that have been deleted. Globally enabled groups are always in use
+func FindUnusedGroups(secGroups []resource.SecurityGroup, spaces []virgil.Space, now time.Time) UnusedReport {
+	spacesByGUID := make(map[string]virgil.Space)
+	for _, space := range spaces {
+		spacesByGUID[space.GUID] = space
+	}
+	report := UnusedReport{Now: now}
+	for _, secGroup := range secGroups {
+		if isTrue(secGroup.GloballyEnabled.Running) || isTrue(secGroup.GloballyEnabled.Staging) {
+			continue
+		}
+		var bound []BoundSpace
+		seen := make(map[string]bool)
+		orphaned, empty := true, true
+		for _, relationship := range append(append([]resource.Relationship{}, secGroup.Relationships.RunningSpaces.Data...), secGroup.Relationships.StagingSpaces.Data...) {
+			if seen[relationship.GUID] {
+				continue
+			}
+			seen[relationship.GUID] = true
+			space, ok := spacesByGUID[relationship.GUID]
+			if !ok {
+				bound = append(bound, BoundSpace{GUID: relationship.GUID, Missing: true})
+				continue
+			}
+			bound = append(bound, BoundSpace{GUID: space.GUID, Name: space.Name, Org: space.Org, Apps: space.Apps})
+			if space.Org != "" {
+				orphaned = false
+				if space.Apps > 0 {
+					empty = false
+				}
+			}
+		}
+		reason := ""
+		switch {
+		case len(bound) == 0:
+			reason = Unbound
+		case orphaned:
+			reason = Orphaned
+		case empty:
+			reason = EmptySpaces
+		default:
+			continue
+		}
+		report.Groups = append(report.Groups, UnusedGroup{
+			Name:      secGroup.Name,
+			GUID:      secGroup.GUID,
+			Reason:    reason,
+			Rules:     len(secGroup.Rules),
+			CreatedAt: secGroup.CreatedAt,
+			UpdatedAt: secGroup.UpdatedAt,
+			Spaces:    bound,
+		})
+	}
+	sort.SliceStable(report.Groups, func(i, j int) bool {
+		return report.Groups[i].UpdatedAt.Before(report.Groups[j].UpdatedAt)
+	})
+	return report
+}
+
+// WriteTo - writes the unused security groups as text with their age in days
+func (r UnusedReport) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Virgil\t- %d unused security groups\n", len(r.Groups))
+	for _, group := range r.Groups {
+		fmt.Fprintf(&b, "  %s (%s) - %d rules, created %d days ago, updated %d days ago\n",
+			group.Name, group.Reason, group.Rules, ageInDays(r.Now, group.CreatedAt), ageInDays(r.Now, group.UpdatedAt))
+		for _, space := range group.Spaces {
+			fmt.Fprintf(&b, "    bound to %s\n", space)
+		}
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func ageInDays(now, then time.Time) int {
+	if then.IsZero() || then.After(now) {
+		return 0
+	}
+	return int(now.Sub(then).Hours() / 24)
+}
+
+func isTrue(value *bool) bool {
+	return value != nil && *value
+}
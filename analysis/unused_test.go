@@ -0,0 +1,100 @@
+package analysis_test
+
+import (
+	"bytes"
+	"github.com/FidelityInternational/virgil"
+	"github.com/FidelityInternational/virgil/analysis"
+	"github.com/FidelityInternational/virgil/fakes"
+	"github.com/cloudfoundry/go-cfclient/v3/resource"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"time"
+)
+
+var _ = Describe("Unused", func() {
+	var (
+		secGroups []resource.SecurityGroup
+		spaces    []virgil.Space
+		now       time.Time
+	)
+
+	boundTo := func(name string, spaceGUIDs ...string) resource.SecurityGroup {
+		secGroup := fakes.SecurityGroup(name, fakes.Rule("tcp", "10.1.1.1", "443"), fakes.Rule("udp", "10.1.1.2", "53"))
+		secGroup.GloballyEnabled.Running = new(bool)
+		for _, guid := range spaceGUIDs {
+			secGroup.Relationships.StagingSpaces.Data = append(secGroup.Relationships.StagingSpaces.Data, resource.Relationship{GUID: guid})
+		}
+		return secGroup
+	}
+
+	names := func(report analysis.UnusedReport) []string {
+		var groups []string
+		for _, group := range report.Groups {
+			groups = append(groups, group.Name+": "+group.Reason)
+		}
+		return groups
+	}
+
+	BeforeEach(func() {
+		now = time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+		unbound := boundTo("unbound")
+		unbound.CreatedAt = now.AddDate(0, 0, -100)
+		unbound.UpdatedAt = now.AddDate(0, 0, -30)
+		secGroups = []resource.SecurityGroup{
+			fakes.SecurityGroup("global", fakes.Rule("all", "10.0.0.0/8", "")),
+			unbound,
+			boundTo("in-use", "dev-guid", "empty-guid"),
+			boundTo("empty", "empty-guid", "deleted-guid"),
+			boundTo("orphaned", "deleted-guid", "old-guid"),
+		}
+		spaces = []virgil.Space{
+			{GUID: "dev-guid", Name: "dev", Org: "platform", Apps: 3},
+			{GUID: "empty-guid", Name: "empty", Org: "platform"},
+			{GUID: "old-guid", Name: "old", Apps: 1},
+		}
+	})
+
+	Describe("#FindUnusedGroups", func() {
+		It("reports unbound, empty and orphaned groups", func() {
+			report := analysis.FindUnusedGroups(secGroups, spaces, now)
+			Expect(names(report)).To(Equal([]string{
+				"empty: empty_spaces",
+				"orphaned: orphaned",
+				"unbound: unbound",
+			}))
+		})
+
+		It("records the rule count, age and bound spaces", func() {
+			report := analysis.FindUnusedGroups(secGroups, spaces, now)
+			Expect(report.Groups[2].Rules).To(Equal(2))
+			Expect(report.Groups[2].CreatedAt).To(Equal(now.AddDate(0, 0, -100)))
+			Expect(report.Groups[1].Spaces).To(Equal([]analysis.BoundSpace{
+				{GUID: "deleted-guid", Missing: true},
+				{GUID: "old-guid", Name: "old", Apps: 1},
+			}))
+		})
+
+		It("treats a group bound to the same space for running and staging as one binding", func() {
+			secGroup := boundTo("both", "empty-guid")
+			secGroup.Relationships.RunningSpaces.Data = []resource.Relationship{{GUID: "empty-guid"}}
+			report := analysis.FindUnusedGroups([]resource.SecurityGroup{secGroup}, spaces, now)
+			Expect(report.Groups[0].Spaces).To(HaveLen(1))
+		})
+	})
+
+	Describe("#WriteTo", func() {
+		It("writes each group with its age and spaces", func() {
+			var out bytes.Buffer
+			_, err := analysis.FindUnusedGroups(secGroups, spaces, now).WriteTo(&out)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out.String()).To(Equal("Virgil\t- 3 unused security groups\n" +
+				"  empty (empty_spaces) - 2 rules, created 0 days ago, updated 0 days ago\n" +
+				"    bound to platform/empty (0 apps)\n" +
+				"    bound to deleted-guid (deleted)\n" +
+				"  orphaned (orphaned) - 2 rules, created 0 days ago, updated 0 days ago\n" +
+				"    bound to deleted-guid (deleted)\n" +
+				"    bound to old (org deleted)\n" +
+				"  unbound (unbound) - 2 rules, created 100 days ago, updated 30 days ago\n"))
+		})
+	})
+})
@@ -0,0 +1,206 @@
+package bosh
+
+import (
+	"fmt"
+	"github.com/FidelityInternational/virgil/render"
+	"github.com/FidelityInternational/virgil/utility"
+	"gopkg.in/yaml.v2"
+	"regexp"
+	"sort"
+)
+
+// CellNetwork - a network a cell instance group is placed on, with the static IPs and AZs the group uses
+type CellNetwork struct {
+	Name      string
+	StaticIPs []string
+	AZs       []string
+}
+
+type manifest struct {
+	InstanceGroups []instanceGroup `yaml:"instance_groups"`
+	Jobs           []instanceGroup `yaml:"jobs"`
+}
+
+type instanceGroup struct {
+	Name     string   `yaml:"name"`
+	AZs      []string `yaml:"azs"`
+	Networks []struct {
+		Name      string   `yaml:"name"`
+		StaticIPs []string `yaml:"static_ips"`
+	} `yaml:"networks"`
+}
+
+type cloudConfig struct {
+	Networks []network `yaml:"networks"`
+}
+
+type network struct {
+	Name    string   `yaml:"name"`
+	Type    string   `yaml:"type"`
+	Subnets []subnet `yaml:"subnets"`
+}
+
+type subnet struct {
+	Range    string   `yaml:"range"`
+	Gateway  string   `yaml:"gateway"`
+	AZ       string   `yaml:"az"`
+	AZs      []string `yaml:"azs"`
+	Reserved []string `yaml:"reserved"`
+	Static   []string `yaml:"static"`
+}
+
+// FindCellNetworks - takes a deployment manifest and a regex to filter instance groups on, returning the networks
+// of every matching group. v1 manifests listing jobs rather than instance groups are also supported
+func FindCellNetworks(deploymentManifest, regex string) ([]CellNetwork, error) {
+	var m manifest
+	if err := yaml.Unmarshal([]byte(deploymentManifest), &m); err != nil {
+		return nil, fmt.Errorf("Deployment manifest was invalid: %s", err)
+	}
+	var cellNetworks []CellNetwork
+	for _, group := range append(m.InstanceGroups, m.Jobs...) {
+		if matched, _ := regexp.MatchString(regex, group.Name); !matched {
+			continue
+		}
+		for _, groupNetwork := range group.Networks {
+			cellNetworks = append(cellNetworks, CellNetwork{Name: groupNetwork.Name, StaticIPs: groupNetwork.StaticIPs, AZs: group.AZs})
+		}
+	}
+	if len(cellNetworks) == 0 {
+		return nil, fmt.Errorf("No instance group matching %s was found in the deployment manifest", regex)
+	}
+	return cellNetworks, nil
+}
+
+// GetSubnetSources - returns the CIDRs of the cloud config subnets the cell networks use, in the AZs of the cell
+// instance groups. When excludeReserved is set the reserved and static ranges, the gateway and the network and
+// broadcast addresses of each subnet are removed, apart from the static IPs of the cells themselves. Networks whose addresses cannot be derived, such as dynamic and vip
+// networks, are returned as warnings
+func GetSubnetSources(cloudConfigs []string, cellNetworks []CellNetwork, excludeReserved bool) ([]string, []string, error) {
+	networks := make(map[string][]network)
+	for _, content := range cloudConfigs {
+		var config cloudConfig
+		if err := yaml.Unmarshal([]byte(content), &config); err != nil {
+			return nil, nil, fmt.Errorf("Cloud config was invalid: %s", err)
+		}
+		for _, n := range config.Networks {
+			networks[n.Name] = append(networks[n.Name], n)
+		}
+	}
+	var sources, warnings []string
+	for _, cellNetwork := range cellNetworks {
+		found, ok := networks[cellNetwork.Name]
+		if !ok {
+			return nil, nil, fmt.Errorf("Network %s could not be found in the cloud config", cellNetwork.Name)
+		}
+		for _, n := range found {
+			if n.Type != "" && n.Type != "manual" {
+				warnings = append(warnings, fmt.Sprintf("network %s is %s, its addresses cannot be derived from the cloud config", n.Name, n.Type))
+				continue
+			}
+			for _, s := range n.Subnets {
+				if s.Range == "" || !inAZs(s, cellNetwork.AZs) {
+					continue
+				}
+				subnetSources, err := s.sources(cellNetwork.StaticIPs, excludeReserved)
+				if err != nil {
+					return nil, nil, fmt.Errorf("Network %s: %s", n.Name, err)
+				}
+				sources = append(sources, subnetSources...)
+			}
+		}
+	}
+	utility.RemoveDuplicates(&sources)
+	render.SortAddresses(sources)
+	utility.RemoveDuplicates(&warnings)
+	sort.Strings(warnings)
+	return sources, warnings, nil
+}
+
+// sources - returns the subnet range as CIDRs, less its reserved and static ranges, gateway, network and broadcast
+// addresses but keeping staticIPs when excludeReserved is set
+func (s subnet) sources(staticIPs []string, excludeReserved bool) ([]string, error) {
+	subnetRange, err := render.ParseAddress(s.Range)
+	if err != nil {
+		return nil, err
+	}
+	if !excludeReserved {
+		return subnetRange.CIDRs(), nil
+	}
+	var excluded []render.Address
+	if subnetRange.Kind == render.Network && subnetRange.Start.Is4() && subnetRange.Prefix.Bits() < 31 {
+		excluded = append(excluded,
+			render.Address{Kind: render.Range, Start: subnetRange.Start, End: subnetRange.Start},
+			render.Address{Kind: render.Range, Start: subnetRange.End, End: subnetRange.End})
+	}
+	reserved := append(append([]string{}, s.Reserved...), s.Static...)
+	if s.Gateway != "" {
+		reserved = append(reserved, s.Gateway)
+	}
+	for _, address := range reserved {
+		parsed, err := render.ParseAddress(address)
+		if err != nil {
+			return nil, err
+		}
+		excluded = append(excluded, parsed)
+	}
+	var sources []string
+	for _, remaining := range subtractAddresses(subnetRange, excluded) {
+		sources = append(sources, remaining.CIDRs()...)
+	}
+	for _, staticIP := range staticIPs {
+		parsed, err := render.ParseAddress(staticIP)
+		if err != nil {
+			return nil, err
+		}
+		if !subnetRange.Contains(parsed) {
+			continue
+		}
+		if parsed.Kind == render.Host {
+			sources = append(sources, parsed.String())
+		} else {
+			sources = append(sources, parsed.CIDRs()...)
+		}
+	}
+	return sources, nil
+}
+
+// inAZs - returns true when the subnet is in one of the AZs, subnets and instance groups without AZs match any
+func inAZs(s subnet, azs []string) bool {
+	subnetAZs := append([]string{}, s.AZs...)
+	if s.AZ != "" {
+		subnetAZs = append(subnetAZs, s.AZ)
+	}
+	if len(subnetAZs) == 0 || len(azs) == 0 {
+		return true
+	}
+	for _, subnetAZ := range subnetAZs {
+		for _, az := range azs {
+			if subnetAZ == az {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// subtractAddresses - returns the ranges of from that are not in any of the excluded addresses
+func subtractAddresses(from render.Address, excluded []render.Address) []render.Address {
+	sort.Slice(excluded, func(i, j int) bool {
+		return excluded[i].Start.Less(excluded[j].Start)
+	})
+	var remaining []render.Address
+	start := from.Start
+	for _, address := range excluded {
+		if !from.Overlaps(address) || address.End.Less(start) {
+			continue
+		}
+		if start.Less(address.Start) {
+			remaining = append(remaining, render.Address{Kind: render.Range, Start: start, End: address.Start.Prev()})
+		}
+		if !address.End.Less(from.End) {
+			return remaining
+		}
+		start = address.End.Next()
+	}
+	return append(remaining, render.Address{Kind: render.Range, Start: start, End: from.End})
+}
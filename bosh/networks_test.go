@@ -0,0 +1,107 @@
+package bosh_test
+
+import (
+	"github.com/FidelityInternational/virgil/bosh"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("#FindCellNetworks", func() {
+	It("returns the networks of the matching instance groups", func() {
+		manifest := `
+instance_groups:
+- name: diego_cell
+  azs: [z1, z2]
+  networks:
+  - name: cf
+    static_ips: [10.0.16.20]
+- name: router
+  networks:
+  - name: edge
+`
+		cellNetworks, err := bosh.FindCellNetworks(manifest, "^diego_cell$")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cellNetworks).To(Equal([]bosh.CellNetwork{{Name: "cf", StaticIPs: []string{"10.0.16.20"}, AZs: []string{"z1", "z2"}}}))
+	})
+
+	It("supports v1 manifests with jobs", func() {
+		cellNetworks, err := bosh.FindCellNetworks("jobs:\n- name: dea_next\n  networks:\n  - name: cf1\n", "^dea")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cellNetworks).To(Equal([]bosh.CellNetwork{{Name: "cf1"}}))
+	})
+
+	It("returns an error when no instance group matches", func() {
+		_, err := bosh.FindCellNetworks("instance_groups:\n- name: router\n", "^diego_cell$")
+		Expect(err).To(MatchError("No instance group matching ^diego_cell$ was found in the deployment manifest"))
+	})
+
+	It("returns an error when the manifest is invalid", func() {
+		_, err := bosh.FindCellNetworks("instance_groups: [", "^diego_cell$")
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("#GetSubnetSources", func() {
+	var cloudConfig string
+
+	BeforeEach(func() {
+		cloudConfig = `
+networks:
+- name: cf
+  type: manual
+  subnets:
+  - range: 10.0.16.0/24
+    gateway: 10.0.16.1
+    azs: [z1]
+    reserved: [10.0.16.2 - 10.0.16.15]
+    static: [10.0.16.16 - 10.0.16.31]
+  - range: 10.0.17.0/24
+    az: z2
+- name: public
+  type: vip
+`
+	})
+
+	It("returns the subnets of the networks in the cells' AZs", func() {
+		sources, warnings, err := bosh.GetSubnetSources([]string{cloudConfig}, []bosh.CellNetwork{{Name: "cf", AZs: []string{"z2"}}}, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sources).To(Equal([]string{"10.0.17.0/24"}))
+		Expect(warnings).To(BeEmpty())
+	})
+
+	It("returns every subnet when the cells have no AZs", func() {
+		sources, _, err := bosh.GetSubnetSources([]string{cloudConfig}, []bosh.CellNetwork{{Name: "cf"}}, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sources).To(Equal([]string{"10.0.16.0/24", "10.0.17.0/24"}))
+	})
+
+	It("excludes reserved and static ranges, the gateway, network and broadcast addresses apart from the cells' static IPs", func() {
+		cellNetworks := []bosh.CellNetwork{{Name: "cf", StaticIPs: []string{"10.0.16.20"}, AZs: []string{"z1"}}}
+		sources, _, err := bosh.GetSubnetSources([]string{cloudConfig}, cellNetworks, true)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sources).To(Equal([]string{"10.0.16.20", "10.0.16.32/27", "10.0.16.64/26", "10.0.16.128/26", "10.0.16.192/27", "10.0.16.224/28", "10.0.16.240/29", "10.0.16.248/30", "10.0.16.252/31", "10.0.16.254/32"}))
+	})
+
+	It("warns about networks whose addresses cannot be derived", func() {
+		sources, warnings, err := bosh.GetSubnetSources([]string{cloudConfig}, []bosh.CellNetwork{{Name: "public"}}, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sources).To(BeEmpty())
+		Expect(warnings).To(Equal([]string{"network public is vip, its addresses cannot be derived from the cloud config"}))
+	})
+
+	It("finds networks across several cloud configs", func() {
+		sources, _, err := bosh.GetSubnetSources([]string{cloudConfig, "networks:\n- name: iso\n  subnets:\n  - range: 10.0.32.0/24\n"}, []bosh.CellNetwork{{Name: "iso"}}, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sources).To(Equal([]string{"10.0.32.0/24"}))
+	})
+
+	It("returns an error when a network cannot be found", func() {
+		_, _, err := bosh.GetSubnetSources([]string{cloudConfig}, []bosh.CellNetwork{{Name: "missing"}}, false)
+		Expect(err).To(MatchError("Network missing could not be found in the cloud config"))
+	})
+
+	It("returns an error when a range is invalid", func() {
+		_, _, err := bosh.GetSubnetSources([]string{"networks:\n- name: cf\n  subnets:\n  - range: nonsense\n"}, []bosh.CellNetwork{{Name: "cf"}}, false)
+		Expect(err).To(MatchError("Network cf: Address nonsense was invalid"))
+	})
+})
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/FidelityInternational/virgil"
+	"github.com/FidelityInternational/virgil/analysis"
+	"github.com/FidelityInternational/virgil/utility"
+	"github.com/urfave/cli"
+	"os"
+)
+
+// analyseCommand - "virgil analyse" reports redundant, shadowed and overlapping security group rules
+func analyseCommand(o *options) cli.Command {
+	var allGroups, jsonOutput bool
+	return cli.Command{
+		Name:      "analyse",
+		Usage:     "Report security group rules that are redundant, shadowed by a broader rule or overlap another rule",
+		UsageText: "virgil [global options] analyse [--all] [--json]",
+		Flags: []cli.Flag{
+			cli.BoolFlag{
+				Name:        "all",
+				Usage:       "Include security groups that are not bound to any space or enabled globally",
+				Destination: &allGroups,
+			},
+			cli.BoolFlag{
+				Name:        "json",
+				Usage:       "Write the findings as JSON",
+				Destination: &jsonOutput,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			cfClient, err := o.connectCF()
+			if err != nil {
+				return err
+			}
+			if !jsonOutput {
+				fmt.Println("CF\t- Fetching Security Groups...")
+			}
+			secGroups, err := virgil.NewCFSecurityGroupSource(cfClient).SecurityGroups(context.Background())
+			if err != nil {
+				return err
+			}
+			if !allGroups {
+				secGroups = utility.GetUsedSecGroups(secGroups)
+			}
+			report := analysis.AnalyseRules(secGroups)
+			if jsonOutput {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(report)
+			}
+			_, err = report.WriteTo(os.Stdout)
+			return err
+		},
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/FidelityInternational/virgil/analysis"
+	"github.com/FidelityInternational/virgil/fakes"
+	"github.com/cloudfoundry/go-cfclient/v3/resource"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("virgil analyse", func() {
+	var (
+		cfServer *fakes.CFServer
+		args     []string
+	)
+
+	BeforeEach(func() {
+		cfServer = fakes.NewCFServer([]resource.SecurityGroup{
+			fakes.SecurityGroup("internal", fakes.Rule("all", "10.0.0.0/8", "")),
+			fakes.SecurityGroup("web", fakes.Rule("tcp", "10.1.1.1", "443")),
+		})
+		args = virgilArgs(cfServer, nil, "analyse")
+	})
+
+	AfterEach(func() {
+		cfServer.Close()
+	})
+
+	It("reports the rules without needing BOSH", func() {
+		output, err := captureStdout(args)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output).To(ContainSubstring("Virgil\t- 1 shadowed rules\n  web #1: tcp 443 10.1.1.1 is covered by internal #1: all 10.0.0.0/8\n"))
+		Expect(output).To(ContainSubstring("Virgil\t- 0 redundant rules\n"))
+	})
+
+	It("reports the findings as JSON", func() {
+		output, err := captureStdout(append(args, "--all", "--json"))
+		Expect(err).ToNot(HaveOccurred())
+		var report analysis.RuleReport
+		Expect(json.Unmarshal([]byte(output), &report)).To(Succeed())
+		Expect(report.Findings).To(HaveLen(1))
+		Expect(report.Findings[0].Kind).To(Equal(analysis.Shadowed))
+		Expect(report.Findings[0].Rule.SecurityGroup).To(Equal("web"))
+		Expect(report.Findings[0].By.SecurityGroup).To(Equal("internal"))
+	})
+
+	It("returns an error when the CF API fails", func() {
+		cfServer.Fail(1)
+		Expect(newApp().Run(args)).ToNot(Succeed())
+	})
+
+	It("returns an error when required flags are missing", func() {
+		Expect(newApp().Run([]string{"virgil", "analyse"})).To(MatchError("cf-system-domain, cf-user and cf-password must all be set"))
+	})
+})
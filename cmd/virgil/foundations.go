@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/FidelityInternational/virgil"
+	"github.com/FidelityInternational/virgil/render"
+	"gopkg.in/yaml.v2"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// foundationOutputs - how the policies of several foundations are written
+var foundationOutputs = []string{"merged", "separate"}
+
+// foundationConfig - the connection details of one CF foundation and its BOSH director in a foundations file
+type foundationConfig struct {
+	Name              string `yaml:"name"`
+	CFSystemDomain    string `yaml:"cf_system_domain"`
+	CFAPIURL          string `yaml:"cf_api_url"`
+	CFUser            string `yaml:"cf_user"`
+	CFPassword        string `yaml:"cf_password"`
+	BOSHURI           string `yaml:"bosh_uri"`
+	BOSHUser          string `yaml:"bosh_user"`
+	BOSHPassword      string `yaml:"bosh_password"`
+	SkipSSLValidation bool   `yaml:"skip_ssl_validation"`
+	DeploymentRegex   string `yaml:"deployment_regex"`
+	JobRegex          string `yaml:"job_regex"`
+}
+
+// foundationsFile - the top level of a foundations file
+type foundationsFile struct {
+	Foundations []foundationConfig `yaml:"foundations"`
+}
+
+// loadFoundations - reads and validates a foundations file
+func loadFoundations(path string) ([]foundationConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file foundationsFile
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return nil, fmt.Errorf("Foundations file %s was invalid: %s", path, err)
+	}
+	if len(file.Foundations) == 0 {
+		return nil, fmt.Errorf("Foundations file %s has no foundations", path)
+	}
+	names := make(map[string]bool)
+	for i, foundation := range file.Foundations {
+		if foundation.Name == "" {
+			return nil, fmt.Errorf("Foundation %d has no name", i+1)
+		}
+		if names[foundation.Name] {
+			return nil, fmt.Errorf("Foundation %s is defined more than once", foundation.Name)
+		}
+		names[foundation.Name] = true
+		if foundation.CFSystemDomain == "" || foundation.CFUser == "" || foundation.CFPassword == "" || foundation.BOSHURI == "" || foundation.BOSHUser == "" || foundation.BOSHPassword == "" {
+			return nil, fmt.Errorf("Foundation %s: cf_system_domain, cf_user, cf_password, bosh_user, bosh_password and bosh_uri must all be set", foundation.Name)
+		}
+	}
+	return file.Foundations, nil
+}
+
+// options - returns a copy of the global options connecting to the foundation instead
+func (f foundationConfig) options(o *options) *options {
+	foundationOptions := *o
+	foundationOptions.systemDomain, foundationOptions.cfAPIURL = f.CFSystemDomain, f.CFAPIURL
+	foundationOptions.cfUser, foundationOptions.cfPassword = f.CFUser, f.CFPassword
+	foundationOptions.boshURI, foundationOptions.boshUser, foundationOptions.boshPassword = f.BOSHURI, f.BOSHUser, f.BOSHPassword
+	foundationOptions.skipSSLValidation = f.SkipSSLValidation
+	return &foundationOptions
+}
+
+// writeFoundations - generates the policy of every foundation in the foundations file concurrently, writing them
+// merged into outputFile or to a file per foundation named after it
+func (o *options) writeFoundations(ctx context.Context, outputFile string) error {
+	if !containsString(foundationOutputs, o.foundationsOutput) {
+		return fmt.Errorf("Foundations output %s is not supported, valid outputs are %s", o.foundationsOutput, strings.Join(foundationOutputs, ", "))
+	}
+	foundations, err := loadFoundations(o.foundationsFile)
+	if err != nil {
+		return err
+	}
+	var generators []*virgil.Generator
+	for i, foundation := range foundations {
+		foundationOptions := foundation.options(o)
+		cfClient, boshClient, err := foundationOptions.connect()
+		if err != nil {
+			return fmt.Errorf("Foundation %s: %s", foundation.Name, err)
+		}
+		if i == 0 {
+			if err := o.configureRenderers(ctx, cfClient); err != nil {
+				return err
+			}
+		}
+		generatorOptions := []virgil.Option{virgil.WithFoundation(foundation.Name)}
+		if foundation.DeploymentRegex != "" {
+			generatorOptions = append(generatorOptions, virgil.WithDeploymentRegex(foundation.DeploymentRegex))
+		}
+		if foundation.JobRegex != "" {
+			generatorOptions = append(generatorOptions, virgil.WithJobRegex(foundation.JobRegex))
+		}
+		generators = append(generators, foundationOptions.newGenerator(cfClient, boshClient, generatorOptions...))
+	}
+	renderer, err := render.Get(o.format)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Virgil\t- Generating Firewall Rules for %d foundations...\n", len(generators))
+	results, err := virgil.GenerateAll(ctx, generators)
+	if err != nil {
+		return err
+	}
+	for _, result := range results {
+		fmt.Printf("Virgil\t- %s: %d firewall rules for %d cells\n", result.Metadata.Foundation, len(result.FirewallRules.FirewallRules), len(result.Sources))
+	}
+	if o.foundationsOutput == "merged" {
+		return writePolicy(renderer, virgil.MergeResults(results), outputFile)
+	}
+	for _, result := range results {
+		if err := writePolicy(renderer, result, foundationFile(outputFile, result.Metadata.Foundation)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writePolicy - renders the result to a file
+func writePolicy(renderer render.Renderer, result virgil.Result, outputFile string) error {
+	var output bytes.Buffer
+	fmt.Printf("Virgil\t- Rendering Firewall Rules as %s...\n", renderer.Name())
+	if err := renderer.Render(&output, result.FirewallRules, result.Metadata); err != nil {
+		return err
+	}
+	if err := os.WriteFile(outputFile, output.Bytes(), os.FileMode(0644)); err != nil {
+		return err
+	}
+	fmt.Println("Firewall Policy written to file: ", outputFile)
+	return nil
+}
+
+// foundationFile - returns the output file of a foundation, such as policy-dc1.yml for policy.yml
+func foundationFile(outputFile, foundation string) string {
+	extension := filepath.Ext(outputFile)
+	return fmt.Sprintf("%s-%s%s", strings.TrimSuffix(outputFile, extension), render.SanitiseName(foundation, 0), extension)
+}
+
+func containsString(xs []string, x string) bool {
+	for _, s := range xs {
+		if s == x {
+			return true
+		}
+	}
+	return false
+}
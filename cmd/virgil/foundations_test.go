@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"github.com/FidelityInternational/virgil/fakes"
+	"github.com/cloudfoundry-community/gogobosh"
+	"github.com/cloudfoundry/go-cfclient/v3/resource"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"os"
+	"path/filepath"
+)
+
+var _ = Describe("virgil --foundations", func() {
+	var (
+		cfServers       []*fakes.CFServer
+		boshServers     []*fakes.BOSHServer
+		tempDir         string
+		foundationsFile string
+		outputFile      string
+	)
+
+	BeforeEach(func() {
+		cfServers = []*fakes.CFServer{
+			fakes.NewCFServer([]resource.SecurityGroup{fakes.SecurityGroup("web", fakes.Rule("tcp", "10.1.0.0/16", "443"))}),
+			fakes.NewCFServer([]resource.SecurityGroup{fakes.SecurityGroup("web", fakes.Rule("tcp", "10.1.0.0/16", "443"))}),
+		}
+		boshServers = []*fakes.BOSHServer{
+			fakes.NewBOSHServer(map[string][]gogobosh.VM{"cf-123": {{JobName: "diego_cell", IPs: []string{"10.0.16.1"}}}}),
+			fakes.NewBOSHServer(map[string][]gogobosh.VM{"cf-b": {{JobName: "diego_cell", IPs: []string{"10.8.16.1"}}}}),
+		}
+		var err error
+		tempDir, err = os.MkdirTemp("", "virgil")
+		Expect(err).ToNot(HaveOccurred())
+		foundationsFile = filepath.Join(tempDir, "foundations.yml")
+		outputFile = filepath.Join(tempDir, "policy.yml")
+		config := "foundations:\n"
+		for i, name := range []string{"dc1-a", "dc1-b"} {
+			config += fmt.Sprintf(`- name: %s
+  cf_system_domain: sys.%s.example.com
+  cf_api_url: %s
+  cf_user: admin
+  cf_password: admin
+  bosh_uri: %s
+  bosh_user: admin
+  bosh_password: admin
+`, name, name, cfServers[i].URL, boshServers[i].URL)
+		}
+		Expect(os.WriteFile(foundationsFile, []byte(config+"  deployment_regex: ^cf-b$\n"), 0644)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		for i := range cfServers {
+			cfServers[i].Close()
+			boshServers[i].Close()
+		}
+		os.RemoveAll(tempDir)
+	})
+
+	It("merges the policies of every foundation", func() {
+		Expect(newApp().Run([]string{"virgil", "--foundations", foundationsFile, outputFile})).To(Succeed())
+		policy, err := os.ReadFile(outputFile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(policy)).To(Equal(`---
+schema_version: "1"
+firewall_rules:
+- port: "443"
+  destination:
+  - 10.1.0.0/16
+  protocol: tcp
+  source:
+  - 10.0.16.1
+  - 10.8.16.1
+`))
+	})
+
+	It("writes a policy per foundation", func() {
+		Expect(newApp().Run([]string{"virgil", "--foundations", foundationsFile, "--foundations-output", "separate", outputFile})).To(Succeed())
+		for _, name := range []string{"dc1-a", "dc1-b"} {
+			_, err := os.Stat(filepath.Join(tempDir, fmt.Sprintf("policy-%s.yml", name)))
+			Expect(err).ToNot(HaveOccurred())
+		}
+		_, err := os.Stat(outputFile)
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("writes the metrics of each foundation", func() {
+		metricsFile := filepath.Join(tempDir, "virgil.prom")
+		Expect(newApp().Run([]string{"virgil", "--foundations", foundationsFile, "--metrics-file", metricsFile, outputFile})).To(Succeed())
+		metrics, err := os.ReadFile(metricsFile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(metrics)).To(ContainSubstring(`virgil_sources{foundation="dc1-a"} 1`))
+		Expect(string(metrics)).To(ContainSubstring(`virgil_sources{foundation="dc1-b"} 1`))
+	})
+
+	It("returns the error of a failing foundation", func() {
+		cfServers[1].Fail(1)
+		Expect(newApp().Run([]string{"virgil", "--foundations", foundationsFile, outputFile})).To(MatchError(ContainSubstring("Foundation dc1-b: ")))
+	})
+
+	It("returns an error for an invalid foundations file", func() {
+		Expect(os.WriteFile(foundationsFile, []byte("foundations:\n- name: dc1-a\n"), 0644)).To(Succeed())
+		Expect(newApp().Run([]string{"virgil", "--foundations", foundationsFile, outputFile})).To(MatchError("Foundation dc1-a: cf_system_domain, cf_user, cf_password, bosh_user, bosh_password and bosh_uri must all be set"))
+		Expect(os.WriteFile(foundationsFile, []byte("foundations: []\n"), 0644)).To(Succeed())
+		Expect(newApp().Run([]string{"virgil", "--foundations", foundationsFile, outputFile})).To(MatchError(fmt.Sprintf("Foundations file %s has no foundations", foundationsFile)))
+		Expect(newApp().Run([]string{"virgil", "--foundations", foundationsFile, "--foundations-output", "tagged", outputFile})).To(MatchError("Foundations output tagged is not supported, valid outputs are merged, separate"))
+		Expect(newApp().Run([]string{"virgil", "--foundations", foundationsFile})).To(MatchError("output_file must be set"))
+	})
+})
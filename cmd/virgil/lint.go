@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/FidelityInternational/virgil"
+	"github.com/FidelityInternational/virgil/lint"
+	"github.com/urfave/cli"
+	"io"
+	"os"
+	"strings"
+)
+
+// lintCommand - "virgil lint" checks the generated policy for risky rules, failing above a severity threshold
+func lintCommand(o *options) cli.Command {
+	var (
+		configFile, constraintsFile, threshold string
+		internalNetworks                       string
+		jsonOutput                             bool
+	)
+	return cli.Command{
+		Name:      "lint",
+		Usage:     fmt.Sprintf("Check the firewall policy for risky rules: %s", strings.Join(lint.CheckNames(), ", ")),
+		UsageText: "virgil [global options] lint [--config lint.yml] [--constraints constraints.yml] [--threshold high] [--internal-networks cidr,...] [--json]",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:        "config",
+				Usage:       "YAML file setting the threshold, check severities, risky ports and internal networks",
+				Destination: &configFile,
+			},
+			cli.StringFlag{
+				Name:        "constraints",
+				Usage:       "YAML file of organisation constraints, violations name the security groups that break them",
+				Destination: &constraintsFile,
+			},
+			cli.StringFlag{
+				Name:        "threshold",
+				Usage:       "Fail when a finding has this severity or above, one of info, low, medium, high or critical. Defaults to high",
+				Destination: &threshold,
+			},
+			cli.StringFlag{
+				Name:        "internal-networks",
+				Usage:       "Comma separated platform internal networks, such as the BOSH and CF networks, that apps should not reach",
+				Destination: &internalNetworks,
+			},
+			cli.BoolFlag{
+				Name:        "json",
+				Usage:       "Write the findings as JSON",
+				Destination: &jsonOutput,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			config := lint.DefaultConfig()
+			if configFile != "" {
+				data, err := os.ReadFile(configFile)
+				if err != nil {
+					return err
+				}
+				if config, err = lint.ParseConfig(data); err != nil {
+					return err
+				}
+			}
+			if constraintsFile != "" {
+				data, err := os.ReadFile(constraintsFile)
+				if err != nil {
+					return err
+				}
+				constraints, err := lint.ParseConstraints(data)
+				if err != nil {
+					return err
+				}
+				config.Constraints = append(config.Constraints, constraints...)
+			}
+			if threshold != "" {
+				severity, err := lint.ParseSeverity(threshold)
+				if err != nil {
+					return err
+				}
+				config.Threshold = severity
+			}
+			if internalNetworks != "" {
+				config.InternalNetworks = append(config.InternalNetworks, strings.Split(internalNetworks, ",")...)
+			}
+			if err := config.Validate(); err != nil {
+				return err
+			}
+			cfClient, boshClient, err := o.connect()
+			if err != nil {
+				return err
+			}
+			var progress io.Writer = os.Stdout
+			if jsonOutput {
+				progress = io.Discard
+			}
+			result, err := o.newGenerator(cfClient, boshClient, virgil.WithProgress(progress)).Generate(context.Background())
+			if err != nil {
+				return err
+			}
+			report, err := lint.Lint(result, config)
+			if err != nil {
+				return err
+			}
+			if jsonOutput {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(report); err != nil {
+					return err
+				}
+			} else {
+				report.WriteTo(os.Stdout)
+			}
+			if report.Failed() {
+				return fmt.Errorf("%d lint findings are %s or above", len(report.AtOrAbove(config.Threshold)), config.Threshold)
+			}
+			return nil
+		},
+	}
+}
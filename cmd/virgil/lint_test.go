@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/FidelityInternational/virgil/fakes"
+	"github.com/FidelityInternational/virgil/lint"
+	"github.com/cloudfoundry-community/gogobosh"
+	"github.com/cloudfoundry/go-cfclient/v3/resource"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"os"
+	"path/filepath"
+)
+
+var _ = Describe("virgil lint", func() {
+	var (
+		cfServer   *fakes.CFServer
+		boshServer *fakes.BOSHServer
+		args       []string
+	)
+
+	BeforeEach(func() {
+		cfServer = fakes.NewCFServer([]resource.SecurityGroup{
+			fakes.SecurityGroup("web", fakes.Rule("tcp", "10.1.0.0/16", "443")),
+			fakes.SecurityGroup("ssh", fakes.Rule("tcp", "10.2.0.0/16", "22")),
+		})
+		boshServer = fakes.NewBOSHServer(map[string][]gogobosh.VM{
+			"cf-123": {{JobName: "diego_cell", IPs: []string{"10.0.16.1"}}},
+		})
+		args = virgilArgs(cfServer, boshServer, "lint")
+	})
+
+	AfterEach(func() {
+		cfServer.Close()
+		boshServer.Close()
+	})
+
+	It("fails when a finding is at or above the threshold", func() {
+		Expect(newApp().Run(args)).To(MatchError("1 lint findings are high or above"))
+	})
+
+	It("succeeds when the findings are below the threshold", func() {
+		output, err := captureStdout(append(args, "--threshold", "critical", "--json"))
+		Expect(err).ToNot(HaveOccurred())
+		var report lint.Report
+		Expect(json.Unmarshal([]byte(output), &report)).To(Succeed())
+		Expect(report.Threshold).To(Equal(lint.Critical))
+		Expect(report.Findings).To(HaveLen(1))
+		Expect(report.Findings[0].Severity).To(Equal(lint.High))
+		Expect(report.Findings[0].Port).To(Equal("22"))
+		Expect(report.Findings[0].SecurityGroups).To(Equal([]string{"ssh"}))
+	})
+
+	It("writes only the JSON report to stdout when the findings are at or above the threshold", func() {
+		output, err := captureStdout(append(args, "--json"))
+		Expect(err).To(MatchError("1 lint findings are high or above"))
+		var report lint.Report
+		Expect(json.Unmarshal([]byte(output), &report)).To(Succeed())
+		Expect(report.Findings).To(HaveLen(1))
+	})
+
+	It("reads the lint configuration file", func() {
+		tempDir, err := os.MkdirTemp("", "virgil")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(tempDir)
+		configFile := filepath.Join(tempDir, "lint.yml")
+		Expect(os.WriteFile(configFile, []byte("checks:\n  risky-port:\n    disabled: true\n"), 0644)).To(Succeed())
+		Expect(newApp().Run(append(args, "--config", configFile))).To(Succeed())
+		Expect(newApp().Run(append(args, "--config", configFile, "--internal-networks", "10.1.0.0/24"))).To(MatchError("1 lint findings are high or above"))
+	})
+
+	It("fails when a constraint is violated", func() {
+		tempDir, err := os.MkdirTemp("", "virgil")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(tempDir)
+		constraintsFile := filepath.Join(tempDir, "constraints.yml")
+		Expect(os.WriteFile(constraintsFile, []byte("constraints:\n- name: web-https-only\n  severity: critical\n  match: {destinations: [10.1.0.0/16]}\n  allow: {ports: [\"8443\"]}\n"), 0644)).To(Succeed())
+		Expect(newApp().Run(append(args, "--constraints", constraintsFile, "--threshold", "critical"))).To(MatchError("1 lint findings are critical or above"))
+	})
+
+	It("returns an error for an invalid threshold", func() {
+		Expect(newApp().Run(append(args, "--threshold", "severe"))).To(MatchError(ContainSubstring("Severity severe was invalid")))
+	})
+})
@@ -0,0 +1,380 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/FidelityInternational/virgil"
+	"github.com/FidelityInternational/virgil/metrics"
+	"github.com/FidelityInternational/virgil/render"
+	"github.com/cloudfoundry/go-cfclient/v3/client"
+	"github.com/urfave/cli"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+// interruptContext - returns a context cancelled on SIGINT or SIGTERM, used by long running commands
+var interruptContext = func() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+func main() {
+	if err := newApp().Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+}
+
+// newApp - returns the virgil CLI, each call has its own flag values
+func newApp() *cli.App {
+	o := &options{recorder: metrics.NewRecorder()}
+
+	app := cli.NewApp()
+	app.Name = "virgil"
+	app.Usage = "A CLI App to return a list of firewall rules based on Cloud Foundry Security Groups"
+	app.UsageText = "virgil [options] output_file"
+	app.Version = "1.0.0"
+	app.Flags = []cli.Flag{
+		cli.StringFlag{
+			Name:        "cf-system-domain, csd",
+			Usage:       "Cloud Foundry System Domain",
+			Destination: &o.systemDomain,
+		},
+		cli.StringFlag{
+			Name:        "cf-user, cu",
+			Usage:       "Cloud Foundry Admin User",
+			Destination: &o.cfUser,
+		},
+		cli.StringFlag{
+			Name:        "cf-password, cp",
+			Usage:       "Cloud Foundry Admin Password",
+			Destination: &o.cfPassword,
+		},
+		cli.StringFlag{
+			Name:        "cf-api-url",
+			Usage:       "Cloud Foundry API URL, defaults to https://api.<cf-system-domain>",
+			Destination: &o.cfAPIURL,
+		},
+		cli.StringFlag{
+			Name:        "bosh-user, bu",
+			Usage:       "BOSH User",
+			Destination: &o.boshUser,
+		},
+		cli.StringFlag{
+			Name:        "bosh-password, bp",
+			Usage:       "BOSH Password",
+			Destination: &o.boshPassword,
+		},
+		cli.StringFlag{
+			Name:        "bosh-uri, buri",
+			Usage:       "BOSH URI",
+			Destination: &o.boshURI,
+		},
+		cli.BoolFlag{
+			Name:        "skip-ssl-validation, skip-ssl",
+			Usage:       "Skip SSL Validation",
+			Destination: &o.skipSSLValidation,
+		},
+		cli.StringFlag{
+			Name:        "format, f",
+			Usage:       fmt.Sprintf("Output format: %s", strings.Join(render.Names(), ", ")),
+			Value:       "yaml",
+			Destination: &o.format,
+		},
+		cli.StringFlag{
+			Name:        "panos-output",
+			Usage:       "PAN-OS output style: xml or set",
+			Value:       "xml",
+			Destination: &o.panosOutput,
+		},
+		cli.StringFlag{
+			Name:        "panos-prefix",
+			Usage:       "Prefix for PAN-OS object and rule names",
+			Value:       "virgil",
+			Destination: &o.panosOptions.Prefix,
+		},
+		cli.StringFlag{
+			Name:        "panos-vsys",
+			Usage:       "PAN-OS virtual system for XML output",
+			Value:       "vsys1",
+			Destination: &o.panosOptions.Vsys,
+		},
+		cli.StringFlag{
+			Name:        "panos-from-zone",
+			Usage:       "PAN-OS source zone for security rules",
+			Value:       "any",
+			Destination: &o.panosOptions.FromZone,
+		},
+		cli.StringFlag{
+			Name:        "panos-to-zone",
+			Usage:       "PAN-OS destination zone for security rules",
+			Value:       "any",
+			Destination: &o.panosOptions.ToZone,
+		},
+		cli.StringFlag{
+			Name:        "asa-prefix",
+			Usage:       "Prefix for Cisco ASA object and object-group names",
+			Value:       "virgil",
+			Destination: &o.asaOptions.Prefix,
+		},
+		cli.StringFlag{
+			Name:        "asa-access-list",
+			Usage:       "Cisco ASA access-list name",
+			Value:       "virgil-egress",
+			Destination: &o.asaOptions.AccessList,
+		},
+		cli.StringFlag{
+			Name:        "srx-prefix",
+			Usage:       "Prefix for Juniper SRX address, application and policy names",
+			Value:       "virgil",
+			Destination: &o.srxOptions.Prefix,
+		},
+		cli.StringFlag{
+			Name:        "srx-address-book",
+			Usage:       "Juniper SRX address-book to define addresses in",
+			Value:       "global",
+			Destination: &o.srxOptions.AddressBook,
+		},
+		cli.StringFlag{
+			Name:        "srx-from-zone",
+			Usage:       "Juniper SRX source zone for security policies",
+			Value:       "trust",
+			Destination: &o.srxOptions.FromZone,
+		},
+		cli.StringFlag{
+			Name:        "srx-to-zone",
+			Usage:       "Juniper SRX destination zone for security policies",
+			Value:       "untrust",
+			Destination: &o.srxOptions.ToZone,
+		},
+		cli.StringFlag{
+			Name:        "fortigate-output",
+			Usage:       "FortiGate output style: cli or rest",
+			Value:       "cli",
+			Destination: &o.fortigateOutput,
+		},
+		cli.StringFlag{
+			Name:        "fortigate-prefix",
+			Usage:       "Prefix for FortiGate object and policy names",
+			Value:       "virgil",
+			Destination: &o.fortigateOptions.Prefix,
+		},
+		cli.StringFlag{
+			Name:        "fortigate-srcintf",
+			Usage:       "FortiGate source interface for firewall policies",
+			Value:       "any",
+			Destination: &o.fortigateOptions.SrcInterface,
+		},
+		cli.StringFlag{
+			Name:        "fortigate-dstintf",
+			Usage:       "FortiGate destination interface for firewall policies",
+			Value:       "any",
+			Destination: &o.fortigateOptions.DstInterface,
+		},
+		cli.StringFlag{
+			Name:        "checkpoint-output",
+			Usage:       "Check Point output style: json or mgmt_cli",
+			Value:       "json",
+			Destination: &o.checkpointOutput,
+		},
+		cli.StringFlag{
+			Name:        "checkpoint-prefix",
+			Usage:       "Prefix for Check Point object and rule names",
+			Value:       "virgil",
+			Destination: &o.checkpointOptions.Prefix,
+		},
+		cli.StringFlag{
+			Name:        "checkpoint-layer",
+			Usage:       "Check Point access layer to add rules to",
+			Value:       "Network",
+			Destination: &o.checkpointOptions.Layer,
+		},
+		cli.StringFlag{
+			Name:        "checkpoint-position",
+			Usage:       "Check Point access rule position",
+			Value:       "bottom",
+			Destination: &o.checkpointOptions.Position,
+		},
+		cli.StringFlag{
+			Name:        "nsxt-prefix",
+			Usage:       "Prefix for NSX-T group, service and rule IDs",
+			Value:       "virgil",
+			Destination: &o.nsxtOptions.Prefix,
+		},
+		cli.StringFlag{
+			Name:        "nsxt-domain",
+			Usage:       "NSX-T domain to create groups and the security policy in",
+			Value:       "default",
+			Destination: &o.nsxtOptions.Domain,
+		},
+		cli.StringFlag{
+			Name:        "nsxt-policy-id",
+			Usage:       "NSX-T security policy ID (defaults to <prefix>-egress)",
+			Destination: &o.nsxtOptions.PolicyID,
+		},
+		cli.StringFlag{
+			Name:        "nsxt-category",
+			Usage:       "NSX-T distributed firewall category for the security policy",
+			Value:       "Application",
+			Destination: &o.nsxtOptions.Category,
+		},
+		cli.StringFlag{
+			Name:        "k8s-policy-name",
+			Usage:       "Name of the generated Kubernetes, Calico or Cilium policies",
+			Value:       "virgil-egress",
+			Destination: &o.k8sOptions.PolicyName,
+		},
+		cli.StringFlag{
+			Name:        "k8s-namespace",
+			Usage:       "Namespace for the generated policy when no namespace mapping is given",
+			Value:       "default",
+			Destination: &o.k8sNamespace,
+		},
+		cli.StringFlag{
+			Name:        "k8s-namespace-mapping",
+			Usage:       "YAML file mapping Kubernetes namespaces to lists of CF space GUIDs or org/space names",
+			Destination: &o.k8sNamespaceMapping,
+		},
+		cli.BoolFlag{
+			Name:        "table-explode",
+			Usage:       "Write a CSV/XLSX row per source, destination and port rather than per firewall rule",
+			Destination: &o.tableOptions.Explode,
+		},
+		cli.StringFlag{
+			Name:        "table-columns",
+			Usage:       "Comma separated CSV/XLSX columns from protocol, port, source, destination and security_groups",
+			Value:       strings.Join(render.TableColumns, ","),
+			Destination: &o.tableColumns,
+		},
+		cli.StringFlag{
+			Name:        "report-template",
+			Usage:       "Template file replacing the built in html or markdown report template",
+			Destination: &o.reportTemplate,
+		},
+		cli.StringFlag{
+			Name:        "template",
+			Usage:       "Go text/template file executed over the firewall rules for the template format",
+			Destination: &o.templateFile,
+		},
+		cli.StringFlag{
+			Name:        "sources",
+			Usage:       "Where firewall rule sources are taken from: vms, the IPs of the cell VMs, or subnets, the cloud config subnets of the networks the cells are placed on",
+			Value:       "vms",
+			Destination: &o.sources,
+		},
+		cli.BoolFlag{
+			Name:        "exclude-reserved-ranges",
+			Usage:       "Leave the reserved and static ranges of each subnet out of the sources, apart from the cells' own static IPs, when --sources is subnets",
+			Destination: &o.excludeReservedRanges,
+		},
+		cli.StringFlag{
+			Name:        "foundations",
+			Usage:       "YAML file listing several CF foundations and BOSH directors to generate one run for, replacing the CF and BOSH flags",
+			Destination: &o.foundationsFile,
+		},
+		cli.StringFlag{
+			Name:        "foundations-output",
+			Usage:       "How the policies of --foundations are written: merged into output_file, or separate files named after each foundation",
+			Value:       "merged",
+			Destination: &o.foundationsOutput,
+		},
+		cli.StringFlag{
+			Name:        "metrics-file",
+			Usage:       "File Prometheus metrics are written to after each run, for the node_exporter textfile collector",
+			Destination: &o.metricsFile,
+		},
+	}
+	app.Action = func(c *cli.Context) error {
+		if o.foundationsFile != "" {
+			if c.NArg() == 0 {
+				return errors.New("output_file must be set")
+			}
+			if _, err := render.Get(o.format); err != nil {
+				return err
+			}
+			return o.writeFoundations(context.Background(), c.Args()[0])
+		}
+		if o.systemDomain == "" || o.cfUser == "" || o.cfPassword == "" || c.NArg() == 0 || o.boshUser == "" || o.boshPassword == "" || o.boshURI == "" {
+			return errors.New("cf-system-domain, cf-user, cf-password, bosh-user, bosh-password, bosh-uri and output_file must all be set")
+		}
+		if _, err := render.Get(o.format); err != nil {
+			return err
+		}
+		cfClient, boshClient, err := o.connect()
+		if err != nil {
+			return err
+		}
+		ctx := context.Background()
+		if err := o.configureRenderers(ctx, cfClient); err != nil {
+			return err
+		}
+		renderer, err := render.Get(o.format)
+		if err != nil {
+			return err
+		}
+		var output bytes.Buffer
+		if err := o.newGenerator(cfClient, boshClient, virgil.WithRenderer(renderer), virgil.WithProgress(os.Stdout)).Render(ctx, &output); err != nil {
+			return err
+		}
+		if err := os.WriteFile(c.Args()[0], output.Bytes(), os.FileMode(0644)); err != nil {
+			return err
+		}
+		fmt.Println("Firewall Policy written to file: ", c.Args()[0])
+		return nil
+	}
+	app.Commands = []cli.Command{
+		serveCommand(o),
+		watchCommand(o),
+		verifyCommand(o),
+		lintCommand(o),
+		analyseCommand(o),
+		unusedCommand(o),
+		queryCommand(o),
+		spacePolicyCommand(o),
+	}
+	return app
+}
+
+// loadNamespaceMapping - reads a namespace mapping file, resolving org/space names to space GUIDs
+func loadNamespaceMapping(ctx context.Context, cfClient *client.Client, mappingFile string) (render.NamespaceMapping, error) {
+	data, err := os.ReadFile(mappingFile)
+	if err != nil {
+		return nil, err
+	}
+	mapping, err := render.ParseNamespaceMapping(data)
+	if err != nil {
+		return nil, err
+	}
+	spaceGUIDs := make(map[string]string)
+	for namespace, spaces := range mapping {
+		for i, space := range spaces {
+			if !strings.Contains(space, "/") {
+				continue
+			}
+			if len(spaceGUIDs) == 0 {
+				allSpaces, orgs, err := cfClient.Spaces.ListIncludeOrganizationsAll(ctx, nil)
+				if err != nil {
+					return nil, err
+				}
+				orgNames := make(map[string]string)
+				for _, org := range orgs {
+					orgNames[org.GUID] = org.Name
+				}
+				for _, s := range allSpaces {
+					if s.Relationships != nil && s.Relationships.Organization != nil && s.Relationships.Organization.Data != nil {
+						spaceGUIDs[fmt.Sprintf("%s/%s", orgNames[s.Relationships.Organization.Data.GUID], s.Name)] = s.GUID
+					}
+				}
+			}
+			guid, ok := spaceGUIDs[space]
+			if !ok {
+				return nil, fmt.Errorf("Space %s in namespace %s could not be found", space, namespace)
+			}
+			mapping[namespace][i] = guid
+		}
+	}
+	return mapping, nil
+}
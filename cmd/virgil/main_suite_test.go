@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"github.com/FidelityInternational/virgil/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestVirgilCLI(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Virgil CLI test suite")
+}
+
+// virgilArgs - returns the global flags pointing virgil at the fake CF API, and the fake BOSH director when
+// boshServer is set, followed by commandArgs
+func virgilArgs(cfServer *fakes.CFServer, boshServer *fakes.BOSHServer, commandArgs ...string) []string {
+	args := []string{
+		"virgil",
+		"--cf-system-domain", "sys.example.com",
+		"--cf-api-url", cfServer.URL,
+		"--cf-user", "admin",
+		"--cf-password", "admin",
+	}
+	if boshServer != nil {
+		args = append(args,
+			"--bosh-uri", boshServer.URL,
+			"--bosh-user", "admin",
+			"--bosh-password", "admin",
+		)
+	}
+	return append(args, commandArgs...)
+}
+
+// captureStdout - runs virgil with args, returning what it wrote to stdout along with the result of the run
+func captureStdout(args []string) (string, error) {
+	reader, writer, err := os.Pipe()
+	Expect(err).ToNot(HaveOccurred())
+	stdout := os.Stdout
+	os.Stdout = writer
+	output := make(chan string)
+	go func() {
+		var buffer bytes.Buffer
+		io.Copy(&buffer, reader)
+		output <- buffer.String()
+	}()
+	err = newApp().Run(args)
+	os.Stdout = stdout
+	writer.Close()
+	return <-output, err
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"github.com/FidelityInternational/virgil/fakes"
+	"github.com/cloudfoundry-community/gogobosh"
+	"github.com/cloudfoundry/go-cfclient/v3/resource"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"os"
+	"path/filepath"
+)
+
+var _ = Describe("virgil", func() {
+	var (
+		cfServer   *fakes.CFServer
+		boshServer *fakes.BOSHServer
+		outputFile string
+		args       []string
+	)
+
+	BeforeEach(func() {
+		cfServer = fakes.NewCFServer([]resource.SecurityGroup{
+			fakes.SecurityGroup("web", fakes.Rule("tcp", "10.1.0.0/16", "443")),
+			fakes.SecurityGroup("ping", fakes.Rule("icmp", "10.3.0.1", "")),
+		})
+		boshServer = fakes.NewBOSHServer(map[string][]gogobosh.VM{
+			"cf-123": {{JobName: "diego_cell", IPs: []string{"10.0.16.1"}}},
+		})
+		tempDir, err := os.MkdirTemp("", "virgil")
+		Expect(err).ToNot(HaveOccurred())
+		outputFile = filepath.Join(tempDir, "policy")
+		args = virgilArgs(cfServer, boshServer)
+	})
+
+	AfterEach(func() {
+		cfServer.Close()
+		boshServer.Close()
+		os.RemoveAll(filepath.Dir(outputFile))
+	})
+
+	It("writes the policy as YAML", func() {
+		Expect(newApp().Run(append(args, outputFile))).To(Succeed())
+		policy, err := os.ReadFile(outputFile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(policy)).To(Equal(`---
+schema_version: "1"
+firewall_rules:
+- port: "443"
+  destination:
+  - 10.1.0.0/16
+  protocol: tcp
+  source:
+  - 10.0.16.1
+`))
+	})
+
+	It("writes the policy in the requested format", func() {
+		Expect(newApp().Run(append(args, "--format", "cisco-asa", "--asa-access-list", "CF-OUT", outputFile))).To(Succeed())
+		policy, err := os.ReadFile(outputFile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(policy)).To(ContainSubstring("access-list CF-OUT extended permit tcp"))
+	})
+
+	It("writes the table columns listed with spaces after the commas", func() {
+		Expect(newApp().Run(append(args, "--format", "csv", "--table-columns", "source, port", outputFile))).To(Succeed())
+		policy, err := os.ReadFile(outputFile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(policy)).To(HavePrefix("source,port\n10.0.16.1,"))
+	})
+
+	It("takes the sources from the cloud config subnets of the cell networks", func() {
+		boshServer.SetManifest("cf-123", "instance_groups:\n- name: diego_cell\n  networks:\n  - name: cf\n")
+		boshServer.SetCloudConfig("networks:\n- name: cf\n  type: manual\n  subnets:\n  - range: 10.0.16.0/24\n    gateway: 10.0.16.1\n    reserved: [10.0.16.2 - 10.0.16.127]\n")
+		Expect(newApp().Run(append(args, "--sources", "subnets", outputFile))).To(Succeed())
+		policy, err := os.ReadFile(outputFile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(policy)).To(ContainSubstring("  source:\n  - 10.0.16.0/24\n"))
+		Expect(newApp().Run(append(args, "--sources", "subnets", "--exclude-reserved-ranges", outputFile))).To(Succeed())
+		policy, err = os.ReadFile(outputFile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(policy)).To(ContainSubstring("  source:\n  - 10.0.16.128/26\n  - 10.0.16.192/27\n"))
+		Expect(string(policy)).To(ContainSubstring("  - 10.0.16.252/31\n  - 10.0.16.254/32\n"))
+		Expect(string(policy)).ToNot(ContainSubstring("10.0.16.0/32"))
+	})
+
+	It("returns an error for unknown sources", func() {
+		Expect(newApp().Run(append(args, "--sources", "ips", outputFile))).To(MatchError("Sources ips is not supported, valid sources are vms, subnets"))
+	})
+
+	It("writes metrics to the metrics file", func() {
+		metricsFile := filepath.Join(filepath.Dir(outputFile), "virgil.prom")
+		Expect(newApp().Run(append(args, "--metrics-file", metricsFile, outputFile))).To(Succeed())
+		metrics, err := os.ReadFile(metricsFile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(metrics)).To(ContainSubstring(`virgil_firewall_rules{foundation="sys.example.com",protocol="tcp"} 1`))
+		Expect(string(metrics)).To(ContainSubstring(`virgil_skipped_rules{foundation="sys.example.com"} 1`))
+	})
+
+	It("writes metrics to the metrics file when the run fails", func() {
+		metricsFile := filepath.Join(filepath.Dir(outputFile), "virgil.prom")
+		cfServer.Fail(1)
+		Expect(newApp().Run(append(args, "--metrics-file", metricsFile, outputFile))).ToNot(Succeed())
+		metrics, err := os.ReadFile(metricsFile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(metrics)).To(ContainSubstring(`virgil_fetch_errors_total{foundation="sys.example.com",source="cf_security_groups"} 1`))
+	})
+
+	It("returns an error when required flags are missing", func() {
+		Expect(newApp().Run([]string{"virgil", outputFile})).To(MatchError(ContainSubstring("must all be set")))
+	})
+
+	It("returns an error for an unknown format", func() {
+		Expect(newApp().Run(append(args, "--format", "pf", outputFile))).To(MatchError(ContainSubstring("Output format pf is not supported")))
+	})
+
+	It("returns an error when the CF API fails", func() {
+		cfServer.Fail(1)
+		Expect(newApp().Run(append(args, outputFile))).ToNot(Succeed())
+	})
+})
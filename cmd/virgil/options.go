@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/FidelityInternational/virgil"
+	"github.com/FidelityInternational/virgil/metrics"
+	"github.com/FidelityInternational/virgil/render"
+	"github.com/cloudfoundry-community/gogobosh"
+	"github.com/cloudfoundry/go-cfclient/v3/client"
+	"github.com/cloudfoundry/go-cfclient/v3/config"
+	"os"
+	"strings"
+)
+
+// sourceKinds - where the sources of the firewall rules are taken from, the IPs of the cell VMs or the cloud config
+// subnets of the networks the cells are placed on
+var sourceKinds = []string{"vms", "subnets"}
+
+// options - the global flags shared by every virgil command
+type options struct {
+	systemDomain, cfUser, cfPassword, boshUser, boshPassword, boshURI string
+	cfAPIURL                                                          string
+	format, panosOutput, fortigateOutput, checkpointOutput            string
+	k8sNamespace, k8sNamespaceMapping, tableColumns, reportTemplate   string
+	templateFile, metricsFile, foundationsFile, foundationsOutput     string
+	sources                                                           string
+	panosOptions                                                      render.PANOSOptions
+	asaOptions                                                        render.ASAOptions
+	srxOptions                                                        render.SRXOptions
+	fortigateOptions                                                  render.FortiGateOptions
+	checkpointOptions                                                 render.CheckPointOptions
+	nsxtOptions                                                       render.NSXTOptions
+	k8sOptions                                                        render.KubernetesOptions
+	tableOptions                                                      render.TableOptions
+	skipSSLValidation, excludeReservedRanges                          bool
+	recorder                                                          *metrics.Recorder
+}
+
+// connect - logs in to the CF API and BOSH director given by the global flags
+func (o *options) connect() (*client.Client, *gogobosh.Client, error) {
+	if o.systemDomain == "" || o.cfUser == "" || o.cfPassword == "" || o.boshUser == "" || o.boshPassword == "" || o.boshURI == "" {
+		return nil, nil, errors.New("cf-system-domain, cf-user, cf-password, bosh-user, bosh-password and bosh-uri must all be set")
+	}
+	if o.sources != "" && !containsString(sourceKinds, o.sources) {
+		return nil, nil, fmt.Errorf("Sources %s is not supported, valid sources are %s", o.sources, strings.Join(sourceKinds, ", "))
+	}
+	cfClient, err := o.connectCF()
+	if err != nil {
+		return nil, nil, err
+	}
+	boshClient, err := gogobosh.NewClient(&gogobosh.Config{
+		Username:          o.boshUser,
+		Password:          o.boshPassword,
+		BOSHAddress:       o.boshURI,
+		SkipSslValidation: o.skipSSLValidation,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfClient, boshClient, nil
+}
+
+// connectCF - logs in to the CF API given by the global flags, for commands that do not need BOSH
+func (o *options) connectCF() (*client.Client, error) {
+	if o.systemDomain == "" || o.cfUser == "" || o.cfPassword == "" {
+		return nil, errors.New("cf-system-domain, cf-user and cf-password must all be set")
+	}
+	cfAPIURL := o.cfAPIURL
+	if cfAPIURL == "" {
+		cfAPIURL = fmt.Sprintf("https://api.%s", o.systemDomain)
+	}
+	cfConfig, err := config.New(cfAPIURL, config.UserPassword(o.cfUser, o.cfPassword))
+	if err != nil {
+		return nil, err
+	}
+	return client.New(cfConfig)
+}
+
+// configureRenderers - registers the built in renderers again with the options given as flags
+func (o *options) configureRenderers(ctx context.Context, cfClient *client.Client) error {
+	var (
+		mapping        render.NamespaceMapping
+		reportOptions  render.ReportOptions
+		templateSource []byte
+		err            error
+	)
+	if o.k8sNamespaceMapping != "" {
+		if mapping, err = loadNamespaceMapping(ctx, cfClient, o.k8sNamespaceMapping); err != nil {
+			return err
+		}
+	}
+	if o.reportTemplate != "" {
+		reportSource, err := os.ReadFile(o.reportTemplate)
+		if err != nil {
+			return err
+		}
+		reportOptions.Template = string(reportSource)
+	}
+	if o.templateFile != "" {
+		if templateSource, err = os.ReadFile(o.templateFile); err != nil {
+			return err
+		}
+	}
+	tableOptions := o.tableOptions
+	for _, column := range strings.Split(o.tableColumns, ",") {
+		tableOptions.Columns = append(tableOptions.Columns, strings.TrimSpace(column))
+	}
+	for _, renderer := range []render.Renderer{
+		render.PANOSRenderer{Output: o.panosOutput, Options: o.panosOptions},
+		render.ASARenderer{Options: o.asaOptions},
+		render.SRXRenderer{Options: o.srxOptions},
+		render.FortiGateRenderer{Output: o.fortigateOutput, Options: o.fortigateOptions},
+		render.CheckPointRenderer{Output: o.checkpointOutput, Options: o.checkpointOptions},
+		render.NSXTRenderer{Options: o.nsxtOptions},
+		render.KubernetesRenderer{Flavour: "kubernetes", Namespace: o.k8sNamespace, Mapping: mapping, Options: o.k8sOptions},
+		render.KubernetesRenderer{Flavour: "calico", Namespace: o.k8sNamespace, Mapping: mapping, Options: o.k8sOptions},
+		render.KubernetesRenderer{Flavour: "cilium", Namespace: o.k8sNamespace, Mapping: mapping, Options: o.k8sOptions},
+		render.TableRenderer{Format: "csv", Options: tableOptions},
+		render.TableRenderer{Format: "xlsx", Options: tableOptions},
+		render.ReportRenderer{Format: "html", Options: reportOptions},
+		render.ReportRenderer{Format: "markdown", Options: reportOptions},
+		render.TemplateRenderer{Source: string(templateSource)},
+	} {
+		render.Register(renderer)
+	}
+	return nil
+}
+
+// newGenerator - returns a Generator reading from the given clients, recording metrics about every run
+func (o *options) newGenerator(cfClient *client.Client, boshClient *gogobosh.Client, generatorOptions ...virgil.Option) *virgil.Generator {
+	return o.newGeneratorFrom(virgil.NewCFSecurityGroupSource(cfClient), boshClient, generatorOptions...)
+}
+
+// newGeneratorFrom - returns a Generator reading security groups from the given source, such as the security
+// groups of a single space, recording metrics about every run
+func (o *options) newGeneratorFrom(secGroups virgil.SecurityGroupSource, boshClient *gogobosh.Client, generatorOptions ...virgil.Option) *virgil.Generator {
+	var observer virgil.Observer = o.recorder
+	if o.metricsFile != "" {
+		observer = o.recorder.Textfile(o.metricsFile, func(err error) {
+			fmt.Printf("Virgil\t- WARNING: writing metrics to %s failed - %s\n", o.metricsFile, err)
+		})
+	}
+	cells := virgil.NewBOSHCellSource(boshClient)
+	defaultOptions := []virgil.Option{virgil.WithFoundation(o.systemDomain), virgil.WithObserver(observer)}
+	if o.sources == "subnets" {
+		defaultOptions = append(defaultOptions, virgil.WithSubnetSources(cells, o.excludeReservedRanges))
+	}
+	return virgil.NewGenerator(secGroups, cells, append(defaultOptions, generatorOptions...)...)
+}
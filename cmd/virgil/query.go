@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/FidelityInternational/virgil"
+	"github.com/FidelityInternational/virgil/query"
+	"github.com/FidelityInternational/virgil/utility"
+	"github.com/cloudfoundry/go-cfclient/v3/resource"
+	"github.com/urfave/cli"
+	"io"
+	"os"
+	"strings"
+)
+
+// queryCommand - "virgil query" answers whether the policy allows a single packet, and which rules allow it
+func queryCommand(o *options) cli.Command {
+	var (
+		q          query.Query
+		policyFile string
+		jsonOutput bool
+	)
+	return cli.Command{
+		Name:      "query",
+		Usage:     "Answer whether the firewall policy allows traffic from a cell to a destination, failing when it is denied",
+		UsageText: "virgil [global options] query --src ip --dst ip [--port port] [--proto tcp|udp|icmp] [--policy policy.yml] [--json]",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:        "src",
+				Usage:       "IP address the traffic comes from, such as a cell",
+				Destination: &q.Source,
+			},
+			cli.StringFlag{
+				Name:        "dst",
+				Usage:       "IP address the traffic goes to",
+				Destination: &q.Destination,
+			},
+			cli.StringFlag{
+				Name:        "port",
+				Usage:       "Destination port, required for tcp and udp",
+				Destination: &q.Port,
+			},
+			cli.StringFlag{
+				Name:        "proto",
+				Value:       "tcp",
+				Usage:       fmt.Sprintf("Protocol, one of %s", strings.Join(query.Protocols, ", ")),
+				Destination: &q.Protocol,
+			},
+			cli.StringFlag{
+				Name:        "policy",
+				Usage:       "A yaml or json policy written by virgil to query instead of generating one, security groups are not named",
+				Destination: &policyFile,
+			},
+			cli.BoolFlag{
+				Name:        "json",
+				Usage:       "Write the answer as JSON",
+				Destination: &jsonOutput,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			q.Protocol = strings.ToLower(q.Protocol)
+			if err := q.Validate(); err != nil {
+				return err
+			}
+			var (
+				firewallRules utility.FirewallRules
+				secGroups     []resource.SecurityGroup
+			)
+			if policyFile != "" {
+				data, err := os.ReadFile(policyFile)
+				if err != nil {
+					return err
+				}
+				if firewallRules, err = query.ParsePolicy(data); err != nil {
+					return err
+				}
+			} else {
+				cfClient, boshClient, err := o.connect()
+				if err != nil {
+					return err
+				}
+				var progress io.Writer = os.Stdout
+				if jsonOutput {
+					progress = io.Discard
+				}
+				result, err := o.newGenerator(cfClient, boshClient, virgil.WithProgress(progress)).Generate(context.Background())
+				if err != nil {
+					return err
+				}
+				firewallRules, secGroups = result.FirewallRules, result.Metadata.SecurityGroups
+			}
+			answer := query.Evaluate(firewallRules, secGroups, q)
+			if jsonOutput {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(answer); err != nil {
+					return err
+				}
+			} else {
+				answer.WriteTo(os.Stdout)
+			}
+			if !answer.Allowed {
+				return fmt.Errorf("%s is denied by the policy", q)
+			}
+			return nil
+		},
+	}
+}
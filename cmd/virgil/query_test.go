@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/FidelityInternational/virgil/fakes"
+	"github.com/FidelityInternational/virgil/query"
+	"github.com/cloudfoundry-community/gogobosh"
+	"github.com/cloudfoundry/go-cfclient/v3/resource"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"os"
+	"path/filepath"
+)
+
+var _ = Describe("virgil query", func() {
+	var (
+		cfServer   *fakes.CFServer
+		boshServer *fakes.BOSHServer
+		tempDir    string
+		globalArgs []string
+	)
+
+	BeforeEach(func() {
+		cfServer = fakes.NewCFServer([]resource.SecurityGroup{
+			fakes.SecurityGroup("postgres", fakes.Rule("tcp", "10.20.0.0/16", "5432")),
+		})
+		boshServer = fakes.NewBOSHServer(map[string][]gogobosh.VM{
+			"cf-123": {{JobName: "diego_cell", IPs: []string{"10.0.1.5"}}},
+		})
+		var err error
+		tempDir, err = os.MkdirTemp("", "virgil")
+		Expect(err).ToNot(HaveOccurred())
+		globalArgs = virgilArgs(cfServer, boshServer)
+	})
+
+	AfterEach(func() {
+		cfServer.Close()
+		boshServer.Close()
+		os.RemoveAll(tempDir)
+	})
+
+	run := func(args ...string) error {
+		return newApp().Run(append(append([]string{}, globalArgs...), append([]string{"query"}, args...)...))
+	}
+
+	It("succeeds when the generated policy allows the traffic", func() {
+		Expect(run("--src", "10.0.1.5", "--dst", "10.20.3.4", "--port", "5432")).To(Succeed())
+		output, err := captureStdout(append(append([]string{}, globalArgs...), "query", "--src", "10.0.1.5", "--dst", "10.20.3.4", "--port", "5432", "--proto", "TCP", "--json"))
+		Expect(err).ToNot(HaveOccurred())
+		var answer query.Answer
+		Expect(json.Unmarshal([]byte(output), &answer)).To(Succeed())
+		Expect(answer.Allowed).To(BeTrue())
+		Expect(answer.Query.Protocol).To(Equal("tcp"))
+		Expect(answer.Matches).To(HaveLen(1))
+		Expect(answer.Matches[0].Rule.Destination).To(Equal([]string{"10.20.0.0/16"}))
+		Expect(answer.Matches[0].SecurityGroups).To(Equal([]string{"postgres"}))
+	})
+
+	It("fails when the traffic is denied", func() {
+		Expect(run("--src", "10.0.1.5", "--dst", "10.20.3.4", "--port", "22")).To(MatchError("tcp 22 10.0.1.5 -> 10.20.3.4 is denied by the policy"))
+	})
+
+	It("writes only the JSON answer to stdout when the traffic is denied", func() {
+		output, err := captureStdout(append(append([]string{}, globalArgs...), "query", "--src", "10.0.1.5", "--dst", "10.20.3.4", "--port", "22", "--json"))
+		Expect(err).To(MatchError("tcp 22 10.0.1.5 -> 10.20.3.4 is denied by the policy"))
+		var answer query.Answer
+		Expect(json.Unmarshal([]byte(output), &answer)).To(Succeed())
+		Expect(answer.Allowed).To(BeFalse())
+		Expect(answer.Matches).To(BeEmpty())
+	})
+
+	It("queries a saved policy without connecting to CF or BOSH", func() {
+		policyFile := filepath.Join(tempDir, "policy.yml")
+		Expect(os.WriteFile(policyFile, []byte("schema_version: \"1\"\nfirewall_rules:\n- port: \"443\"\n  protocol: tcp\n  source: [10.0.1.0/24]\n  destination: [10.30.0.1]\n"), 0644)).To(Succeed())
+		Expect(newApp().Run([]string{"virgil", "query", "--policy", policyFile, "--src", "10.0.1.9", "--dst", "10.30.0.1", "--port", "443"})).To(Succeed())
+		Expect(cfServer.Requests()).To(Equal(0))
+	})
+
+	It("returns an error for an invalid query", func() {
+		Expect(run("--src", "10.0.1.5", "--dst", "10.20.3.4")).To(MatchError("Port must be set for tcp queries"))
+	})
+})
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"github.com/FidelityInternational/virgil/server"
+	"github.com/urfave/cli"
+	"time"
+)
+
+// serveCommand - "virgil serve" serves the current policy over HTTP, regenerating it on an interval
+func serveCommand(o *options) cli.Command {
+	var (
+		listen   string
+		interval time.Duration
+	)
+	return cli.Command{
+		Name:      "serve",
+		Usage:     "Serve the current firewall policy over HTTP at /policy?format=, /healthz, /metadata and /metrics",
+		UsageText: "virgil [global options] serve [--listen :8080] [--interval 5m]",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:        "listen, l",
+				Usage:       "Address to listen on",
+				Value:       ":8080",
+				Destination: &listen,
+			},
+			cli.DurationFlag{
+				Name:        "interval, i",
+				Usage:       "How often the policy is regenerated",
+				Value:       5 * time.Minute,
+				Destination: &interval,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if interval <= 0 {
+				return fmt.Errorf("Interval %s was invalid", interval)
+			}
+			cfClient, boshClient, err := o.connect()
+			if err != nil {
+				return err
+			}
+			ctx, stop := interruptContext()
+			defer stop()
+			if err := o.configureRenderers(ctx, cfClient); err != nil {
+				return err
+			}
+			srv := server.New(o.newGenerator(cfClient, boshClient), interval)
+			srv.Handle("/metrics", o.recorder)
+			fmt.Printf("Virgil\t- Serving Firewall Policy on %s, regenerating every %s\n", listen, interval)
+			return srv.ListenAndServe(ctx, listen, func(err error) {
+				fmt.Printf("Virgil\t- WARNING: regenerating Firewall Policy failed, serving the last good policy - %s\n", err)
+			})
+		},
+	}
+}
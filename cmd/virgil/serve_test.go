@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"github.com/FidelityInternational/virgil/fakes"
+	"github.com/cloudfoundry-community/gogobosh"
+	"github.com/cloudfoundry/go-cfclient/v3/resource"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io"
+	"net"
+	"net/http"
+)
+
+var _ = Describe("virgil serve", func() {
+	var (
+		cfServer   *fakes.CFServer
+		boshServer *fakes.BOSHServer
+		args       []string
+	)
+
+	BeforeEach(func() {
+		cfServer = fakes.NewCFServer([]resource.SecurityGroup{
+			fakes.SecurityGroup("web", fakes.Rule("tcp", "10.1.0.0/16", "443")),
+		})
+		boshServer = fakes.NewBOSHServer(map[string][]gogobosh.VM{
+			"cf-123": {{JobName: "diego_cell", IPs: []string{"10.0.16.1"}}},
+		})
+		args = virgilArgs(cfServer, boshServer, "serve")
+	})
+
+	AfterEach(func() {
+		cfServer.Close()
+		boshServer.Close()
+	})
+
+	It("serves the policy until interrupted", func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		address := listener.Addr().String()
+		listener.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer func(original func() (context.Context, context.CancelFunc)) {
+			interruptContext = original
+		}(interruptContext)
+		interruptContext = func() (context.Context, context.CancelFunc) {
+			return ctx, cancel
+		}
+		done := make(chan error)
+		go func() {
+			done <- newApp().Run(append(args, "--listen", address))
+		}()
+		var body string
+		Eventually(func() int {
+			response, err := http.Get("http://" + address + "/policy?format=json")
+			if err != nil {
+				return 0
+			}
+			defer response.Body.Close()
+			data, _ := io.ReadAll(response.Body)
+			body = string(data)
+			return response.StatusCode
+		}).Should(Equal(http.StatusOK))
+		Expect(body).To(ContainSubstring("10.1.0.0/16"))
+
+		response, err := http.Get("http://" + address + "/metrics")
+		Expect(err).ToNot(HaveOccurred())
+		defer response.Body.Close()
+		metrics, err := io.ReadAll(response.Body)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(metrics)).To(ContainSubstring(`virgil_sources{foundation="sys.example.com"} 1`))
+
+		cancel()
+		Eventually(done).Should(Receive(BeNil()))
+	})
+
+	It("returns an error for an invalid interval", func() {
+		Expect(newApp().Run(append(args, "--interval", "0s"))).To(MatchError("Interval 0s was invalid"))
+	})
+
+	It("returns an error when required flags are missing", func() {
+		Expect(newApp().Run([]string{"virgil", "serve"})).To(MatchError(ContainSubstring("must all be set")))
+	})
+})
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/FidelityInternational/virgil"
+	"github.com/FidelityInternational/virgil/render"
+	"github.com/FidelityInternational/virgil/utility"
+	"github.com/cloudfoundry/go-cfclient/v3/client"
+	"github.com/urfave/cli"
+	"io"
+	"os"
+	"strings"
+)
+
+// spacePolicy - the firewall rules a space's apps can reach in each lifecycle, written by --json
+type spacePolicy struct {
+	Org        string                     `json:"org"`
+	Space      string                     `json:"space"`
+	SpaceGUID  string                     `json:"space_guid"`
+	Lifecycles map[string]lifecyclePolicy `json:"lifecycles"`
+}
+
+// lifecyclePolicy - the security groups applied to a space for one lifecycle and the firewall rules generated from them
+type lifecyclePolicy struct {
+	SecurityGroups []string `json:"security_groups"`
+	utility.FirewallRules
+}
+
+// spacePolicyCommand - "virgil space-policy" generates the firewall rules for the security groups of a single space
+func spacePolicyCommand(o *options) cli.Command {
+	var (
+		org, space string
+		jsonOutput bool
+	)
+	return cli.Command{
+		Name:      "space-policy",
+		Usage:     "Generate the firewall rules apps in a single space can reach, for the running and staging lifecycles",
+		UsageText: "virgil [global options] space-policy --org org --space space [--json]",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:        "org",
+				Usage:       "Name of the org the space is in",
+				Destination: &org,
+			},
+			cli.StringFlag{
+				Name:        "space",
+				Usage:       "Name of the space",
+				Destination: &space,
+			},
+			cli.BoolFlag{
+				Name:        "json",
+				Usage:       "Write the security groups and firewall rules of each lifecycle as JSON rather than in the global --format",
+				Destination: &jsonOutput,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if org == "" || space == "" {
+				return errors.New("org and space must both be set")
+			}
+			if _, err := render.Get(o.format); err != nil {
+				return err
+			}
+			cfClient, boshClient, err := o.connect()
+			if err != nil {
+				return err
+			}
+			ctx := context.Background()
+			spaceGUID, err := findSpace(ctx, cfClient, org, space)
+			if err != nil {
+				return err
+			}
+			if err := o.configureRenderers(ctx, cfClient); err != nil {
+				return err
+			}
+			renderer, err := render.Get(o.format)
+			if err != nil {
+				return err
+			}
+			var progress io.Writer = os.Stdout
+			if jsonOutput {
+				progress = io.Discard
+			}
+			policy := spacePolicy{Org: org, Space: space, SpaceGUID: spaceGUID, Lifecycles: make(map[string]lifecyclePolicy)}
+			for _, lifecycle := range virgil.Lifecycles {
+				fmt.Fprintf(progress, "Virgil\t- Generating Firewall Rules for %s apps in %s/%s...\n", lifecycle, org, space)
+				secGroups := virgil.NewCFSpaceSecurityGroupSource(cfClient, spaceGUID, lifecycle)
+				result, err := o.newGeneratorFrom(secGroups, boshClient, virgil.WithProgress(progress)).Generate(ctx)
+				if err != nil {
+					return err
+				}
+				names := []string{}
+				for _, secGroup := range result.Metadata.SecurityGroups {
+					names = append(names, secGroup.Name)
+				}
+				if jsonOutput {
+					policy.Lifecycles[lifecycle] = lifecyclePolicy{SecurityGroups: names, FirewallRules: result.FirewallRules}
+					continue
+				}
+				applied := strings.Join(names, ", ")
+				if applied == "" {
+					applied = "none"
+				}
+				fmt.Printf("Virgil\t- Security groups applied to %s apps in %s/%s: %s\n", lifecycle, org, space, applied)
+				if err := renderer.Render(os.Stdout, result.FirewallRules, result.Metadata); err != nil {
+					return err
+				}
+			}
+			if jsonOutput {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(policy)
+			}
+			return nil
+		},
+	}
+}
+
+// findSpace - returns the GUID of the named space in the named org
+func findSpace(ctx context.Context, cfClient *client.Client, org, space string) (string, error) {
+	opts := client.NewSpaceListOptions()
+	opts.Names.EqualTo(space)
+	spaces, orgs, err := cfClient.Spaces.ListIncludeOrganizationsAll(ctx, opts)
+	if err != nil {
+		return "", err
+	}
+	orgNames := make(map[string]string)
+	for _, o := range orgs {
+		orgNames[o.GUID] = o.Name
+	}
+	for _, s := range spaces {
+		if s.Name == space && s.Relationships != nil && s.Relationships.Organization != nil && s.Relationships.Organization.Data != nil &&
+			orgNames[s.Relationships.Organization.Data.GUID] == org {
+			return s.GUID, nil
+		}
+	}
+	return "", fmt.Errorf("Space %s/%s could not be found", org, space)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/FidelityInternational/virgil/fakes"
+	"github.com/FidelityInternational/virgil/utility"
+	"github.com/cloudfoundry-community/gogobosh"
+	"github.com/cloudfoundry/go-cfclient/v3/resource"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("virgil space-policy", func() {
+	var (
+		cfServer   *fakes.CFServer
+		boshServer *fakes.BOSHServer
+		globalArgs []string
+	)
+
+	BeforeEach(func() {
+		bound := fakes.SecurityGroup("postgres", fakes.Rule("tcp", "10.20.0.0/16", "5432"))
+		bound.GloballyEnabled.Running = new(bool)
+		bound.Relationships.RunningSpaces.Data = []resource.Relationship{{GUID: "dev-guid"}}
+		cfServer = fakes.NewCFServer([]resource.SecurityGroup{
+			fakes.SecurityGroup("dns", fakes.Rule("udp", "10.0.0.2", "53")),
+			bound,
+		})
+		cfServer.SetSpaces([]resource.Organization{fakes.Org("platform")}, []resource.Space{fakes.Space("dev", "platform")}, nil)
+		boshServer = fakes.NewBOSHServer(map[string][]gogobosh.VM{
+			"cf-123": {{JobName: "diego_cell", IPs: []string{"10.0.1.5"}}},
+		})
+		globalArgs = virgilArgs(cfServer, boshServer)
+	})
+
+	AfterEach(func() {
+		cfServer.Close()
+		boshServer.Close()
+	})
+
+	run := func(args ...string) error {
+		return newApp().Run(append(append([]string{}, globalArgs...), args...))
+	}
+
+	It("generates the policy of both lifecycles for the space", func() {
+		output, err := captureStdout(append(append([]string{}, globalArgs...), "space-policy", "--org", "platform", "--space", "dev"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output).To(ContainSubstring("Virgil\t- Security groups applied to running apps in platform/dev: dns, postgres\n"))
+		Expect(output).To(ContainSubstring("- port: \"5432\"\n  destination:\n  - 10.20.0.0/16\n  protocol: tcp\n  source:\n  - 10.0.1.5\n"))
+		Expect(output).To(ContainSubstring("Virgil\t- Security groups applied to staging apps in platform/dev: none\n---\nschema_version: \"1\"\nfirewall_rules: []\n"))
+	})
+
+	It("writes the policy of each lifecycle as JSON", func() {
+		output, err := captureStdout(append(append([]string{}, globalArgs...), "space-policy", "--org", "platform", "--space", "dev", "--json"))
+		Expect(err).ToNot(HaveOccurred())
+		var policy spacePolicy
+		Expect(json.Unmarshal([]byte(output), &policy)).To(Succeed())
+		Expect(policy.SpaceGUID).To(Equal("dev-guid"))
+		Expect(policy.Lifecycles["running"].SecurityGroups).To(Equal([]string{"dns", "postgres"}))
+		Expect(policy.Lifecycles["running"].FirewallRules.FirewallRules).To(Equal([]utility.FirewallRule{
+			{Port: "5432", Protocol: "tcp", Destination: []string{"10.20.0.0/16"}, Source: []string{"10.0.1.5"}},
+			{Port: "53", Protocol: "udp", Destination: []string{"10.0.0.2"}, Source: []string{"10.0.1.5"}},
+		}))
+		Expect(policy.Lifecycles["staging"].SecurityGroups).To(BeEmpty())
+		Expect(policy.Lifecycles["staging"].FirewallRules.FirewallRules).To(BeEmpty())
+	})
+
+	It("writes the policy of each lifecycle in the requested format", func() {
+		output, err := captureStdout(append(append([]string{}, globalArgs...), "--format", "csv", "space-policy", "--org", "platform", "--space", "dev"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output).To(ContainSubstring("protocol,port,source,destination,security_groups\ntcp,5432,10.0.1.5,10.20.0.0/16,postgres\nudp,53,10.0.1.5,10.0.0.2,dns\n"))
+	})
+
+	It("returns an error when the space cannot be found", func() {
+		Expect(run("space-policy", "--org", "other", "--space", "dev")).To(MatchError("Space other/dev could not be found"))
+	})
+
+	It("returns an error when the CF API fails", func() {
+		cfServer.Fail(2)
+		Expect(run("space-policy", "--org", "platform", "--space", "dev")).ToNot(Succeed())
+	})
+
+	It("returns an error when the org or space is missing", func() {
+		Expect(run("space-policy", "--org", "platform")).To(MatchError("org and space must both be set"))
+	})
+})
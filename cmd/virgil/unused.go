@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/FidelityInternational/virgil"
+	"github.com/FidelityInternational/virgil/analysis"
+	"github.com/urfave/cli"
+	"os"
+	"time"
+)
+
+// unusedCommand - "virgil unused" reports security groups that apply to no apps so they can be deleted
+func unusedCommand(o *options) cli.Command {
+	var jsonOutput bool
+	return cli.Command{
+		Name:      "unused",
+		Usage:     "Report security groups that are not bound anywhere, or bound only to empty or deleted spaces",
+		UsageText: "virgil [global options] unused [--json]",
+		Flags: []cli.Flag{
+			cli.BoolFlag{
+				Name:        "json",
+				Usage:       "Write the unused security groups as JSON",
+				Destination: &jsonOutput,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			cfClient, err := o.connectCF()
+			if err != nil {
+				return err
+			}
+			if !jsonOutput {
+				fmt.Println("CF\t- Fetching Security Groups...")
+			}
+			secGroups, err := virgil.NewCFSecurityGroupSource(cfClient).SecurityGroups(context.Background())
+			if err != nil {
+				return err
+			}
+			if !jsonOutput {
+				fmt.Println("CF\t- Fetching Spaces...")
+			}
+			spaces, err := virgil.NewCFSpaceSource(cfClient).Spaces(context.Background())
+			if err != nil {
+				return err
+			}
+			report := analysis.FindUnusedGroups(secGroups, spaces, time.Now())
+			if jsonOutput {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(report)
+			}
+			_, err = report.WriteTo(os.Stdout)
+			return err
+		},
+	}
+}
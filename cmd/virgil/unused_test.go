@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/FidelityInternational/virgil/analysis"
+	"github.com/FidelityInternational/virgil/fakes"
+	"github.com/cloudfoundry/go-cfclient/v3/resource"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("virgil unused", func() {
+	var (
+		cfServer *fakes.CFServer
+		args     []string
+	)
+
+	BeforeEach(func() {
+		unbound := fakes.SecurityGroup("unbound", fakes.Rule("tcp", "10.1.1.1", "443"))
+		unbound.GloballyEnabled.Running = new(bool)
+		cfServer = fakes.NewCFServer([]resource.SecurityGroup{
+			fakes.SecurityGroup("internal", fakes.Rule("all", "10.0.0.0/8", "")),
+			unbound,
+		})
+		cfServer.SetSpaces([]resource.Organization{fakes.Org("platform")}, []resource.Space{fakes.Space("dev", "platform")}, nil)
+		args = virgilArgs(cfServer, nil, "unused")
+	})
+
+	AfterEach(func() {
+		cfServer.Close()
+	})
+
+	It("reports unused security groups without needing BOSH", func() {
+		output, err := captureStdout(args)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output).To(ContainSubstring("Virgil\t- 1 unused security groups\n  unbound (unbound) - 1 rules,"))
+		Expect(output).ToNot(ContainSubstring("internal"))
+	})
+
+	It("reports the unused security groups as JSON", func() {
+		output, err := captureStdout(append(append([]string{}, args...), "--json"))
+		Expect(err).ToNot(HaveOccurred())
+		var report analysis.UnusedReport
+		Expect(json.Unmarshal([]byte(output), &report)).To(Succeed())
+		Expect(report.Groups).To(HaveLen(1))
+		Expect(report.Groups[0].Name).To(Equal("unbound"))
+		Expect(report.Groups[0].Reason).To(Equal(analysis.Unbound))
+		Expect(report.Groups[0].Rules).To(Equal(1))
+	})
+
+	It("returns an error when the CF API fails", func() {
+		cfServer.Fail(1)
+		Expect(newApp().Run(args)).ToNot(Succeed())
+	})
+
+	It("returns an error when required flags are missing", func() {
+		Expect(newApp().Run([]string{"virgil", "unused"})).To(MatchError("cf-system-domain, cf-user and cf-password must all be set"))
+	})
+})
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/FidelityInternational/virgil"
+	"github.com/FidelityInternational/virgil/verify"
+	"github.com/urfave/cli"
+	"os"
+	"strings"
+)
+
+// verifyCommand - "virgil verify" compares the policy CF needs with a live firewall export
+func verifyCommand(o *options) cli.Command {
+	var against, exportFormat, chains string
+	return cli.Command{
+		Name:      "verify",
+		Usage:     "Compare the firewall policy CF needs with a firewall export, failing when rules are missing or unneeded",
+		UsageText: "virgil [global options] verify --against export [--export-format iptables|virgil] [--iptables-chains FORWARD]",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:        "against",
+				Usage:       "Firewall export to verify, the output of iptables-save or a yaml or json policy written by virgil",
+				Destination: &against,
+			},
+			cli.StringFlag{
+				Name:        "export-format",
+				Usage:       fmt.Sprintf("Format of the export, one of %s. Detected from the export when not set", strings.Join(verify.ExportFormats, ", ")),
+				Destination: &exportFormat,
+			},
+			cli.StringFlag{
+				Name:        "iptables-chains",
+				Usage:       "Comma separated iptables chains to read ACCEPT rules from, every chain when not set",
+				Destination: &chains,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if against == "" {
+				return errors.New("against must be set")
+			}
+			data, err := os.ReadFile(against)
+			if err != nil {
+				return err
+			}
+			if exportFormat == "" {
+				exportFormat = verify.DetectExportFormat(data)
+			}
+			var chainList []string
+			if chains != "" {
+				chainList = strings.Split(chains, ",")
+			}
+			export, err := verify.ParseExport(data, exportFormat, chainList)
+			if err != nil {
+				return err
+			}
+			cfClient, boshClient, err := o.connect()
+			if err != nil {
+				return err
+			}
+			result, err := o.newGenerator(cfClient, boshClient, virgil.WithProgress(os.Stdout)).Generate(context.Background())
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Virgil\t- Verifying Firewall Policy against %s...\n", against)
+			report := verify.Verify(result.FirewallRules, export)
+			report.WriteTo(os.Stdout)
+			if report.Drifted() {
+				return fmt.Errorf("Firewall has drifted from the policy CF needs, %d rules are missing and %d are not needed", len(report.Missing), len(report.Unneeded))
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"github.com/FidelityInternational/virgil/fakes"
+	"github.com/cloudfoundry-community/gogobosh"
+	"github.com/cloudfoundry/go-cfclient/v3/resource"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"os"
+	"path/filepath"
+)
+
+var _ = Describe("virgil verify", func() {
+	var (
+		cfServer   *fakes.CFServer
+		boshServer *fakes.BOSHServer
+		tempDir    string
+		exportFile string
+		args       []string
+	)
+
+	BeforeEach(func() {
+		cfServer = fakes.NewCFServer([]resource.SecurityGroup{
+			fakes.SecurityGroup("web", fakes.Rule("tcp", "10.1.0.0/16", "443")),
+		})
+		boshServer = fakes.NewBOSHServer(map[string][]gogobosh.VM{
+			"cf-123": {{JobName: "diego_cell", IPs: []string{"10.0.16.1"}}},
+		})
+		var err error
+		tempDir, err = os.MkdirTemp("", "virgil")
+		Expect(err).ToNot(HaveOccurred())
+		exportFile = filepath.Join(tempDir, "iptables.rules")
+		args = virgilArgs(cfServer, boshServer, "verify", "--against", exportFile)
+	})
+
+	AfterEach(func() {
+		cfServer.Close()
+		boshServer.Close()
+		os.RemoveAll(tempDir)
+	})
+
+	It("succeeds when the firewall matches the policy", func() {
+		Expect(os.WriteFile(exportFile, []byte("*filter\n-A FORWARD -s 10.0.16.0/24 -d 10.1.0.0/16 -p tcp -m tcp --dport 443 -j ACCEPT\nCOMMIT\n"), 0644)).To(Succeed())
+		Expect(newApp().Run(args)).To(Succeed())
+	})
+
+	It("fails when the firewall has drifted", func() {
+		Expect(os.WriteFile(exportFile, []byte("*filter\n-A FORWARD -s 10.0.16.0/24 -d 10.1.0.0/16 -p tcp -m tcp --dport 22 -j ACCEPT\nCOMMIT\n"), 0644)).To(Succeed())
+		Expect(newApp().Run(args)).To(MatchError("Firewall has drifted from the policy CF needs, 1 rules are missing and 1 are not needed"))
+	})
+
+	It("verifies against a policy written by virgil", func() {
+		globalArgs := args[:len(args)-3]
+		Expect(newApp().Run(append(append([]string{}, globalArgs...), exportFile))).To(Succeed())
+		Expect(newApp().Run(append(append([]string{}, globalArgs...), "verify", "--against", exportFile, "--export-format", "virgil"))).To(Succeed())
+	})
+
+	It("returns an error when against is not set", func() {
+		Expect(newApp().Run(args[:len(args)-2])).To(MatchError("against must be set"))
+	})
+
+	It("returns an error for an unknown export format", func() {
+		Expect(os.WriteFile(exportFile, []byte(""), 0644)).To(Succeed())
+		Expect(newApp().Run(append(args, "--export-format", "pf"))).To(MatchError(ContainSubstring("Export format pf is not supported")))
+	})
+})
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/FidelityInternational/virgil/render"
+	"github.com/FidelityInternational/virgil/utility"
+	"github.com/FidelityInternational/virgil/watch"
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v2"
+	"os"
+	"time"
+)
+
+// watchCommand - "virgil watch" regenerates the policy on an interval and acts only when it changes
+func watchCommand(o *options) cli.Command {
+	var (
+		interval                  time.Duration
+		outputFile, hook, webhook string
+	)
+	return cli.Command{
+		Name:      "watch",
+		Usage:     "Poll CF and BOSH, writing the policy, running a hook or posting to a webhook when it changes",
+		UsageText: "virgil [global options] watch [--interval 1m] [--output file] [--hook command] [--webhook url]",
+		Flags: []cli.Flag{
+			cli.DurationFlag{
+				Name:        "interval, i",
+				Usage:       "How often CF and BOSH are polled",
+				Value:       time.Minute,
+				Destination: &interval,
+			},
+			cli.StringFlag{
+				Name:        "output, o",
+				Usage:       "File the policy is written to in the global --format when it changes",
+				Destination: &outputFile,
+			},
+			cli.StringFlag{
+				Name:        "hook",
+				Usage:       "Command run with sh -c when the policy changes, the diff is given as JSON on stdin",
+				Destination: &hook,
+			},
+			cli.StringFlag{
+				Name:        "webhook",
+				Usage:       "Slack or Teams compatible incoming webhook URL the diff is posted to when the policy changes",
+				Destination: &webhook,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if interval <= 0 {
+				return fmt.Errorf("Interval %s was invalid", interval)
+			}
+			if outputFile == "" && hook == "" && webhook == "" {
+				return errors.New("At least one of output, hook or webhook must be set")
+			}
+			if _, err := render.Get(o.format); err != nil {
+				return err
+			}
+			cfClient, boshClient, err := o.connect()
+			if err != nil {
+				return err
+			}
+			ctx, stop := interruptContext()
+			defer stop()
+			if err := o.configureRenderers(ctx, cfClient); err != nil {
+				return err
+			}
+			renderer, err := render.Get(o.format)
+			if err != nil {
+				return err
+			}
+			var actions []watch.Action
+			if outputFile != "" {
+				actions = append(actions, watch.FileAction{Path: outputFile, Renderer: renderer})
+			}
+			if hook != "" {
+				actions = append(actions, watch.HookAction{Command: hook, PolicyFile: outputFile})
+			}
+			if webhook != "" {
+				actions = append(actions, watch.WebhookAction{URL: webhook})
+			}
+			watcher := watch.New(o.newGenerator(cfClient, boshClient), interval, append(actions, watch.ActionFunc(logChange))...)
+			if firewallRules, ok := readPolicy(outputFile, o.format); ok {
+				fmt.Printf("Virgil\t- Comparing with the Firewall Policy in %s\n", outputFile)
+				watcher.Seed(firewallRules)
+			}
+			fmt.Printf("Virgil\t- Watching for Firewall Policy changes every %s\n", interval)
+			watcher.Run(ctx, func(err error) {
+				fmt.Printf("Virgil\t- WARNING: %s\n", err)
+			})
+			return nil
+		},
+	}
+}
+
+// logChange - prints a summary of each policy change
+func logChange(ctx context.Context, change watch.Change) error {
+	fmt.Printf("Virgil\t- Firewall Policy changed:\n%s\n", change.Diff.Summary(0))
+	return nil
+}
+
+// readPolicy - reads a previously written yaml or json policy, other formats cannot be read back
+func readPolicy(path, format string) (utility.FirewallRules, bool) {
+	var firewallRules utility.FirewallRules
+	if path == "" || (format != "yaml" && format != "json") {
+		return firewallRules, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return firewallRules, false
+	}
+	if format == "json" {
+		err = json.Unmarshal(data, &firewallRules)
+	} else {
+		err = yaml.Unmarshal(data, &firewallRules)
+	}
+	return firewallRules, err == nil && firewallRules.SchemaVersion != ""
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"github.com/FidelityInternational/virgil/fakes"
+	"github.com/cloudfoundry-community/gogobosh"
+	"github.com/cloudfoundry/go-cfclient/v3/resource"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"os"
+	"path/filepath"
+)
+
+var _ = Describe("virgil watch", func() {
+	var (
+		cfServer   *fakes.CFServer
+		boshServer *fakes.BOSHServer
+		tempDir    string
+		args       []string
+		cancel     context.CancelFunc
+		done       chan error
+		original   func() (context.Context, context.CancelFunc)
+	)
+
+	readFile := func(path string) func() string {
+		return func() string {
+			data, _ := os.ReadFile(path)
+			return string(data)
+		}
+	}
+
+	start := func(extraArgs ...string) {
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(context.Background())
+		interruptContext = func() (context.Context, context.CancelFunc) {
+			return ctx, cancel
+		}
+		done = make(chan error)
+		go func() {
+			done <- newApp().Run(append(args, extraArgs...))
+		}()
+	}
+
+	BeforeEach(func() {
+		cfServer = fakes.NewCFServer([]resource.SecurityGroup{
+			fakes.SecurityGroup("web", fakes.Rule("tcp", "10.1.0.0/16", "443")),
+		})
+		boshServer = fakes.NewBOSHServer(map[string][]gogobosh.VM{
+			"cf-123": {{JobName: "diego_cell", IPs: []string{"10.0.16.1"}}},
+		})
+		var err error
+		tempDir, err = os.MkdirTemp("", "virgil")
+		Expect(err).ToNot(HaveOccurred())
+		args = virgilArgs(cfServer, boshServer, "watch", "--interval", "10ms")
+		done = nil
+		original = interruptContext
+	})
+
+	AfterEach(func() {
+		if done != nil {
+			cancel()
+			Eventually(done).Should(Receive(BeNil()))
+		}
+		interruptContext = original
+		cfServer.Close()
+		boshServer.Close()
+		os.RemoveAll(tempDir)
+	})
+
+	It("writes the policy and rewrites it when it changes", func() {
+		output := filepath.Join(tempDir, "policy.yml")
+		start("--output", output)
+		Eventually(readFile(output)).Should(ContainSubstring("port: \"443\""))
+		cfServer.SetSecurityGroups([]resource.SecurityGroup{
+			fakes.SecurityGroup("web", fakes.Rule("tcp", "10.1.0.0/16", "8443")),
+		})
+		Eventually(readFile(output)).Should(ContainSubstring("port: \"8443\""))
+	})
+
+	It("only runs the hook when the policy differs from the existing output", func() {
+		output := filepath.Join(tempDir, "policy.yml")
+		marker := filepath.Join(tempDir, "hook")
+		Expect(os.WriteFile(output, []byte(`---
+schema_version: "1"
+firewall_rules:
+- port: "443"
+  destination:
+  - 10.1.0.0/16
+  protocol: tcp
+  source:
+  - 10.0.16.1
+`), 0644)).To(Succeed())
+		start("--output", output, "--hook", "cat >> "+marker)
+		Eventually(cfServer.Requests).Should(BeNumerically(">=", 3))
+		Expect(marker).ToNot(BeAnExistingFile())
+		boshServer.SetVMs("cf-123", []gogobosh.VM{
+			{JobName: "diego_cell", IPs: []string{"10.0.16.1"}},
+			{JobName: "diego_cell", IPs: []string{"10.0.16.2"}},
+		})
+		Eventually(readFile(marker)).Should(ContainSubstring(`"added_sources":["10.0.16.2"]`))
+	})
+
+	It("returns an error when no action is given", func() {
+		Expect(newApp().Run(args)).To(MatchError("At least one of output, hook or webhook must be set"))
+	})
+})
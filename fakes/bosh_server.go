@@ -0,0 +1,189 @@
+package fakes
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/cloudfoundry-community/gogobosh"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// BOSHServer - a stub BOSH director using basic auth, serving deployments with their VMs and manifests and the
+// cloud config
+type BOSHServer struct {
+	*httptest.Server
+	mutex       sync.Mutex
+	vms         map[string][]gogobosh.VM
+	manifests   map[string]string
+	cloudConfig string
+	tasks       map[int]string
+	failures    int
+}
+
+// NewBOSHServer - starts a BOSHServer holding the given deployments and VMs, call Close when finished
+func NewBOSHServer(vms map[string][]gogobosh.VM) *BOSHServer {
+	s := &BOSHServer{vms: vms, manifests: make(map[string]string), tasks: make(map[int]string)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/info", s.info)
+	mux.HandleFunc("/deployments", s.deployments)
+	mux.HandleFunc("/deployments/", s.deployment)
+	mux.HandleFunc("/configs", s.configs)
+	mux.HandleFunc("/tasks/", s.task)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// SetVMs - replaces the VMs of a deployment, adding the deployment if it is new
+func (s *BOSHServer) SetVMs(deployment string, vms []gogobosh.VM) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.vms[deployment] = vms
+}
+
+// SetManifest - replaces the manifest of a deployment
+func (s *BOSHServer) SetManifest(deployment, manifest string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.manifests[deployment] = manifest
+}
+
+// SetCloudConfig - replaces the content of the cloud config
+func (s *BOSHServer) SetCloudConfig(content string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.cloudConfig = content
+}
+
+// Fail - makes the next count deployment requests return a 500 error
+func (s *BOSHServer) Fail(count int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.failures = count
+}
+
+// Client - returns a gogobosh client for the stub
+func (s *BOSHServer) Client() (*gogobosh.Client, error) {
+	return gogobosh.NewClient(&gogobosh.Config{
+		BOSHAddress: s.URL,
+		Username:    "admin",
+		Password:    "admin",
+	})
+}
+
+func (s *BOSHServer) failed(w http.ResponseWriter) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.failures == 0 {
+		return false
+	}
+	s.failures--
+	http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	return true
+}
+
+func (s *BOSHServer) info(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]interface{}{
+		"name":                "stub-director",
+		"uuid":                "stub-director-uuid",
+		"version":             "270.0.0",
+		"user_authentication": map[string]interface{}{"type": "basic", "options": map[string]string{}},
+	})
+}
+
+func (s *BOSHServer) deployments(w http.ResponseWriter, r *http.Request) {
+	if s.failed(w) {
+		return
+	}
+	s.mutex.Lock()
+	var names []string
+	for name := range s.vms {
+		names = append(names, name)
+	}
+	s.mutex.Unlock()
+	sort.Strings(names)
+	deployments := []gogobosh.Deployment{}
+	for _, name := range names {
+		deployments = append(deployments, gogobosh.Deployment{Name: name})
+	}
+	writeJSON(w, deployments)
+}
+
+// deployment - serves the manifest of a deployment, or its VMs when the path ends in /vms
+func (s *BOSHServer) deployment(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/deployments/")
+	if strings.HasSuffix(name, "/vms") {
+		s.deploymentVMs(w, r, strings.TrimSuffix(name, "/vms"))
+		return
+	}
+	if strings.Contains(name, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	if s.failed(w) {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.vms[name]; !ok {
+		http.Error(w, fmt.Sprintf("Deployment '%s' doesn't exist", name), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, gogobosh.Manifest{Manifest: s.manifests[name]})
+}
+
+// configs - serves the latest cloud config as a list, as the director does for "configs?type=cloud&latest=true"
+func (s *BOSHServer) configs(w http.ResponseWriter, r *http.Request) {
+	if s.failed(w) {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	configs := []map[string]string{}
+	if s.cloudConfig != "" && r.URL.Query().Get("type") == "cloud" {
+		configs = append(configs, map[string]string{"id": "1", "name": "default", "type": "cloud", "content": s.cloudConfig})
+	}
+	writeJSON(w, configs)
+}
+
+// deploymentVMs - starts a task whose result holds the VMs of the deployment, BOSH runs "vms?format=full" as a task
+func (s *BOSHServer) deploymentVMs(w http.ResponseWriter, r *http.Request, name string) {
+	if s.failed(w) {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	vms, ok := s.vms[name]
+	if !ok {
+		http.Error(w, fmt.Sprintf("Deployment '%s' doesn't exist", name), http.StatusNotFound)
+		return
+	}
+	var lines []string
+	for _, vm := range vms {
+		line, _ := json.Marshal(vm)
+		lines = append(lines, string(line))
+	}
+	id := len(s.tasks) + 1
+	s.tasks[id] = strings.Join(lines, "\n") + "\n"
+	writeJSON(w, gogobosh.Task{ID: id, State: "queued", Description: "retrieve vm-stats"})
+}
+
+func (s *BOSHServer) task(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/tasks/"), "/")
+	id, err := strconv.Atoi(parts[0])
+	s.mutex.Lock()
+	result, ok := s.tasks[id]
+	s.mutex.Unlock()
+	if err != nil || !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if len(parts) == 2 && parts[1] == "output" {
+		fmt.Fprint(w, result)
+		return
+	}
+	writeJSON(w, gogobosh.Task{ID: id, State: "done", Description: "retrieve vm-stats"})
+}
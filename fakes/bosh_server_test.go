@@ -0,0 +1,67 @@
+package fakes_test
+
+import (
+	"github.com/FidelityInternational/virgil/fakes"
+	"github.com/cloudfoundry-community/gogobosh"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BOSHServer", func() {
+	var server *fakes.BOSHServer
+
+	BeforeEach(func() {
+		server = fakes.NewBOSHServer(map[string][]gogobosh.VM{
+			"cf-123": {
+				{JobName: "diego_cell", IPs: []string{"10.0.16.1"}},
+				{JobName: "router", IPs: []string{"10.0.16.10"}},
+			},
+			"concourse": {},
+		})
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("serves deployments and their VMs to a gogobosh client", func() {
+		boshClient, err := server.Client()
+		Expect(err).ToNot(HaveOccurred())
+		deployments, err := boshClient.GetDeployments()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deployments).To(HaveLen(2))
+		Expect(deployments[0].Name).To(Equal("cf-123"))
+		vms, err := boshClient.GetDeploymentVMs("cf-123")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vms).To(HaveLen(2))
+		Expect(vms[0].JobName).To(Equal("diego_cell"))
+		Expect(vms[0].IPs).To(Equal([]string{"10.0.16.1"}))
+	})
+
+	It("serves replaced VMs", func() {
+		boshClient, err := server.Client()
+		Expect(err).ToNot(HaveOccurred())
+		server.SetVMs("cf-123", []gogobosh.VM{{JobName: "diego_cell", IPs: []string{"10.0.16.5"}}})
+		vms, err := boshClient.GetDeploymentVMs("cf-123")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vms).To(HaveLen(1))
+		Expect(vms[0].IPs).To(Equal([]string{"10.0.16.5"}))
+	})
+
+	It("serves deployment manifests", func() {
+		boshClient, err := server.Client()
+		Expect(err).ToNot(HaveOccurred())
+		server.SetManifest("cf-123", "name: cf-123\n")
+		manifest, err := boshClient.GetDeployment("cf-123")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(manifest.Manifest).To(Equal("name: cf-123\n"))
+	})
+
+	It("fails requests when asked to", func() {
+		boshClient, err := server.Client()
+		Expect(err).ToNot(HaveOccurred())
+		server.Fail(1)
+		_, err = boshClient.GetDeployments()
+		Expect(err).To(HaveOccurred())
+	})
+})
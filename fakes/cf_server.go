@@ -0,0 +1,208 @@
+package fakes
+
+import (
+	"encoding/json"
+	"github.com/cloudfoundry/go-cfclient/v3/client"
+	"github.com/cloudfoundry/go-cfclient/v3/config"
+	"github.com/cloudfoundry/go-cfclient/v3/resource"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// CFServer - a stub Cloud Foundry API and UAA serving enough of the v3 API for virgil to run against
+type CFServer struct {
+	*httptest.Server
+	mutex     sync.Mutex
+	secGroups []resource.SecurityGroup
+	orgs      []resource.Organization
+	spaces    []resource.Space
+	apps      []resource.App
+	failures  int
+	requests  int
+}
+
+// NewCFServer - starts a CFServer returning the given security groups, call Close when finished
+func NewCFServer(secGroups []resource.SecurityGroup) *CFServer {
+	s := &CFServer{secGroups: secGroups}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.root)
+	mux.HandleFunc("/oauth/token", s.token)
+	mux.HandleFunc("/v3/security_groups", s.securityGroups)
+	mux.HandleFunc("/v3/spaces", s.listSpaces)
+	mux.HandleFunc("/v3/spaces/", s.spaceSecurityGroups)
+	mux.HandleFunc("/v3/apps", s.listApps)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// SetSecurityGroups - replaces the security groups returned by later requests
+func (s *CFServer) SetSecurityGroups(secGroups []resource.SecurityGroup) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.secGroups = secGroups
+}
+
+// SetSpaces - replaces the orgs, spaces and apps returned by later requests, spaces are listed with the
+// orgs they belong to included
+func (s *CFServer) SetSpaces(orgs []resource.Organization, spaces []resource.Space, apps []resource.App) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.orgs = orgs
+	s.spaces = spaces
+	s.apps = apps
+}
+
+// Fail - makes the next count API requests return a 500 error
+func (s *CFServer) Fail(count int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.failures = count
+}
+
+// Requests - returns how many security group list requests the stub has served
+func (s *CFServer) Requests() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.requests
+}
+
+// Client - returns a go-cfclient client logged in to the stub
+func (s *CFServer) Client() (*client.Client, error) {
+	cfConfig, err := config.New(s.URL, config.UserPassword("admin", "admin"))
+	if err != nil {
+		return nil, err
+	}
+	return client.New(cfConfig)
+}
+
+func (s *CFServer) root(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"links": map[string]interface{}{
+			"self":    map[string]string{"href": s.URL},
+			"login":   map[string]string{"href": s.URL},
+			"uaa":     map[string]string{"href": s.URL},
+			"app_ssh": map[string]interface{}{"href": "ssh.example.com:2222", "meta": map[string]string{"oauth_client": "ssh-proxy"}},
+		},
+	})
+}
+
+func (s *CFServer) token(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]interface{}{
+		"access_token":  "stub-access-token",
+		"refresh_token": "stub-refresh-token",
+		"token_type":    "bearer",
+		"expires_in":    3600,
+	})
+}
+
+func (s *CFServer) securityGroups(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	s.requests++
+	secGroups := s.secGroups
+	s.mutex.Unlock()
+	if s.failed() {
+		writeCFError(w)
+		return
+	}
+	resources := make([]resource.SecurityGroup, 0, len(secGroups))
+	resources = append(resources, secGroups...)
+	writeJSON(w, map[string]interface{}{
+		"pagination": resource.Pagination{TotalResults: len(resources), TotalPages: 1},
+		"resources":  resources,
+	})
+}
+
+func (s *CFServer) listSpaces(w http.ResponseWriter, r *http.Request) {
+	if s.failed() {
+		writeCFError(w)
+		return
+	}
+	s.mutex.Lock()
+	spaces := append([]resource.Space{}, s.spaces...)
+	orgs := append([]resource.Organization{}, s.orgs...)
+	s.mutex.Unlock()
+	writeJSON(w, map[string]interface{}{
+		"pagination": resource.Pagination{TotalResults: len(spaces), TotalPages: 1},
+		"resources":  spaces,
+		"included":   map[string]interface{}{"organizations": orgs},
+	})
+}
+
+// spaceSecurityGroups - serves the running or staging security groups of a space, those enabled globally or bound
+// to the space for the lifecycle
+func (s *CFServer) spaceSecurityGroups(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v3/spaces/"), "/")
+	if len(parts) != 2 || (parts[1] != "running_security_groups" && parts[1] != "staging_security_groups") {
+		http.NotFound(w, r)
+		return
+	}
+	if s.failed() {
+		writeCFError(w)
+		return
+	}
+	s.mutex.Lock()
+	secGroups := s.secGroups
+	s.mutex.Unlock()
+	resources := []resource.SecurityGroup{}
+	for _, secGroup := range secGroups {
+		global, spaces := secGroup.GloballyEnabled.Running, secGroup.Relationships.RunningSpaces.Data
+		if parts[1] == "staging_security_groups" {
+			global, spaces = secGroup.GloballyEnabled.Staging, secGroup.Relationships.StagingSpaces.Data
+		}
+		bound := global != nil && *global
+		for _, space := range spaces {
+			bound = bound || space.GUID == parts[0]
+		}
+		if bound {
+			resources = append(resources, secGroup)
+		}
+	}
+	writeJSON(w, map[string]interface{}{
+		"pagination": resource.Pagination{TotalResults: len(resources), TotalPages: 1},
+		"resources":  resources,
+	})
+}
+
+func (s *CFServer) listApps(w http.ResponseWriter, r *http.Request) {
+	if s.failed() {
+		writeCFError(w)
+		return
+	}
+	s.mutex.Lock()
+	apps := append([]resource.App{}, s.apps...)
+	s.mutex.Unlock()
+	writeJSON(w, map[string]interface{}{
+		"pagination": resource.Pagination{TotalResults: len(apps), TotalPages: 1},
+		"resources":  apps,
+	})
+}
+
+// failed - returns true when the request should fail, counting down the failures asked for
+func (s *CFServer) failed() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.failures > 0 {
+		s.failures--
+		return true
+	}
+	return false
+}
+
+func writeCFError(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusInternalServerError)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": []map[string]interface{}{{"code": 10001, "title": "CF-UnknownError", "detail": "An unknown error occurred."}},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
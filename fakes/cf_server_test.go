@@ -0,0 +1,91 @@
+package fakes_test
+
+import (
+	"context"
+	"github.com/FidelityInternational/virgil/fakes"
+	"github.com/cloudfoundry/go-cfclient/v3/resource"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CFServer", func() {
+	var server *fakes.CFServer
+
+	BeforeEach(func() {
+		server = fakes.NewCFServer([]resource.SecurityGroup{
+			fakes.SecurityGroup("web", fakes.Rule("tcp", "10.1.0.0/16", "443")),
+		})
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("serves security groups to a go-cfclient client", func() {
+		cfClient, err := server.Client()
+		Expect(err).ToNot(HaveOccurred())
+		secGroups, err := cfClient.SecurityGroups.ListAll(context.Background(), nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(secGroups).To(HaveLen(1))
+		Expect(secGroups[0].Name).To(Equal("web"))
+		Expect(*secGroups[0].Rules[0].Ports).To(Equal("443"))
+		Expect(*secGroups[0].GloballyEnabled.Running).To(BeTrue())
+		Expect(server.Requests()).To(Equal(1))
+	})
+
+	It("serves replaced security groups", func() {
+		cfClient, err := server.Client()
+		Expect(err).ToNot(HaveOccurred())
+		server.SetSecurityGroups(nil)
+		secGroups, err := cfClient.SecurityGroups.ListAll(context.Background(), nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(secGroups).To(BeEmpty())
+	})
+
+	It("fails requests when asked to", func() {
+		cfClient, err := server.Client()
+		Expect(err).ToNot(HaveOccurred())
+		server.Fail(1)
+		_, err = cfClient.SecurityGroups.ListAll(context.Background(), nil)
+		Expect(err).To(HaveOccurred())
+		_, err = cfClient.SecurityGroups.ListAll(context.Background(), nil)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("serves spaces with their orgs and apps", func() {
+		cfClient, err := server.Client()
+		Expect(err).ToNot(HaveOccurred())
+		server.SetSpaces(
+			[]resource.Organization{fakes.Org("platform")},
+			[]resource.Space{fakes.Space("dev", "platform")},
+			[]resource.App{fakes.App("api", "dev")},
+		)
+		spaces, orgs, err := cfClient.Spaces.ListIncludeOrganizationsAll(context.Background(), nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(spaces).To(HaveLen(1))
+		Expect(spaces[0].Relationships.Organization.Data.GUID).To(Equal("platform-guid"))
+		Expect(orgs).To(HaveLen(1))
+		Expect(orgs[0].Name).To(Equal("platform"))
+		apps, err := cfClient.Applications.ListAll(context.Background(), nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(apps).To(HaveLen(1))
+		Expect(apps[0].Relationships.Space.Data.GUID).To(Equal("dev-guid"))
+	})
+
+	It("serves the running and staging security groups of a space", func() {
+		cfClient, err := server.Client()
+		Expect(err).ToNot(HaveOccurred())
+		bound := fakes.SecurityGroup("bound", fakes.Rule("tcp", "10.2.0.0/16", "5432"))
+		bound.GloballyEnabled.Running = new(bool)
+		bound.Relationships.StagingSpaces.Data = []resource.Relationship{{GUID: "dev-guid"}}
+		server.SetSecurityGroups([]resource.SecurityGroup{fakes.SecurityGroup("web", fakes.Rule("tcp", "10.1.0.0/16", "443")), bound})
+		running, err := cfClient.SecurityGroups.ListRunningForSpaceAll(context.Background(), "dev-guid", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(running).To(HaveLen(1))
+		Expect(running[0].Name).To(Equal("web"))
+		staging, err := cfClient.SecurityGroups.ListStagingForSpaceAll(context.Background(), "dev-guid", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(staging).To(HaveLen(1))
+		Expect(staging[0].Name).To(Equal("bound"))
+	})
+})
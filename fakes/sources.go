@@ -0,0 +1,173 @@
+package fakes
+
+import (
+	"context"
+	"github.com/cloudfoundry-community/gogobosh"
+	"github.com/cloudfoundry/go-cfclient/v3/resource"
+	"sync"
+)
+
+// SecurityGroupSource - an in-memory virgil.SecurityGroupSource returning SecGroups, or Err when set
+type SecurityGroupSource struct {
+	mutex     sync.Mutex
+	SecGroups []resource.SecurityGroup
+	Err       error
+	calls     int
+}
+
+// SecurityGroups - returns the configured security groups
+func (s *SecurityGroupSource) SecurityGroups(ctx context.Context) ([]resource.SecurityGroup, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.calls++
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if s.Err != nil {
+		return nil, s.Err
+	}
+	return append([]resource.SecurityGroup(nil), s.SecGroups...), nil
+}
+
+// Set - replaces the security groups returned by later calls
+func (s *SecurityGroupSource) Set(secGroups []resource.SecurityGroup) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.SecGroups = secGroups
+}
+
+// Calls - returns how many times SecurityGroups has been called
+func (s *SecurityGroupSource) Calls() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.calls
+}
+
+// CellSource - an in-memory virgil.CellSource and virgil.NetworkSource returning DeploymentList, the VMs and
+// manifest of each deployment and CloudConfigList, or Err when set
+type CellSource struct {
+	mutex           sync.Mutex
+	DeploymentList  []gogobosh.Deployment
+	VMs             map[string][]gogobosh.VM
+	Manifests       map[string]string
+	CloudConfigList []string
+	Err             error
+}
+
+// NewCellSource - returns a CellSource holding a single deployment with the given VMs
+func NewCellSource(deployment string, vms ...gogobosh.VM) *CellSource {
+	return &CellSource{
+		DeploymentList: []gogobosh.Deployment{{Name: deployment}},
+		VMs:            map[string][]gogobosh.VM{deployment: vms},
+	}
+}
+
+// Deployments - returns the configured deployments
+func (s *CellSource) Deployments(ctx context.Context) ([]gogobosh.Deployment, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if s.Err != nil {
+		return nil, s.Err
+	}
+	return append([]gogobosh.Deployment(nil), s.DeploymentList...), nil
+}
+
+// DeploymentVMs - returns the configured VMs of the deployment
+func (s *CellSource) DeploymentVMs(ctx context.Context, deployment string) ([]gogobosh.VM, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if s.Err != nil {
+		return nil, s.Err
+	}
+	return append([]gogobosh.VM(nil), s.VMs[deployment]...), nil
+}
+
+// SetVMs - replaces the VMs of a deployment returned by later calls
+func (s *CellSource) SetVMs(deployment string, vms []gogobosh.VM) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.VMs == nil {
+		s.VMs = make(map[string][]gogobosh.VM)
+	}
+	s.VMs[deployment] = vms
+}
+
+// DeploymentManifest - returns the configured manifest of the deployment
+func (s *CellSource) DeploymentManifest(ctx context.Context, deployment string) (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if s.Err != nil {
+		return "", s.Err
+	}
+	return s.Manifests[deployment], nil
+}
+
+// CloudConfigs - returns the configured cloud configs
+func (s *CellSource) CloudConfigs(ctx context.Context) ([]string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if s.Err != nil {
+		return nil, s.Err
+	}
+	return append([]string(nil), s.CloudConfigList...), nil
+}
+
+// SecurityGroup - returns a globally running enabled security group with the given rules
+func SecurityGroup(name string, rules ...resource.SecurityGroupRule) resource.SecurityGroup {
+	running, staging := true, false
+	return resource.SecurityGroup{
+		Resource: resource.Resource{GUID: name + "-guid"},
+		Name:     name,
+		GloballyEnabled: resource.SecurityGroupGloballyEnabled{
+			Running: &running,
+			Staging: &staging,
+		},
+		Rules: rules,
+	}
+}
+
+// Rule - returns a security group rule, ports are omitted when empty
+func Rule(protocol, destination, ports string) resource.SecurityGroupRule {
+	rule := resource.SecurityGroupRule{Protocol: protocol, Destination: destination}
+	if ports != "" {
+		rule.Ports = &ports
+	}
+	return rule
+}
+
+// Org - returns an org with the given name
+func Org(name string) resource.Organization {
+	return resource.Organization{Resource: resource.Resource{GUID: name + "-guid"}, Name: name}
+}
+
+// Space - returns a space with the given name in the named org
+func Space(name, org string) resource.Space {
+	return resource.Space{
+		Resource: resource.Resource{GUID: name + "-guid"},
+		Name:     name,
+		Relationships: &resource.SpaceRelationships{
+			Organization: &resource.ToOneRelationship{Data: &resource.Relationship{GUID: org + "-guid"}},
+		},
+	}
+}
+
+// App - returns an app with the given name in the named space
+func App(name, space string) resource.App {
+	return resource.App{
+		Resource:      resource.Resource{GUID: name + "-guid"},
+		Name:          name,
+		Relationships: resource.SpaceRelationship{Space: resource.ToOneRelationship{Data: &resource.Relationship{GUID: space + "-guid"}}},
+	}
+}
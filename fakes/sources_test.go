@@ -0,0 +1,79 @@
+package fakes_test
+
+import (
+	"context"
+	"errors"
+	"github.com/FidelityInternational/virgil/fakes"
+	"github.com/cloudfoundry-community/gogobosh"
+	"github.com/cloudfoundry/go-cfclient/v3/resource"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SecurityGroupSource", func() {
+	It("returns the configured security groups and counts calls", func() {
+		source := &fakes.SecurityGroupSource{SecGroups: []resource.SecurityGroup{fakes.SecurityGroup("web")}}
+		secGroups, err := source.SecurityGroups(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(secGroups).To(HaveLen(1))
+		source.Set(nil)
+		secGroups, err = source.SecurityGroups(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(secGroups).To(BeEmpty())
+		Expect(source.Calls()).To(Equal(2))
+	})
+
+	It("returns the configured error", func() {
+		source := &fakes.SecurityGroupSource{Err: errors.New("CF unavailable")}
+		_, err := source.SecurityGroups(context.Background())
+		Expect(err).To(MatchError("CF unavailable"))
+	})
+
+	It("returns an error when the context is cancelled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := (&fakes.SecurityGroupSource{}).SecurityGroups(ctx)
+		Expect(err).To(MatchError(context.Canceled))
+	})
+})
+
+var _ = Describe("CellSource", func() {
+	It("returns the deployment and its VMs", func() {
+		source := fakes.NewCellSource("cf-123", gogobosh.VM{JobName: "diego_cell", IPs: []string{"10.0.16.1"}})
+		deployments, err := source.Deployments(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deployments).To(Equal([]gogobosh.Deployment{{Name: "cf-123"}}))
+		vms, err := source.DeploymentVMs(context.Background(), "cf-123")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vms).To(HaveLen(1))
+		source.SetVMs("cf-123", nil)
+		vms, err = source.DeploymentVMs(context.Background(), "cf-123")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vms).To(BeEmpty())
+	})
+
+	It("returns the manifests and cloud configs", func() {
+		source := &fakes.CellSource{Manifests: map[string]string{"cf-123": "name: cf-123\n"}, CloudConfigList: []string{"networks: []\n"}}
+		manifest, err := source.DeploymentManifest(context.Background(), "cf-123")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(manifest).To(Equal("name: cf-123\n"))
+		cloudConfigs, err := source.CloudConfigs(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cloudConfigs).To(Equal([]string{"networks: []\n"}))
+	})
+
+	It("returns the configured error", func() {
+		source := &fakes.CellSource{Err: errors.New("director unavailable")}
+		_, err := source.Deployments(context.Background())
+		Expect(err).To(MatchError("director unavailable"))
+		_, err = source.CloudConfigs(context.Background())
+		Expect(err).To(MatchError("director unavailable"))
+	})
+})
+
+var _ = Describe("#Rule", func() {
+	It("omits empty ports", func() {
+		Expect(fakes.Rule("all", "10.0.0.0/8", "").Ports).To(BeNil())
+		Expect(*fakes.Rule("tcp", "10.0.0.0/8", "443").Ports).To(Equal("443"))
+	})
+})
@@ -0,0 +1,61 @@
+package virgil
+
+import (
+	"context"
+	"fmt"
+	"github.com/FidelityInternational/virgil/utility"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// GenerateAll - runs the generators concurrently, one per foundation, returning their results in the same order.
+// When any generator fails the first error is returned, prefixed with the name of its foundation
+func GenerateAll(ctx context.Context, generators []*Generator) ([]Result, error) {
+	results := make([]Result, len(generators))
+	errs := make([]error, len(generators))
+	var wg sync.WaitGroup
+	for i, generator := range generators {
+		wg.Add(1)
+		go func(i int, generator *Generator) {
+			defer wg.Done()
+			results[i], errs[i] = generator.Generate(ctx)
+		}(i, generator)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("Foundation %s: %s", generators[i].Foundation(), err)
+		}
+	}
+	return results, nil
+}
+
+// MergeResults - combines the results of several foundations into one, unioning the sources of firewall rules with
+// the same protocol, port and destination. Deployments are prefixed with their foundation name
+func MergeResults(results []Result) Result {
+	var (
+		merged      Result
+		ruleSets    []utility.FirewallRules
+		foundations []string
+	)
+	for _, result := range results {
+		foundations = append(foundations, result.Metadata.Foundation)
+		merged.Sources = append(merged.Sources, result.Sources...)
+		ruleSets = append(ruleSets, result.FirewallRules)
+		for _, deployment := range result.Metadata.Deployments {
+			merged.Metadata.Deployments = append(merged.Metadata.Deployments, fmt.Sprintf("%s/%s", result.Metadata.Foundation, deployment))
+		}
+		merged.Metadata.TotalSecurityGroups += result.Metadata.TotalSecurityGroups
+		merged.Metadata.SecurityGroups = append(merged.Metadata.SecurityGroups, result.Metadata.SecurityGroups...)
+		merged.Metadata.SkippedRules = append(merged.Metadata.SkippedRules, result.Metadata.SkippedRules...)
+		if result.Metadata.GeneratedAt.After(merged.Metadata.GeneratedAt) {
+			merged.Metadata.GeneratedAt = result.Metadata.GeneratedAt
+		}
+	}
+	utility.RemoveDuplicates(&merged.Sources)
+	sort.Strings(merged.Sources)
+	merged.FirewallRules = utility.MergeFirewallRules(ruleSets...)
+	merged.Metadata.Foundation = strings.Join(foundations, ",")
+	return merged
+}
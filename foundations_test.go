@@ -0,0 +1,63 @@
+package virgil_test
+
+import (
+	"context"
+	"errors"
+	"github.com/FidelityInternational/virgil"
+	"github.com/FidelityInternational/virgil/fakes"
+	"github.com/cloudfoundry-community/gogobosh"
+	"github.com/cloudfoundry/go-cfclient/v3/resource"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Foundations", func() {
+	var generators []*virgil.Generator
+
+	newGenerator := func(foundation, cellIP string, secGroups ...resource.SecurityGroup) *virgil.Generator {
+		return virgil.NewGenerator(
+			&fakes.SecurityGroupSource{SecGroups: secGroups},
+			fakes.NewCellSource("cf", gogobosh.VM{JobName: "diego_cell", IPs: []string{cellIP}}),
+			virgil.WithFoundation(foundation),
+		)
+	}
+
+	BeforeEach(func() {
+		generators = []*virgil.Generator{
+			newGenerator("dc1-a", "10.0.16.1", fakes.SecurityGroup("web", fakes.Rule("tcp", "10.1.0.0/16", "443"))),
+			newGenerator("dc1-b", "10.1.16.1", fakes.SecurityGroup("web", fakes.Rule("tcp", "10.1.0.0/16", "443")), fakes.SecurityGroup("dns", fakes.Rule("udp", "10.2.0.1", "53"))),
+		}
+	})
+
+	Describe("#GenerateAll", func() {
+		It("returns the result of each foundation in order", func() {
+			results, err := virgil.GenerateAll(context.Background(), generators)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(results).To(HaveLen(2))
+			Expect(results[0].Metadata.Foundation).To(Equal("dc1-a"))
+			Expect(results[1].Sources).To(Equal([]string{"10.1.16.1"}))
+		})
+
+		It("returns the first error with its foundation", func() {
+			failing := &fakes.SecurityGroupSource{Err: errors.New("CF API unavailable")}
+			generators = append(generators, virgil.NewGenerator(failing, fakes.NewCellSource("cf"), virgil.WithFoundation("dc1-c")))
+			_, err := virgil.GenerateAll(context.Background(), generators)
+			Expect(err).To(MatchError("Foundation dc1-c: CF API unavailable"))
+		})
+	})
+
+	Describe("#MergeResults", func() {
+		It("unions the sources of matching rules and combines the metadata", func() {
+			results, err := virgil.GenerateAll(context.Background(), generators)
+			Expect(err).ToNot(HaveOccurred())
+			merged := virgil.MergeResults(results)
+			Expect(merged.Sources).To(Equal([]string{"10.0.16.1", "10.1.16.1"}))
+			Expect(merged.FirewallRules.FirewallRules).To(HaveLen(2))
+			Expect(merged.FirewallRules.FirewallRules[0].Source).To(Equal([]string{"10.0.16.1", "10.1.16.1"}))
+			Expect(merged.FirewallRules.FirewallRules[1].Source).To(Equal([]string{"10.1.16.1"}))
+			Expect(merged.Metadata.Foundation).To(Equal("dc1-a,dc1-b"))
+			Expect(merged.Metadata.Deployments).To(Equal([]string{"dc1-a/cf", "dc1-b/cf"}))
+			Expect(merged.Metadata.TotalSecurityGroups).To(Equal(3))
+		})
+	})
+})
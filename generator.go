@@ -0,0 +1,288 @@
+package virgil
+
+import (
+	"context"
+	"fmt"
+	"github.com/FidelityInternational/virgil/bosh"
+	"github.com/FidelityInternational/virgil/render"
+	"github.com/FidelityInternational/virgil/utility"
+	"github.com/cloudfoundry/go-cfclient/v3/resource"
+	"io"
+	"regexp"
+	"sort"
+	"time"
+)
+
+const (
+	// DefaultDeploymentRegex - matches the Cloud Foundry deployment on the BOSH director
+	DefaultDeploymentRegex = "^cf.*"
+	// DefaultJobRegex - matches the DEA and Diego cell jobs whose IPs are the firewall rule sources
+	DefaultJobRegex = "^(dea|diego_cell|diego-cell).*"
+)
+
+// Filter - narrows the security groups firewall rules are generated from, applied after unused groups are removed
+type Filter func(secGroups []resource.SecurityGroup) []resource.SecurityGroup
+
+// Observer - notified as a Generator runs, used to collect metrics
+type Observer interface {
+	// Fetched - called after each CF or BOSH request with the generator's foundation, the duration and error
+	Fetched(foundation, source string, duration time.Duration, err error)
+	// Generated - called when a run succeeds
+	Generated(result Result, duration time.Duration)
+	// Failed - called when a run fails
+	Failed(err error, duration time.Duration)
+}
+
+const (
+	// FetchSecurityGroups - the Observer source name for fetching security groups
+	FetchSecurityGroups = "cf_security_groups"
+	// FetchDeployments - the Observer source name for fetching BOSH deployments
+	FetchDeployments = "bosh_deployments"
+	// FetchVMs - the Observer source name for fetching the VMs of the CF deployment
+	FetchVMs = "bosh_vms"
+	// FetchManifest - the Observer source name for fetching the manifest of the CF deployment
+	FetchManifest = "bosh_manifest"
+	// FetchCloudConfig - the Observer source name for fetching the BOSH cloud config
+	FetchCloudConfig = "bosh_cloud_config"
+)
+
+// Option - configures a Generator
+type Option func(*Generator)
+
+// Generator - fetches security groups and cell IPs and turns them into firewall rules
+type Generator struct {
+	securityGroups  SecurityGroupSource
+	cells           CellSource
+	networks        NetworkSource
+	excludeReserved bool
+	deploymentRegex string
+	jobRegex        string
+	filters         []Filter
+	renderer        render.Renderer
+	foundation      string
+	progress        io.Writer
+	observers       []Observer
+}
+
+// Result - the firewall rules produced by a Generator along with details of the run
+type Result struct {
+	Sources       []string
+	FirewallRules utility.FirewallRules
+	Metadata      render.Metadata
+}
+
+// WithDeploymentRegex - sets the regex used to find the Cloud Foundry deployment, defaults to DefaultDeploymentRegex
+func WithDeploymentRegex(regex string) Option {
+	return func(g *Generator) {
+		g.deploymentRegex = regex
+	}
+}
+
+// WithJobRegex - sets the regex used to find the cell VMs within the deployment, defaults to DefaultJobRegex
+func WithJobRegex(regex string) Option {
+	return func(g *Generator) {
+		g.jobRegex = regex
+	}
+}
+
+// WithSubnetSources - derives the sources from the cloud config subnets of the networks the cells are placed on,
+// rather than the IPs of the cell VMs, so the sources stay the same as cells are recreated or scaled out. When
+// excludeReserved is set the reserved and static ranges, gateway, network and broadcast addresses of each subnet are
+// left out
+func WithSubnetSources(networks NetworkSource, excludeReserved bool) Option {
+	return func(g *Generator) {
+		g.networks = networks
+		g.excludeReserved = excludeReserved
+	}
+}
+
+// WithFilters - adds filters applied, in order, to the used security groups
+func WithFilters(filters ...Filter) Option {
+	return func(g *Generator) {
+		g.filters = append(g.filters, filters...)
+	}
+}
+
+// WithRenderer - sets the renderer used by Render, defaults to YAML
+func WithRenderer(renderer render.Renderer) Option {
+	return func(g *Generator) {
+		g.renderer = renderer
+	}
+}
+
+// WithFoundation - sets the foundation name recorded in the result metadata
+func WithFoundation(foundation string) Option {
+	return func(g *Generator) {
+		g.foundation = foundation
+	}
+}
+
+// WithProgress - writes progress messages, such as those printed by the CLI, to the given writer
+func WithProgress(w io.Writer) Option {
+	return func(g *Generator) {
+		g.progress = w
+	}
+}
+
+// WithObserver - adds an Observer notified of fetches and the outcome of each run
+func WithObserver(observer Observer) Option {
+	return func(g *Generator) {
+		g.observers = append(g.observers, observer)
+	}
+}
+
+// NewGenerator - returns a Generator reading from the given security group and cell sources
+func NewGenerator(securityGroups SecurityGroupSource, cells CellSource, options ...Option) *Generator {
+	g := &Generator{
+		securityGroups:  securityGroups,
+		cells:           cells,
+		deploymentRegex: DefaultDeploymentRegex,
+		jobRegex:        DefaultJobRegex,
+		renderer:        render.YAMLRenderer{},
+		progress:        io.Discard,
+	}
+	for _, option := range options {
+		option(g)
+	}
+	return g
+}
+
+// Foundation - returns the foundation name recorded in the result metadata
+func (g *Generator) Foundation() string {
+	return g.foundation
+}
+
+// Generate - fetches the security groups and cell IPs and returns the compressed firewall rules
+func (g *Generator) Generate(ctx context.Context) (Result, error) {
+	start := time.Now()
+	result, err := g.generate(ctx)
+	for _, observer := range g.observers {
+		if err != nil {
+			observer.Failed(err, time.Since(start))
+		} else {
+			observer.Generated(result, time.Since(start))
+		}
+	}
+	return result, err
+}
+
+// fetched - notifies observers of a CF or BOSH request that started at start
+func (g *Generator) fetched(source string, start time.Time, err error) {
+	for _, observer := range g.observers {
+		observer.Fetched(g.foundation, source, time.Since(start), err)
+	}
+}
+
+func (g *Generator) generate(ctx context.Context) (Result, error) {
+	if _, err := regexp.Compile(g.deploymentRegex); err != nil {
+		return Result{}, fmt.Errorf("Deployment regex %s was invalid", g.deploymentRegex)
+	}
+	if _, err := regexp.Compile(g.jobRegex); err != nil {
+		return Result{}, fmt.Errorf("Job regex %s was invalid", g.jobRegex)
+	}
+	fmt.Fprintln(g.progress, "CF\t- Fetching Security Groups...")
+	start := time.Now()
+	allSecGroups, err := g.securityGroups.SecurityGroups(ctx)
+	g.fetched(FetchSecurityGroups, start, err)
+	if err != nil {
+		return Result{}, err
+	}
+	fmt.Fprintln(g.progress, "BOSH\t- Finding CF deployment...")
+	start = time.Now()
+	deployments, err := g.cells.Deployments(ctx)
+	g.fetched(FetchDeployments, start, err)
+	if err != nil {
+		return Result{}, err
+	}
+	deployment := bosh.FindDeployment(deployments, g.deploymentRegex)
+	if deployment == "" {
+		return Result{}, fmt.Errorf("No deployment matching %s was found", g.deploymentRegex)
+	}
+	var sources []string
+	if g.networks != nil {
+		sources, err = g.subnetSources(ctx, deployment)
+	} else {
+		sources, err = g.vmSources(ctx, deployment)
+	}
+	if err != nil {
+		return Result{}, err
+	}
+	fmt.Fprintln(g.progress, "Virgil\t- Filtering for 'used' Security Groups...")
+	secGroups := utility.GetUsedSecGroups(allSecGroups)
+	for _, filter := range g.filters {
+		secGroups = filter(secGroups)
+	}
+	fmt.Fprintln(g.progress, "Virgil\t- Generating Firewall Rules...")
+	skippedRules := utility.GetSkippedRules(secGroups)
+	for _, skippedRule := range skippedRules {
+		fmt.Fprintf(g.progress, "Virgil\t- WARNING: %s: %s rule to %s skipped - %s\n", skippedRule.SecurityGroup, skippedRule.Rule.Protocol, skippedRule.Rule.Destination, skippedRule.Reason)
+	}
+	return Result{
+		Sources:       sources,
+		FirewallRules: utility.GetFirewallRules(sources, secGroups),
+		Metadata: render.Metadata{
+			GeneratedAt:         time.Now().UTC(),
+			Foundation:          g.foundation,
+			Deployments:         []string{deployment},
+			TotalSecurityGroups: len(allSecGroups),
+			SecurityGroups:      secGroups,
+			SkippedRules:        skippedRules,
+		},
+	}, nil
+}
+
+// vmSources - returns the IPs of the cell VMs in the deployment
+func (g *Generator) vmSources(ctx context.Context, deployment string) ([]string, error) {
+	fmt.Fprintln(g.progress, "BOSH\t- Fetching DEA/Diego Cell VM details...")
+	start := time.Now()
+	boshVMs, err := g.cells.DeploymentVMs(ctx, deployment)
+	g.fetched(FetchVMs, start, err)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintln(g.progress, "BOSH\t- Fetching DEA/Diego Cell VM IPs...")
+	sources := bosh.GetAllIPs(bosh.FindVMs(boshVMs, g.jobRegex))
+	sort.Strings(sources)
+	return sources, nil
+}
+
+// subnetSources - returns the CIDRs of the cloud config subnets the cell instance groups of the deployment use
+func (g *Generator) subnetSources(ctx context.Context, deployment string) ([]string, error) {
+	fmt.Fprintln(g.progress, "BOSH\t- Fetching CF deployment manifest...")
+	start := time.Now()
+	manifest, err := g.networks.DeploymentManifest(ctx, deployment)
+	g.fetched(FetchManifest, start, err)
+	if err != nil {
+		return nil, err
+	}
+	cellNetworks, err := bosh.FindCellNetworks(manifest, g.jobRegex)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintln(g.progress, "BOSH\t- Fetching cloud config...")
+	start = time.Now()
+	cloudConfigs, err := g.networks.CloudConfigs(ctx)
+	g.fetched(FetchCloudConfig, start, err)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintln(g.progress, "BOSH\t- Finding DEA/Diego Cell subnets...")
+	sources, warnings, err := bosh.GetSubnetSources(cloudConfigs, cellNetworks, g.excludeReserved)
+	if err != nil {
+		return nil, err
+	}
+	for _, warning := range warnings {
+		fmt.Fprintf(g.progress, "BOSH\t- WARNING: %s\n", warning)
+	}
+	return sources, nil
+}
+
+// Render - generates the firewall rules and writes them to w using the configured renderer
+func (g *Generator) Render(ctx context.Context, w io.Writer) error {
+	result, err := g.Generate(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(g.progress, "Virgil\t- Rendering Firewall Rules as %s...\n", g.renderer.Name())
+	return g.renderer.Render(w, result.FirewallRules, result.Metadata)
+}
@@ -0,0 +1,214 @@
+package virgil_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/FidelityInternational/virgil"
+	"github.com/FidelityInternational/virgil/fakes"
+	"github.com/FidelityInternational/virgil/render"
+	"github.com/FidelityInternational/virgil/utility"
+	"github.com/cloudfoundry-community/gogobosh"
+	"github.com/cloudfoundry/go-cfclient/v3/resource"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"time"
+)
+
+var _ = Describe("Generator", func() {
+	var (
+		secGroups *fakes.SecurityGroupSource
+		cells     *fakes.CellSource
+	)
+
+	BeforeEach(func() {
+		unused := fakes.SecurityGroup("unused", fakes.Rule("tcp", "10.9.0.0/16", "22"))
+		unused.GloballyEnabled.Running = utility.BoolPtr(false)
+		secGroups = &fakes.SecurityGroupSource{
+			SecGroups: []resource.SecurityGroup{
+				fakes.SecurityGroup("dns", fakes.Rule("udp", "10.2.0.1", "53")),
+				fakes.SecurityGroup("web", fakes.Rule("tcp", "10.1.0.0/16", "443")),
+				unused,
+			},
+		}
+		cells = &fakes.CellSource{
+			DeploymentList: []gogobosh.Deployment{{Name: "concourse"}, {Name: "cf-12345"}, {Name: "cf-other"}},
+			VMs: map[string][]gogobosh.VM{
+				"cf-12345": {
+					{JobName: "diego_cell", IPs: []string{"10.0.16.2"}},
+					{JobName: "diego_cell", IPs: []string{"10.0.16.1"}},
+					{JobName: "router", IPs: []string{"10.0.16.10"}},
+				},
+				"cf-other": {
+					{JobName: "isolated_cell", IPs: []string{"10.0.32.1"}},
+				},
+			},
+		}
+	})
+
+	Describe("#Generate", func() {
+		It("returns firewall rules from the used security groups and cell IPs", func() {
+			result, err := virgil.NewGenerator(secGroups, cells, virgil.WithFoundation("sys.example.com")).Generate(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Sources).To(Equal([]string{"10.0.16.1", "10.0.16.2"}))
+			Expect(result.FirewallRules.FirewallRules).To(ConsistOf(
+				utility.FirewallRule{Protocol: "udp", Port: "53", Destination: []string{"10.2.0.1"}, Source: []string{"10.0.16.1", "10.0.16.2"}},
+				utility.FirewallRule{Protocol: "tcp", Port: "443", Destination: []string{"10.1.0.0/16"}, Source: []string{"10.0.16.1", "10.0.16.2"}},
+			))
+			Expect(result.Metadata.Foundation).To(Equal("sys.example.com"))
+			Expect(result.Metadata.Deployments).To(Equal([]string{"cf-12345"}))
+			Expect(result.Metadata.TotalSecurityGroups).To(Equal(3))
+			Expect(result.Metadata.SecurityGroups).To(HaveLen(2))
+		})
+
+		It("uses the deployment and job regexes", func() {
+			result, err := virgil.NewGenerator(secGroups, cells,
+				virgil.WithDeploymentRegex("^cf-other$"),
+				virgil.WithJobRegex("^isolated_cell$"),
+			).Generate(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Sources).To(Equal([]string{"10.0.32.1"}))
+			Expect(result.Metadata.Deployments).To(Equal([]string{"cf-other"}))
+		})
+
+		It("applies filters to the used security groups", func() {
+			onlyWeb := func(secGroups []resource.SecurityGroup) []resource.SecurityGroup {
+				var filtered []resource.SecurityGroup
+				for _, secGroup := range secGroups {
+					if secGroup.Name == "web" {
+						filtered = append(filtered, secGroup)
+					}
+				}
+				return filtered
+			}
+			result, err := virgil.NewGenerator(secGroups, cells, virgil.WithFilters(onlyWeb)).Generate(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.FirewallRules.FirewallRules).To(HaveLen(1))
+			Expect(result.FirewallRules.FirewallRules[0].Port).To(Equal("443"))
+		})
+
+		It("records skipped rules and reports them as progress", func() {
+			secGroups.SecGroups = append(secGroups.SecGroups, fakes.SecurityGroup("ping", fakes.Rule("icmp", "10.3.0.1", "")))
+			var progress bytes.Buffer
+			result, err := virgil.NewGenerator(secGroups, cells, virgil.WithProgress(&progress)).Generate(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Metadata.SkippedRules).To(HaveLen(1))
+			Expect(progress.String()).To(ContainSubstring("CF\t- Fetching Security Groups..."))
+			Expect(progress.String()).To(ContainSubstring("WARNING: ping: icmp rule to 10.3.0.1 skipped"))
+		})
+
+		It("returns an error when no deployment matches", func() {
+			_, err := virgil.NewGenerator(secGroups, cells, virgil.WithDeploymentRegex("^bosh$")).Generate(context.Background())
+			Expect(err).To(MatchError("No deployment matching ^bosh$ was found"))
+		})
+
+		It("returns an error when a regex is invalid", func() {
+			_, err := virgil.NewGenerator(secGroups, cells, virgil.WithJobRegex("(")).Generate(context.Background())
+			Expect(err).To(MatchError("Job regex ( was invalid"))
+		})
+
+		It("returns source errors", func() {
+			cells.Err = errors.New("director unavailable")
+			_, err := virgil.NewGenerator(secGroups, cells).Generate(context.Background())
+			Expect(err).To(MatchError("director unavailable"))
+		})
+
+		It("derives sources from the cloud config subnets of the cell networks", func() {
+			cells.Manifests = map[string]string{"cf-12345": `
+instance_groups:
+- name: diego_cell
+  azs: [z1]
+  networks:
+  - name: cf
+- name: router
+  networks:
+  - name: edge
+`}
+			cells.CloudConfigList = []string{`
+networks:
+- name: cf
+  type: manual
+  subnets:
+  - range: 10.0.16.0/20
+    az: z1
+    reserved: [10.0.16.1 - 10.0.16.255]
+  - range: 10.0.32.0/20
+    az: z2
+- name: edge
+  type: manual
+  subnets:
+  - range: 10.0.0.0/24
+`}
+			observer := &recordingObserver{}
+			result, err := virgil.NewGenerator(secGroups, cells, virgil.WithSubnetSources(cells, false), virgil.WithObserver(observer)).Generate(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Sources).To(Equal([]string{"10.0.16.0/20"}))
+			Expect(result.FirewallRules.FirewallRules[0].Source).To(Equal([]string{"10.0.16.0/20"}))
+			Expect(observer.fetched).To(Equal([]string{virgil.FetchSecurityGroups, virgil.FetchDeployments, virgil.FetchManifest, virgil.FetchCloudConfig}))
+
+			result, err = virgil.NewGenerator(secGroups, cells, virgil.WithSubnetSources(cells, true)).Generate(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Sources).To(Equal([]string{
+				"10.0.17.0/24", "10.0.18.0/23", "10.0.20.0/22", "10.0.24.0/22", "10.0.28.0/23", "10.0.30.0/24", "10.0.31.0/25",
+				"10.0.31.128/26", "10.0.31.192/27", "10.0.31.224/28", "10.0.31.240/29", "10.0.31.248/30", "10.0.31.252/31", "10.0.31.254/32",
+			}))
+		})
+
+		It("returns an error when the cell networks are not in the cloud config", func() {
+			cells.Manifests = map[string]string{"cf-12345": "instance_groups:\n- name: diego_cell\n  networks:\n  - name: cf\n"}
+			_, err := virgil.NewGenerator(secGroups, cells, virgil.WithSubnetSources(cells, false)).Generate(context.Background())
+			Expect(err).To(MatchError("Network cf could not be found in the cloud config"))
+		})
+
+		It("notifies observers of each fetch and the outcome of the run", func() {
+			observer := &recordingObserver{}
+			_, err := virgil.NewGenerator(secGroups, cells, virgil.WithObserver(observer)).Generate(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(observer.fetched).To(Equal([]string{virgil.FetchSecurityGroups, virgil.FetchDeployments, virgil.FetchVMs}))
+			Expect(observer.generated).To(Equal(1))
+
+			cells.Err = errors.New("director unavailable")
+			_, err = virgil.NewGenerator(secGroups, cells, virgil.WithObserver(observer)).Generate(context.Background())
+			Expect(err).To(HaveOccurred())
+			Expect(observer.fetchErrors).To(Equal([]string{virgil.FetchDeployments}))
+			Expect(observer.failed).To(MatchError("director unavailable"))
+		})
+	})
+
+	Describe("#Render", func() {
+		It("writes YAML by default", func() {
+			var output bytes.Buffer
+			Expect(virgil.NewGenerator(secGroups, cells).Render(context.Background(), &output)).To(Succeed())
+			Expect(output.String()).To(HavePrefix("---\n"))
+			Expect(output.String()).To(ContainSubstring("10.1.0.0/16"))
+		})
+
+		It("writes with the configured renderer", func() {
+			var output bytes.Buffer
+			Expect(virgil.NewGenerator(secGroups, cells, virgil.WithRenderer(render.JSONRenderer{})).Render(context.Background(), &output)).To(Succeed())
+			Expect(output.String()).To(HavePrefix("{"))
+		})
+	})
+})
+
+type recordingObserver struct {
+	fetched, fetchErrors []string
+	generated            int
+	failed               error
+}
+
+func (o *recordingObserver) Fetched(foundation, source string, duration time.Duration, err error) {
+	if err != nil {
+		o.fetchErrors = append(o.fetchErrors, source)
+		return
+	}
+	o.fetched = append(o.fetched, source)
+}
+
+func (o *recordingObserver) Generated(result virgil.Result, duration time.Duration) {
+	o.generated++
+}
+
+func (o *recordingObserver) Failed(err error, duration time.Duration) {
+	o.failed = err
+}
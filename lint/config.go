@@ -0,0 +1,157 @@
+package lint
+
+import (
+	"fmt"
+	"github.com/FidelityInternational/virgil/render"
+	"gopkg.in/yaml.v2"
+	"sort"
+	"strings"
+)
+
+// Severity - how serious a lint finding is, ordered from Info to Critical
+type Severity int
+
+const (
+	// Info - worth knowing about
+	Info Severity = iota
+	// Low - unlikely to be a problem
+	Low
+	// Medium - should be reviewed
+	Medium
+	// High - should be fixed
+	High
+	// Critical - must be fixed
+	Critical
+)
+
+var severityNames = []string{"info", "low", "medium", "high", "critical"}
+
+// ParseSeverity - parses a severity name such as high
+func ParseSeverity(name string) (Severity, error) {
+	for i, severityName := range severityNames {
+		if strings.EqualFold(name, severityName) {
+			return Severity(i), nil
+		}
+	}
+	return Info, fmt.Errorf("Severity %s was invalid, valid severities are %s", name, strings.Join(severityNames, ", "))
+}
+
+// String - returns the severity name
+func (s Severity) String() string {
+	if s < Info || s > Critical {
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+	return severityNames[s]
+}
+
+// MarshalText - writes the severity as its name in YAML and JSON
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText - reads the severity from its name in YAML and JSON
+func (s *Severity) UnmarshalText(text []byte) error {
+	severity, err := ParseSeverity(string(text))
+	if err != nil {
+		return err
+	}
+	*s = severity
+	return nil
+}
+
+// CheckConfig - overrides the severity of a check or disables it
+type CheckConfig struct {
+	Severity *Severity `yaml:"severity"`
+	Disabled bool      `yaml:"disabled"`
+}
+
+// Config - configures which checks run, their severities and the severity that fails a lint run
+type Config struct {
+	// Threshold - findings of this severity or above fail the run
+	Threshold Severity `yaml:"threshold"`
+	// RiskyPorts - ports flagged by the risky-port check
+	RiskyPorts []int `yaml:"risky_ports"`
+	// MaxPortRange - the most ports a rule may allow before the wide-port-range check flags it
+	MaxPortRange int `yaml:"max_port_range"`
+	// InternalNetworks - platform networks, such as the BOSH and CF networks, flagged by the internal-network check
+	InternalNetworks []string `yaml:"internal_networks"`
+	// Checks - per check overrides by check name
+	Checks map[string]CheckConfig `yaml:"checks"`
+	// Constraints - organisation rules checked along with the built in checks
+	Constraints []Constraint `yaml:"constraints"`
+}
+
+// DefaultConfig - returns the configuration used when no lint configuration file is given
+func DefaultConfig() Config {
+	return Config{
+		Threshold:    High,
+		RiskyPorts:   []int{22, 445, 3389},
+		MaxPortRange: 1000,
+		Checks:       map[string]CheckConfig{},
+	}
+}
+
+// ParseConfig - parses a YAML lint configuration, anything not set keeps its default
+func ParseConfig(data []byte) (Config, error) {
+	config := DefaultConfig()
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		return Config{}, fmt.Errorf("Lint configuration was invalid: %s", err)
+	}
+	return config, config.Validate()
+}
+
+// Validate - returns an error for unknown checks, invalid internal networks or invalid constraints
+func (c Config) Validate() error {
+	for name := range c.Checks {
+		if _, ok := checkByName(name); !ok {
+			return fmt.Errorf("Check %s is not supported, valid checks are %s", name, strings.Join(CheckNames(), ", "))
+		}
+	}
+	for _, network := range c.InternalNetworks {
+		if _, err := render.ParseAddress(network); err != nil {
+			return fmt.Errorf("Internal network %s was invalid", network)
+		}
+	}
+	names := make(map[string]bool)
+	for _, constraint := range c.Constraints {
+		if err := constraint.Validate(); err != nil {
+			return err
+		}
+		if names[constraint.Name] {
+			return fmt.Errorf("Constraint %s is defined more than once", constraint.Name)
+		}
+		names[constraint.Name] = true
+	}
+	return nil
+}
+
+// severity - returns the configured severity of a check and whether it is enabled
+func (c Config) severity(check Check) (Severity, bool) {
+	override, ok := c.Checks[check.Name]
+	if !ok {
+		return check.Severity, true
+	}
+	if override.Severity != nil {
+		return *override.Severity, !override.Disabled
+	}
+	return check.Severity, !override.Disabled
+}
+
+// CheckNames - returns the names of every check, sorted
+func CheckNames() []string {
+	var names []string
+	for _, check := range Checks {
+		names = append(names, check.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func checkByName(name string) (Check, bool) {
+	for _, check := range Checks {
+		if check.Name == name {
+			return check, true
+		}
+	}
+	return Check{}, false
+}
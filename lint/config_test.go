@@ -0,0 +1,68 @@
+package lint_test
+
+import (
+	"encoding/json"
+	"github.com/FidelityInternational/virgil/lint"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Config", func() {
+	Describe("#ParseSeverity", func() {
+		It("parses severity names", func() {
+			severity, err := lint.ParseSeverity("HIGH")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(severity).To(Equal(lint.High))
+			Expect(severity.String()).To(Equal("high"))
+		})
+
+		It("returns an error for unknown severities", func() {
+			_, err := lint.ParseSeverity("severe")
+			Expect(err).To(MatchError("Severity severe was invalid, valid severities are info, low, medium, high, critical"))
+		})
+
+		It("writes severities as names in JSON", func() {
+			data, err := json.Marshal(lint.Finding{Severity: lint.Critical})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(ContainSubstring(`"severity":"critical"`))
+		})
+	})
+
+	Describe("#ParseConfig", func() {
+		It("overrides the defaults", func() {
+			config, err := lint.ParseConfig([]byte(`---
+threshold: medium
+risky_ports: [23]
+internal_networks: [10.0.0.0/16]
+checks:
+  public-destination:
+    severity: critical
+  all-protocol:
+    disabled: true
+`))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config.Threshold).To(Equal(lint.Medium))
+			Expect(config.RiskyPorts).To(Equal([]int{23}))
+			Expect(config.MaxPortRange).To(Equal(1000))
+			Expect(*config.Checks["public-destination"].Severity).To(Equal(lint.Critical))
+			Expect(config.Checks["all-protocol"].Disabled).To(BeTrue())
+		})
+
+		It("returns an error for unknown checks", func() {
+			_, err := lint.ParseConfig([]byte("checks:\n  open-ports: {}\n"))
+			Expect(err).To(MatchError("Check open-ports is not supported, valid checks are all-protocol, any-destination, internal-network, public-destination, risky-port, wide-port-range"))
+		})
+
+		It("returns an error for invalid severities and networks", func() {
+			_, err := lint.ParseConfig([]byte("threshold: severe\n"))
+			Expect(err).To(MatchError(ContainSubstring("Severity severe was invalid")))
+			_, err = lint.ParseConfig([]byte("internal_networks: [10.0.0.0/99]\n"))
+			Expect(err).To(MatchError("Internal network 10.0.0.0/99 was invalid"))
+		})
+
+		It("returns an error for unknown keys", func() {
+			_, err := lint.ParseConfig([]byte("threshhold: high\n"))
+			Expect(err).To(MatchError(ContainSubstring("Lint configuration was invalid")))
+		})
+	})
+})
@@ -0,0 +1,267 @@
+package lint
+
+import (
+	"fmt"
+	"github.com/FidelityInternational/virgil/render"
+	"github.com/FidelityInternational/virgil/utility"
+	"github.com/cloudfoundry/go-cfclient/v3/resource"
+	"gopkg.in/yaml.v2"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Constraint - an organisation rule such as "no egress to 10.200.0.0/16 except on 443". Firewall rules
+// selected by Match must be within Allow, otherwise the security groups allowing them are in violation
+type Constraint struct {
+	Name        string    `yaml:"name"`
+	Description string    `yaml:"description"`
+	Severity    *Severity `yaml:"severity"`
+	Match       Selector  `yaml:"match"`
+	Allow       Selector  `yaml:"allow"`
+}
+
+// Selector - the rules a constraint matches or allows, fields that are not set match everything.
+// Matching needs a rule to overlap the protocols, ports and destinations, allowing needs it to be within them
+type Selector struct {
+	Protocols      []string `yaml:"protocols"`
+	Ports          []string `yaml:"ports"`
+	Destinations   []string `yaml:"destinations"`
+	SecurityGroups []string `yaml:"security_groups"`
+}
+
+// constraintsFile - the top level of a constraints file
+type constraintsFile struct {
+	Constraints []Constraint `yaml:"constraints"`
+}
+
+// ParseConstraints - parses a YAML constraints file
+func ParseConstraints(data []byte) ([]Constraint, error) {
+	var file constraintsFile
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return nil, fmt.Errorf("Constraints were invalid: %s", err)
+	}
+	for _, constraint := range file.Constraints {
+		if err := constraint.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return file.Constraints, nil
+}
+
+// Validate - returns an error when the constraint has no name or an invalid port, address or regex
+func (c Constraint) Validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("Constraint has no name")
+	}
+	if _, ok := checkByName(c.Name); ok {
+		return fmt.Errorf("Constraint %s has the same name as a lint check", c.Name)
+	}
+	for _, selector := range []Selector{c.Match, c.Allow} {
+		for _, port := range selector.Ports {
+			if _, _, err := render.SplitPortRange(port); err != nil {
+				return fmt.Errorf("Constraint %s: %s", c.Name, err)
+			}
+		}
+		for _, destination := range selector.Destinations {
+			if _, err := render.ParseAddress(destination); err != nil {
+				return fmt.Errorf("Constraint %s: %s", c.Name, err)
+			}
+		}
+		for _, pattern := range selector.SecurityGroups {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("Constraint %s: Security group regex %s was invalid", c.Name, pattern)
+			}
+		}
+	}
+	return nil
+}
+
+// Violations - returns whether target violates the constraint and the security groups allowing it that are
+// in violation, groups exempted by Allow.SecurityGroups are left out
+func (c Constraint) Violations(target Target, secGroups []string) ([]string, bool) {
+	if !c.matches(target) {
+		return nil, false
+	}
+	offenders := secGroups
+	if len(c.Match.SecurityGroups) > 0 {
+		if offenders = filterGroups(c.Match.SecurityGroups, offenders, true); len(offenders) == 0 {
+			return nil, false
+		}
+	}
+	if len(c.Allow.SecurityGroups) > 0 && len(offenders) > 0 {
+		if offenders = filterGroups(c.Allow.SecurityGroups, offenders, false); len(offenders) == 0 {
+			return nil, false
+		}
+	}
+	if c.allows(target) {
+		return nil, false
+	}
+	return offenders, true
+}
+
+// groupViolations - evaluates the constraint against the ports each security group in secGroupNames opens itself,
+// rather than the target's port range that may merge the ports of several groups, returning the groups in violation.
+// Targets without named security groups are evaluated as they are
+func (c Constraint) groupViolations(target Target, secGroupNames []string, secGroups []resource.SecurityGroup) ([]string, bool) {
+	if len(secGroupNames) == 0 {
+		return c.Violations(target, nil)
+	}
+	var offenders []string
+	for _, secGroup := range secGroups {
+		if !containsFold(secGroupNames, secGroup.Name) {
+			continue
+		}
+		for _, groupTarget := range groupTargets(target, secGroup) {
+			if groupOffenders, violated := c.Violations(groupTarget, []string{secGroup.Name}); violated {
+				offenders = append(offenders, groupOffenders...)
+				break
+			}
+		}
+	}
+	utility.RemoveDuplicates(&offenders)
+	return offenders, len(offenders) > 0
+}
+
+// groupTargets - returns a target for each port range the security group opens to the target's destination,
+// clipped to the target's ports
+func groupTargets(target Target, secGroup resource.SecurityGroup) []Target {
+	var targets []Target
+	for _, rule := range secGroup.Rules {
+		if !strings.EqualFold(rule.Protocol, target.Protocol) || rule.Destination != target.Destination {
+			continue
+		}
+		if target.Port == "" {
+			targets = append(targets, target)
+			continue
+		}
+		if rule.Ports == nil {
+			continue
+		}
+		targetStart, targetEnd, err := render.SplitPortRange(target.Port)
+		if err != nil {
+			continue
+		}
+		for _, port := range strings.Split(*rule.Ports, ",") {
+			start, end, err := render.SplitPortRange(strings.TrimSpace(port))
+			if err != nil || end < targetStart || targetEnd < start {
+				continue
+			}
+			if start < targetStart {
+				start = targetStart
+			}
+			if end > targetEnd {
+				end = targetEnd
+			}
+			groupTarget := target
+			groupTarget.Port = strconv.Itoa(start)
+			if start != end {
+				groupTarget.Port = fmt.Sprintf("%d-%d", start, end)
+			}
+			targets = append(targets, groupTarget)
+		}
+	}
+	return targets
+}
+
+// matches - returns true when target overlaps the match selector
+func (c Constraint) matches(target Target) bool {
+	if len(c.Match.Protocols) > 0 && !strings.EqualFold(target.Protocol, "all") && !containsFold(c.Match.Protocols, target.Protocol) {
+		return false
+	}
+	if len(c.Match.Ports) > 0 {
+		start, end, ok := targetPorts(target)
+		if !ok || !anyPort(c.Match.Ports, func(portStart, portEnd int) bool { return portStart <= end && start <= portEnd }) {
+			return false
+		}
+	}
+	if len(c.Match.Destinations) > 0 {
+		return target.Parsed && anyAddress(c.Match.Destinations, target.Address.Overlaps)
+	}
+	return true
+}
+
+// allows - returns true when target is within the allow selector, a constraint with nothing allowed denies every match
+func (c Constraint) allows(target Target) bool {
+	if len(c.Allow.Protocols) == 0 && len(c.Allow.Ports) == 0 && len(c.Allow.Destinations) == 0 {
+		return false
+	}
+	if len(c.Allow.Protocols) > 0 && !containsFold(c.Allow.Protocols, target.Protocol) {
+		return false
+	}
+	if len(c.Allow.Ports) > 0 {
+		start, end, ok := targetPorts(target)
+		if !ok || !anyPort(c.Allow.Ports, func(portStart, portEnd int) bool { return portStart <= start && end <= portEnd }) {
+			return false
+		}
+	}
+	if len(c.Allow.Destinations) > 0 {
+		return target.Parsed && anyAddress(c.Allow.Destinations, func(address render.Address) bool {
+			return address.Contains(target.Address)
+		})
+	}
+	return true
+}
+
+// message - describes the violation with the constraint's description when it has one
+func (c Constraint) message() string {
+	if c.Description == "" {
+		return fmt.Sprintf("violates constraint %s", c.Name)
+	}
+	return fmt.Sprintf("violates constraint %s: %s", c.Name, c.Description)
+}
+
+// filterGroups - returns the security groups matching any of the patterns when keep is true, or matching none of them
+func filterGroups(patterns, secGroups []string, keep bool) []string {
+	var groups []string
+	for _, secGroup := range secGroups {
+		matched := false
+		for _, pattern := range patterns {
+			if regexp.MustCompile(pattern).MatchString(secGroup) {
+				matched = true
+				break
+			}
+		}
+		if matched == keep {
+			groups = append(groups, secGroup)
+		}
+	}
+	return groups
+}
+
+// targetPorts - returns the ports a target allows, a rule without a port allows every port
+func targetPorts(target Target) (int, int, bool) {
+	if target.Port == "" {
+		return 0, 65535, true
+	}
+	return portRange(target)
+}
+
+func anyPort(ports []string, compare func(start, end int) bool) bool {
+	for _, port := range ports {
+		start, end, err := render.SplitPortRange(port)
+		if err == nil && compare(start, end) {
+			return true
+		}
+	}
+	return false
+}
+
+func anyAddress(addresses []string, compare func(address render.Address) bool) bool {
+	for _, address := range addresses {
+		parsed, err := render.ParseAddress(address)
+		if err == nil && compare(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
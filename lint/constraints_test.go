@@ -0,0 +1,136 @@
+package lint_test
+
+import (
+	"github.com/FidelityInternational/virgil"
+	"github.com/FidelityInternational/virgil/fakes"
+	"github.com/FidelityInternational/virgil/lint"
+	"github.com/FidelityInternational/virgil/render"
+	"github.com/FidelityInternational/virgil/utility"
+	"github.com/cloudfoundry/go-cfclient/v3/resource"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Constraints", func() {
+	const constraints = `---
+constraints:
+- name: database-https-only
+  description: No egress to 10.200.0.0/16 except on 443
+  match:
+    destinations: [10.200.0.0/16]
+  allow:
+    protocols: [tcp]
+    ports: ["443"]
+    security_groups: ["^platform-"]
+- name: no-public-dns
+  severity: medium
+  match:
+    protocols: [udp]
+    ports: ["53"]
+  allow:
+    destinations: [10.0.0.0/8]
+`
+
+	Describe("#ParseConstraints", func() {
+		It("parses a constraints file", func() {
+			parsed, err := lint.ParseConstraints([]byte(constraints))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(parsed).To(HaveLen(2))
+			Expect(parsed[0].Allow.Ports).To(Equal([]string{"443"}))
+			Expect(*parsed[1].Severity).To(Equal(lint.Medium))
+		})
+
+		It("returns an error for invalid constraints", func() {
+			_, err := lint.ParseConstraints([]byte("constraints:\n- match: {ports: [\"443\"]}\n"))
+			Expect(err).To(MatchError("Constraint has no name"))
+			_, err = lint.ParseConstraints([]byte("constraints:\n- name: db\n  match: {destinations: [10.200.0.0/99]}\n"))
+			Expect(err).To(MatchError("Constraint db: Address 10.200.0.0/99 was invalid"))
+			_, err = lint.ParseConstraints([]byte("constraints:\n- name: any-destination\n"))
+			Expect(err).To(MatchError("Constraint any-destination has the same name as a lint check"))
+			_, err = lint.ParseConstraints([]byte("constraints:\n- name: db\n  allow: {security_groups: [\"(\"]}\n"))
+			Expect(err).To(MatchError("Constraint db: Security group regex ( was invalid"))
+		})
+	})
+
+	Describe("#Lint", func() {
+		It("reports violations naming the offending security groups", func() {
+			parsed, err := lint.ParseConstraints([]byte(constraints))
+			Expect(err).ToNot(HaveOccurred())
+			secGroups := []resource.SecurityGroup{
+				fakes.SecurityGroup("app-db", fakes.Rule("tcp", "10.200.0.0/16", "5432")),
+				fakes.SecurityGroup("platform-db", fakes.Rule("tcp", "10.200.1.0/24", "5432")),
+				fakes.SecurityGroup("app-https", fakes.Rule("tcp", "10.200.0.0/16", "443")),
+				fakes.SecurityGroup("public-dns", fakes.Rule("udp", "8.8.8.8", "53")),
+				fakes.SecurityGroup("internal-dns", fakes.Rule("udp", "10.2.0.1", "53")),
+			}
+			result := virgil.Result{
+				FirewallRules: utility.GetFirewallRules([]string{"10.0.16.1"}, secGroups),
+				Metadata:      render.Metadata{SecurityGroups: secGroups},
+			}
+			config := lint.DefaultConfig()
+			config.Constraints = parsed
+			report, err := lint.Lint(result, config)
+			Expect(err).ToNot(HaveOccurred())
+			var violations []lint.Finding
+			for _, finding := range report.Findings {
+				if finding.Check == "database-https-only" || finding.Check == "no-public-dns" {
+					violations = append(violations, finding)
+				}
+			}
+			Expect(violations).To(Equal([]lint.Finding{
+				{
+					Check:          "database-https-only",
+					Severity:       lint.High,
+					Message:        "violates constraint database-https-only: No egress to 10.200.0.0/16 except on 443",
+					Protocol:       "tcp",
+					Port:           "5432",
+					Destination:    "10.200.0.0/16",
+					SecurityGroups: []string{"app-db"},
+				},
+				{
+					Check:          "no-public-dns",
+					Severity:       lint.Medium,
+					Message:        "violates constraint no-public-dns",
+					Protocol:       "udp",
+					Port:           "53",
+					Destination:    "8.8.8.8",
+					SecurityGroups: []string{"public-dns"},
+				},
+			}))
+		})
+
+		It("evaluates the ports each security group opens rather than the merged port range", func() {
+			parsed, err := lint.ParseConstraints([]byte(constraints))
+			Expect(err).ToNot(HaveOccurred())
+			secGroups := []resource.SecurityGroup{
+				fakes.SecurityGroup("app-https", fakes.Rule("tcp", "10.200.0.0/16", "443")),
+				fakes.SecurityGroup("app-admin", fakes.Rule("tcp", "10.200.0.0/16", "444")),
+			}
+			result := virgil.Result{
+				FirewallRules: utility.GetFirewallRules([]string{"10.0.16.1"}, secGroups),
+				Metadata:      render.Metadata{SecurityGroups: secGroups},
+			}
+			Expect(result.FirewallRules.FirewallRules[0].Port).To(Equal("443-444"))
+			config := lint.DefaultConfig()
+			config.Constraints = parsed[:1]
+			report, err := lint.Lint(result, config)
+			Expect(err).ToNot(HaveOccurred())
+			var violations []lint.Finding
+			for _, finding := range report.Findings {
+				if finding.Check == "database-https-only" {
+					violations = append(violations, finding)
+				}
+			}
+			Expect(violations).To(HaveLen(1))
+			Expect(violations[0].Port).To(Equal("443-444"))
+			Expect(violations[0].SecurityGroups).To(Equal([]string{"app-admin"}))
+		})
+
+		It("returns an error when constraints share a name", func() {
+			config := lint.DefaultConfig()
+			config.Constraints = []lint.Constraint{{Name: "db"}, {Name: "db"}}
+			_, err := lint.Lint(virgil.Result{}, config)
+			Expect(err).To(MatchError("Constraint db is defined more than once"))
+		})
+	})
+})
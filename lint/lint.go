@@ -0,0 +1,270 @@
+package lint
+
+import (
+	"fmt"
+	"github.com/FidelityInternational/virgil"
+	"github.com/FidelityInternational/virgil/render"
+	"github.com/FidelityInternational/virgil/utility"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Target - a single destination of a firewall rule, the unit every check looks at
+type Target struct {
+	Protocol    string
+	Port        string
+	Destination string
+	// Address - the parsed destination, zero when the destination could not be parsed
+	Address render.Address
+	// Parsed - true when Address holds the parsed destination
+	Parsed bool
+}
+
+// Check - a lint check with its default severity. Test returns a message when the target fails the check
+type Check struct {
+	Name        string
+	Description string
+	Severity    Severity
+	Test        func(target Target, config Config, internal []render.Address) string
+}
+
+// Checks - the built in lint checks
+var Checks = []Check{
+	{
+		Name:        "any-destination",
+		Description: "Rules allowing every destination address",
+		Severity:    Critical,
+		Test: func(target Target, config Config, internal []render.Address) string {
+			if target.Parsed && isEverything(target.Address) {
+				return fmt.Sprintf("%s allows every destination", target.Destination)
+			}
+			return ""
+		},
+	},
+	{
+		Name:        "all-protocol",
+		Description: "Rules allowing every protocol and port",
+		Severity:    High,
+		Test: func(target Target, config Config, internal []render.Address) string {
+			if strings.EqualFold(target.Protocol, "all") {
+				return "protocol all allows every protocol and port"
+			}
+			return ""
+		},
+	},
+	{
+		Name:        "risky-port",
+		Description: "Rules allowing high risk ports such as SSH, SMB and RDP",
+		Severity:    High,
+		Test: func(target Target, config Config, internal []render.Address) string {
+			start, end, ok := portRange(target)
+			if !ok {
+				return ""
+			}
+			var risky []string
+			for _, port := range config.RiskyPorts {
+				if start <= port && port <= end {
+					risky = append(risky, strconv.Itoa(port))
+				}
+			}
+			if len(risky) == 0 {
+				return ""
+			}
+			return fmt.Sprintf("allows high risk port %s", strings.Join(risky, ", "))
+		},
+	},
+	{
+		Name:        "public-destination",
+		Description: "Rules allowing destinations on the public internet",
+		Severity:    Medium,
+		Test: func(target Target, config Config, internal []render.Address) string {
+			if target.Parsed && !isPrivate(target.Address) {
+				return fmt.Sprintf("%s includes public internet addresses", target.Destination)
+			}
+			return ""
+		},
+	},
+	{
+		Name:        "wide-port-range",
+		Description: "Rules allowing more ports than max_port_range",
+		Severity:    Medium,
+		Test: func(target Target, config Config, internal []render.Address) string {
+			if strings.EqualFold(target.Protocol, "all") {
+				return ""
+			}
+			start, end, ok := portRange(target)
+			if ok && end-start+1 > config.MaxPortRange {
+				return fmt.Sprintf("port range %s allows %d ports, more than %d", target.Port, end-start+1, config.MaxPortRange)
+			}
+			return ""
+		},
+	},
+	{
+		Name:        "internal-network",
+		Description: "Rules allowing platform internal networks or the cells themselves",
+		Severity:    High,
+		Test: func(target Target, config Config, internal []render.Address) string {
+			if !target.Parsed {
+				return ""
+			}
+			for _, network := range internal {
+				if target.Address.Overlaps(network) {
+					return fmt.Sprintf("%s overlaps platform internal network %s", target.Destination, network)
+				}
+			}
+			return ""
+		},
+	},
+}
+
+// Finding - a firewall rule destination that failed a check, with the security groups that allow it
+type Finding struct {
+	Check          string   `json:"check"`
+	Severity       Severity `json:"severity"`
+	Message        string   `json:"message"`
+	Protocol       string   `json:"protocol"`
+	Port           string   `json:"port"`
+	Destination    string   `json:"destination"`
+	SecurityGroups []string `json:"security_groups"`
+}
+
+// Report - the findings of a lint run, most severe first
+type Report struct {
+	Findings  []Finding `json:"findings"`
+	Threshold Severity  `json:"threshold"`
+}
+
+// Lint - runs the enabled checks and the constraints over every destination of the generated firewall rules.
+// The cell IPs the rules are generated for are treated as internal networks along with config.InternalNetworks
+func Lint(result virgil.Result, config Config) (Report, error) {
+	if err := config.Validate(); err != nil {
+		return Report{}, err
+	}
+	var internal []render.Address
+	for _, network := range append(append([]string{}, config.InternalNetworks...), result.Sources...) {
+		if address, err := render.ParseAddress(network); err == nil {
+			internal = append(internal, address)
+		}
+	}
+	report := Report{Threshold: config.Threshold}
+	for _, rule := range result.FirewallRules.FirewallRules {
+		for _, destination := range rule.Destination {
+			target := Target{Protocol: rule.Protocol, Port: rule.Port, Destination: destination}
+			if address, err := render.ParseAddress(destination); err == nil {
+				target.Address, target.Parsed = address, true
+			}
+			secGroups := utility.GetProvenance(rule.Protocol, destination, rule.Port, result.Metadata.SecurityGroups)
+			finding := func(check string, severity Severity, message string, secGroups []string) {
+				report.Findings = append(report.Findings, Finding{
+					Check:          check,
+					Severity:       severity,
+					Message:        message,
+					Protocol:       rule.Protocol,
+					Port:           rule.Port,
+					Destination:    destination,
+					SecurityGroups: secGroups,
+				})
+			}
+			for _, check := range Checks {
+				severity, enabled := config.severity(check)
+				if !enabled {
+					continue
+				}
+				if message := check.Test(target, config, internal); message != "" {
+					finding(check.Name, severity, message, secGroups)
+				}
+			}
+			for _, constraint := range config.Constraints {
+				offenders, violated := constraint.groupViolations(target, secGroups, result.Metadata.SecurityGroups)
+				if !violated {
+					continue
+				}
+				severity := High
+				if constraint.Severity != nil {
+					severity = *constraint.Severity
+				}
+				finding(constraint.Name, severity, constraint.message(), offenders)
+			}
+		}
+	}
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		return report.Findings[i].Severity > report.Findings[j].Severity
+	})
+	return report, nil
+}
+
+// Failed - returns true when any finding is at or above the threshold
+func (r Report) Failed() bool {
+	return len(r.AtOrAbove(r.Threshold)) > 0
+}
+
+// AtOrAbove - returns the findings of the given severity or above
+func (r Report) AtOrAbove(severity Severity) []Finding {
+	var findings []Finding
+	for _, finding := range r.Findings {
+		if finding.Severity >= severity {
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+// WriteTo - writes the findings as text, one per line
+func (r Report) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	if len(r.Findings) == 0 {
+		fmt.Fprintln(&b, "Virgil\t- No lint findings")
+	}
+	for _, finding := range r.Findings {
+		rule := finding.Protocol
+		if finding.Port != "" {
+			rule = fmt.Sprintf("%s %s", rule, finding.Port)
+		}
+		fmt.Fprintf(&b, "%-8s %s: %s to %s (%s) - %s\n", strings.ToUpper(finding.Severity.String()), finding.Check, rule, finding.Destination, strings.Join(finding.SecurityGroups, ", "), finding.Message)
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// portRange - returns the ports a target allows, every port for the all protocol
+func portRange(target Target) (int, int, bool) {
+	if target.Port == "" {
+		return 0, 65535, strings.EqualFold(target.Protocol, "all")
+	}
+	start, end, err := render.SplitPortRange(target.Port)
+	return start, end, err == nil
+}
+
+// privateNetworks - address space that is not routed on the public internet
+var privateNetworks = mustParseAddresses(
+	"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "100.64.0.0/10", "127.0.0.0/8", "169.254.0.0/16",
+	"fc00::/7", "fe80::/10", "::1/128",
+)
+
+func isPrivate(address render.Address) bool {
+	for _, network := range privateNetworks {
+		if network.Contains(address) {
+			return true
+		}
+	}
+	return false
+}
+
+// isEverything - returns true for 0.0.0.0/0, ::/0 and ranges covering the whole address space
+func isEverything(address render.Address) bool {
+	return address.Start.IsUnspecified() && !address.End.Next().IsValid()
+}
+
+func mustParseAddresses(addresses ...string) []render.Address {
+	var parsed []render.Address
+	for _, address := range addresses {
+		parsedAddress, err := render.ParseAddress(address)
+		if err != nil {
+			panic(err)
+		}
+		parsed = append(parsed, parsedAddress)
+	}
+	return parsed
+}
@@ -0,0 +1,111 @@
+package lint_test
+
+import (
+	"bytes"
+	"github.com/FidelityInternational/virgil"
+	"github.com/FidelityInternational/virgil/fakes"
+	"github.com/FidelityInternational/virgil/lint"
+	"github.com/FidelityInternational/virgil/render"
+	"github.com/FidelityInternational/virgil/utility"
+	"github.com/cloudfoundry/go-cfclient/v3/resource"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Lint", func() {
+	var result virgil.Result
+
+	findings := func(report lint.Report, check string) []lint.Finding {
+		var matched []lint.Finding
+		for _, finding := range report.Findings {
+			if finding.Check == check {
+				matched = append(matched, finding)
+			}
+		}
+		return matched
+	}
+
+	BeforeEach(func() {
+		secGroups := []resource.SecurityGroup{
+			fakes.SecurityGroup("open-all", fakes.Rule("all", "0.0.0.0/0", "")),
+			fakes.SecurityGroup("ssh", fakes.Rule("tcp", "10.1.0.0/16", "22")),
+			fakes.SecurityGroup("web", fakes.Rule("tcp", "52.1.1.1", "443")),
+			fakes.SecurityGroup("ephemeral", fakes.Rule("udp", "10.2.0.0/16", "8000-9500")),
+			fakes.SecurityGroup("cells", fakes.Rule("tcp", "10.0.16.0/24", "8080")),
+		}
+		sources := []string{"10.0.16.1"}
+		result = virgil.Result{
+			Sources:       sources,
+			FirewallRules: utility.GetFirewallRules(sources, secGroups),
+			Metadata:      render.Metadata{SecurityGroups: secGroups},
+		}
+	})
+
+	Describe("#Lint", func() {
+		It("runs every check with its default severity", func() {
+			report, err := lint.Lint(result, lint.DefaultConfig())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(findings(report, "any-destination")).To(Equal([]lint.Finding{{
+				Check:          "any-destination",
+				Severity:       lint.Critical,
+				Message:        "0.0.0.0/0 allows every destination",
+				Protocol:       "all",
+				Destination:    "0.0.0.0/0",
+				SecurityGroups: []string{"open-all"},
+			}}))
+			Expect(findings(report, "all-protocol")).To(HaveLen(1))
+			Expect(findings(report, "risky-port")).To(HaveLen(2))
+			Expect(findings(report, "risky-port")[1].Message).To(Equal("allows high risk port 22"))
+			Expect(findings(report, "risky-port")[1].SecurityGroups).To(Equal([]string{"ssh"}))
+			Expect(findings(report, "public-destination")).To(HaveLen(2))
+			Expect(findings(report, "wide-port-range")).To(HaveLen(1))
+			Expect(findings(report, "wide-port-range")[0].Message).To(Equal("port range 8000-9500 allows 1501 ports, more than 1000"))
+			Expect(findings(report, "internal-network")).To(HaveLen(2))
+			Expect(report.Findings[0].Severity).To(Equal(lint.Critical))
+			Expect(report.Failed()).To(BeTrue())
+		})
+
+		It("flags overlap with the configured internal networks", func() {
+			config := lint.DefaultConfig()
+			config.InternalNetworks = []string{"10.1.0.0/24"}
+			report, err := lint.Lint(result, config)
+			Expect(err).ToNot(HaveOccurred())
+			var destinations []string
+			for _, finding := range findings(report, "internal-network") {
+				destinations = append(destinations, finding.Destination)
+			}
+			Expect(destinations).To(ContainElement("10.1.0.0/16"))
+		})
+
+		It("applies severity overrides, disabled checks and the threshold", func() {
+			config, err := lint.ParseConfig([]byte(`---
+threshold: critical
+checks:
+  any-destination:
+    disabled: true
+  risky-port:
+    severity: low
+`))
+			Expect(err).ToNot(HaveOccurred())
+			report, err := lint.Lint(result, config)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(findings(report, "any-destination")).To(BeEmpty())
+			Expect(findings(report, "risky-port")[0].Severity).To(Equal(lint.Low))
+			Expect(report.Failed()).To(BeFalse())
+			Expect(report.AtOrAbove(lint.High)).ToNot(BeEmpty())
+		})
+	})
+
+	Describe("#WriteTo", func() {
+		It("writes a finding per line", func() {
+			report := lint.Report{Findings: []lint.Finding{{
+				Check: "risky-port", Severity: lint.High, Message: "allows high risk port 22",
+				Protocol: "tcp", Port: "22", Destination: "10.1.0.0/16", SecurityGroups: []string{"ssh"},
+			}}}
+			var output bytes.Buffer
+			_, err := report.WriteTo(&output)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(output.String()).To(Equal("HIGH     risky-port: tcp 22 to 10.1.0.0/16 (ssh) - allows high risk port 22\n"))
+		})
+	})
+})
@@ -0,0 +1,275 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/FidelityInternational/virgil"
+	"github.com/FidelityInternational/virgil/utility"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Recorder - a virgil.Observer keeping metrics about generation runs, written in the Prometheus
+// text exposition format by ServeHTTP for scraping or WriteFile for the node_exporter textfile collector
+type Recorder struct {
+	mutex           sync.Mutex
+	fetchSeconds    map[fetchKey]float64
+	fetchSecondsSum map[fetchKey]float64
+	fetchCount      map[fetchKey]int
+	fetchErrors     map[fetchKey]int
+	runs            int
+	runErrors       int
+	runSeconds      float64
+	policies        map[string]*policyStats
+}
+
+// fetchKey - the foundation and source of a CF or BOSH request, so concurrent foundations keep their own fetch metrics
+type fetchKey struct {
+	foundation, source string
+}
+
+// policyStats - the size of the most recent policy generated for a foundation
+type policyStats struct {
+	totalSecGroups  int
+	usedSecGroups   int
+	skippedRules    int
+	rulesByProtocol map[string]int
+	sources         int
+	destinations    int
+	lastSuccess     time.Time
+}
+
+// NewRecorder - returns an empty Recorder
+func NewRecorder() *Recorder {
+	return &Recorder{
+		fetchSeconds:    make(map[fetchKey]float64),
+		fetchSecondsSum: make(map[fetchKey]float64),
+		fetchCount:      make(map[fetchKey]int),
+		fetchErrors:     make(map[fetchKey]int),
+		policies:        make(map[string]*policyStats),
+	}
+}
+
+// Fetched - records the duration and outcome of a CF or BOSH request made for a foundation. The error counts of
+// the requests every run makes start at zero so they are exposed before the first failure
+func (r *Recorder) Fetched(foundation, source string, duration time.Duration, err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, always := range []string{virgil.FetchSecurityGroups, virgil.FetchDeployments, virgil.FetchVMs} {
+		if _, ok := r.fetchErrors[fetchKey{foundation, always}]; !ok {
+			r.fetchErrors[fetchKey{foundation, always}] = 0
+		}
+	}
+	key := fetchKey{foundation, source}
+	r.fetchSeconds[key] = duration.Seconds()
+	r.fetchSecondsSum[key] += duration.Seconds()
+	r.fetchCount[key]++
+	if err != nil {
+		r.fetchErrors[key]++
+	}
+}
+
+// Generated - records the size of a successfully generated policy, keeping the most recent policy of each
+// foundation so runs for several foundations do not overwrite each other
+func (r *Recorder) Generated(result virgil.Result, duration time.Duration) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.runs++
+	r.runSeconds = duration.Seconds()
+	stats := &policyStats{
+		lastSuccess:     result.Metadata.GeneratedAt,
+		totalSecGroups:  result.Metadata.TotalSecurityGroups,
+		usedSecGroups:   len(result.Metadata.SecurityGroups),
+		skippedRules:    len(result.Metadata.SkippedRules),
+		sources:         len(result.Sources),
+		rulesByProtocol: map[string]int{"tcp": 0, "udp": 0, "all": 0},
+	}
+	if stats.lastSuccess.IsZero() {
+		stats.lastSuccess = time.Now()
+	}
+	destinations := make(map[string]bool)
+	for _, rule := range result.FirewallRules.FirewallRules {
+		stats.rulesByProtocol[strings.ToLower(rule.Protocol)]++
+		for _, destination := range rule.Destination {
+			destinations[destination] = true
+		}
+	}
+	stats.destinations = len(destinations)
+	r.policies[result.Metadata.Foundation] = stats
+}
+
+// Failed - records a failed run
+func (r *Recorder) Failed(err error, duration time.Duration) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.runs++
+	r.runErrors++
+	r.runSeconds = duration.Seconds()
+}
+
+// WriteTo - writes the metrics in the Prometheus text exposition format
+func (r *Recorder) WriteTo(w io.Writer) (int64, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	var b bytes.Buffer
+	metric := func(name, kind, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+	sample := func(name, labels string, value float64) {
+		if labels != "" {
+			labels = "{" + labels + "}"
+		}
+		fmt.Fprintf(&b, "%s%s %s\n", name, labels, formatValue(value))
+	}
+
+	metric("virgil_fetch_last_duration_seconds", "gauge", "Duration of the most recent CF or BOSH request by source.")
+	for _, key := range sortedFetchKeys(r.fetchSeconds) {
+		sample("virgil_fetch_last_duration_seconds", labels(key.foundation, "source", key.source), r.fetchSeconds[key])
+	}
+	metric("virgil_fetch_duration_seconds", "summary", "Duration of CF or BOSH requests by source.")
+	for _, key := range sortedFetchKeys(r.fetchSecondsSum) {
+		sample("virgil_fetch_duration_seconds_sum", labels(key.foundation, "source", key.source), r.fetchSecondsSum[key])
+		sample("virgil_fetch_duration_seconds_count", labels(key.foundation, "source", key.source), float64(r.fetchCount[key]))
+	}
+	metric("virgil_fetch_errors_total", "counter", "CF or BOSH requests that failed by source.")
+	for _, key := range sortedFetchKeys(r.fetchErrors) {
+		sample("virgil_fetch_errors_total", labels(key.foundation, "source", key.source), float64(r.fetchErrors[key]))
+	}
+	metric("virgil_generations_total", "counter", "Policy generation runs.")
+	sample("virgil_generations_total", "", float64(r.runs))
+	metric("virgil_generation_errors_total", "counter", "Policy generation runs that failed.")
+	sample("virgil_generation_errors_total", "", float64(r.runErrors))
+	metric("virgil_generation_duration_seconds", "gauge", "Duration of the most recent policy generation run.")
+	sample("virgil_generation_duration_seconds", "", r.runSeconds)
+	if len(r.policies) > 0 {
+		foundations := sortedKeys(r.policies)
+		metric("virgil_last_success_timestamp_seconds", "gauge", "Unix time of the most recent successful policy generation.")
+		for _, foundation := range foundations {
+			sample("virgil_last_success_timestamp_seconds", labels(foundation), float64(r.policies[foundation].lastSuccess.UnixNano())/1e9)
+		}
+		metric("virgil_security_groups", "gauge", "Security groups in the most recent policy, total, used and unused.")
+		for _, foundation := range foundations {
+			stats := r.policies[foundation]
+			sample("virgil_security_groups", labels(foundation, "state", "total"), float64(stats.totalSecGroups))
+			sample("virgil_security_groups", labels(foundation, "state", "used"), float64(stats.usedSecGroups))
+			sample("virgil_security_groups", labels(foundation, "state", "unused"), float64(stats.totalSecGroups-stats.usedSecGroups))
+		}
+		metric("virgil_skipped_rules", "gauge", "Security group rules skipped in the most recent policy, such as icmp rules.")
+		for _, foundation := range foundations {
+			sample("virgil_skipped_rules", labels(foundation), float64(r.policies[foundation].skippedRules))
+		}
+		metric("virgil_firewall_rules", "gauge", "Firewall rules in the most recent policy by protocol.")
+		for _, foundation := range foundations {
+			stats := r.policies[foundation]
+			for _, protocol := range sortedKeys(stats.rulesByProtocol) {
+				sample("virgil_firewall_rules", labels(foundation, "protocol", protocol), float64(stats.rulesByProtocol[protocol]))
+			}
+		}
+		metric("virgil_sources", "gauge", "Source IPs in the most recent policy.")
+		for _, foundation := range foundations {
+			sample("virgil_sources", labels(foundation), float64(r.policies[foundation].sources))
+		}
+		metric("virgil_destinations", "gauge", "Distinct destinations in the most recent policy.")
+		for _, foundation := range foundations {
+			sample("virgil_destinations", labels(foundation), float64(r.policies[foundation].destinations))
+		}
+	}
+	return b.WriteTo(w)
+}
+
+// ServeHTTP - serves the metrics for Prometheus to scrape
+func (r *Recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WriteTo(w)
+}
+
+// WriteFile - writes the metrics to path for the node_exporter textfile collector, replacing the
+// file atomically so the collector never reads a partial file
+func (r *Recorder) WriteFile(path string) error {
+	var b bytes.Buffer
+	if _, err := r.WriteTo(&b); err != nil {
+		return err
+	}
+	return utility.WriteFileAtomic(path, b.Bytes())
+}
+
+// textfile - a virgil.Observer recording in a Recorder and writing its metrics to a file after every run
+type textfile struct {
+	*Recorder
+	path    string
+	onError func(error)
+}
+
+// Textfile - returns an Observer recording in r that writes the metrics to path after every run,
+// for one-shot and watch runs collected by the node_exporter textfile collector. Write errors are
+// passed to onError when it is not nil
+func (r *Recorder) Textfile(path string, onError func(error)) virgil.Observer {
+	return textfile{Recorder: r, path: path, onError: onError}
+}
+
+// Generated - records the run and writes the metrics file
+func (t textfile) Generated(result virgil.Result, duration time.Duration) {
+	t.Recorder.Generated(result, duration)
+	t.write()
+}
+
+// Failed - records the run and writes the metrics file
+func (t textfile) Failed(err error, duration time.Duration) {
+	t.Recorder.Failed(err, duration)
+	t.write()
+}
+
+func (t textfile) write() {
+	if err := t.WriteFile(t.path); err != nil && t.onError != nil {
+		t.onError(err)
+	}
+}
+
+// labels - returns the foundation label, when the policy has a foundation, followed by the given name and value pairs
+func labels(foundation string, pairs ...string) string {
+	var parts []string
+	if foundation != "" {
+		parts = append(parts, label("foundation", foundation))
+	}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		parts = append(parts, label(pairs[i], pairs[i+1]))
+	}
+	return strings.Join(parts, ",")
+}
+
+func label(name, value string) string {
+	value = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(value)
+	return fmt.Sprintf(`%s="%s"`, name, value)
+}
+
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func sortedFetchKeys[V any](values map[fetchKey]V) []fetchKey {
+	var keys []fetchKey
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].foundation != keys[j].foundation {
+			return keys[i].foundation < keys[j].foundation
+		}
+		return keys[i].source < keys[j].source
+	})
+	return keys
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	var keys []string
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,138 @@
+package metrics_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/FidelityInternational/virgil"
+	"github.com/FidelityInternational/virgil/fakes"
+	"github.com/FidelityInternational/virgil/metrics"
+	"github.com/cloudfoundry-community/gogobosh"
+	"github.com/cloudfoundry/go-cfclient/v3/resource"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var _ = Describe("Recorder", func() {
+	var (
+		secGroups *fakes.SecurityGroupSource
+		cells     *fakes.CellSource
+		recorder  *metrics.Recorder
+		generator *virgil.Generator
+	)
+
+	exposition := func() string {
+		var b bytes.Buffer
+		_, err := recorder.WriteTo(&b)
+		Expect(err).ToNot(HaveOccurred())
+		return b.String()
+	}
+
+	BeforeEach(func() {
+		unused := fakes.SecurityGroup("unused")
+		unused.GloballyEnabled.Running = new(bool)
+		secGroups = &fakes.SecurityGroupSource{SecGroups: []resource.SecurityGroup{
+			fakes.SecurityGroup("web", fakes.Rule("tcp", "10.1.0.0/16", "443"), fakes.Rule("tcp", "10.1.0.0/16", "8443")),
+			fakes.SecurityGroup("dns", fakes.Rule("udp", "10.2.0.1", "53"), fakes.Rule("icmp", "10.2.0.1", "")),
+			unused,
+		}}
+		cells = fakes.NewCellSource("cf-123",
+			gogobosh.VM{JobName: "diego_cell", IPs: []string{"10.0.16.1"}},
+			gogobosh.VM{JobName: "diego_cell", IPs: []string{"10.0.16.2"}},
+		)
+		recorder = metrics.NewRecorder()
+		generator = virgil.NewGenerator(secGroups, cells, virgil.WithObserver(recorder))
+	})
+
+	It("records the size of the generated policy", func() {
+		_, err := generator.Generate(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		output := exposition()
+		Expect(output).To(ContainSubstring("# TYPE virgil_security_groups gauge\n"))
+		Expect(output).To(ContainSubstring(`virgil_security_groups{state="total"} 3` + "\n"))
+		Expect(output).To(ContainSubstring(`virgil_security_groups{state="used"} 2` + "\n"))
+		Expect(output).To(ContainSubstring(`virgil_security_groups{state="unused"} 1` + "\n"))
+		Expect(output).To(ContainSubstring("virgil_skipped_rules 1\n"))
+		Expect(output).To(ContainSubstring(`virgil_firewall_rules{protocol="all"} 0` + "\n"))
+		Expect(output).To(ContainSubstring(`virgil_firewall_rules{protocol="tcp"} 2` + "\n"))
+		Expect(output).To(ContainSubstring(`virgil_firewall_rules{protocol="udp"} 1` + "\n"))
+		Expect(output).To(ContainSubstring("virgil_sources 2\n"))
+		Expect(output).To(ContainSubstring("virgil_destinations 2\n"))
+		Expect(output).To(ContainSubstring("virgil_generations_total 1\n"))
+		Expect(output).To(ContainSubstring("virgil_generation_errors_total 0\n"))
+		Expect(output).To(ContainSubstring("# TYPE virgil_fetch_duration_seconds summary\n"))
+		Expect(output).To(ContainSubstring(`virgil_fetch_duration_seconds_count{source="cf_security_groups"} 1` + "\n"))
+		Expect(output).To(ContainSubstring(`virgil_fetch_duration_seconds_count{source="bosh_vms"} 1` + "\n"))
+		Expect(output).To(MatchRegexp(`virgil_fetch_duration_seconds_sum\{source="bosh_deployments"\} [0-9.e-]+\n`))
+		Expect(output).To(MatchRegexp(`virgil_fetch_last_duration_seconds\{source="bosh_deployments"\} [0-9.e-]+\n`))
+		Expect(output).ToNot(ContainSubstring("virgil_fetch_duration_seconds_total"))
+		Expect(output).To(MatchRegexp(`virgil_last_success_timestamp_seconds [0-9]{10}`))
+	})
+
+	It("records the policy of each foundation with a foundation label", func() {
+		for _, foundation := range []string{"dc1", "dc2"} {
+			_, err := virgil.NewGenerator(secGroups, cells, virgil.WithObserver(recorder), virgil.WithFoundation(foundation)).Generate(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			cells.SetVMs("cf-123", []gogobosh.VM{{JobName: "diego_cell", IPs: []string{"10.0.16.1"}}})
+		}
+		output := exposition()
+		Expect(output).To(ContainSubstring(`virgil_sources{foundation="dc1"} 2` + "\n"))
+		Expect(output).To(ContainSubstring(`virgil_sources{foundation="dc2"} 1` + "\n"))
+		Expect(output).To(ContainSubstring(`virgil_firewall_rules{foundation="dc1",protocol="tcp"} 2` + "\n"))
+		Expect(output).To(ContainSubstring(`virgil_firewall_rules{foundation="dc2",protocol="tcp"} 2` + "\n"))
+		Expect(output).To(MatchRegexp(`virgil_last_success_timestamp_seconds\{foundation="dc2"\} [0-9]{10}`))
+		Expect(output).To(ContainSubstring(`virgil_fetch_duration_seconds_count{foundation="dc1",source="cf_security_groups"} 1` + "\n"))
+		Expect(output).To(ContainSubstring(`virgil_fetch_duration_seconds_count{foundation="dc2",source="cf_security_groups"} 1` + "\n"))
+		Expect(output).To(MatchRegexp(`virgil_fetch_last_duration_seconds\{foundation="dc1",source="bosh_vms"\} [0-9.e-]+\n`))
+		Expect(output).To(MatchRegexp(`virgil_fetch_last_duration_seconds\{foundation="dc2",source="bosh_vms"\} [0-9.e-]+\n`))
+		Expect(output).To(ContainSubstring("virgil_generations_total 2\n"))
+	})
+
+	It("records failed fetches and runs", func() {
+		cells.Err = errors.New("director unavailable")
+		_, err := generator.Generate(context.Background())
+		Expect(err).To(HaveOccurred())
+		output := exposition()
+		Expect(output).To(ContainSubstring(`virgil_fetch_errors_total{source="bosh_deployments"} 1` + "\n"))
+		Expect(output).To(ContainSubstring(`virgil_fetch_errors_total{source="cf_security_groups"} 0` + "\n"))
+		Expect(output).To(ContainSubstring("virgil_generation_errors_total 1\n"))
+		Expect(output).ToNot(ContainSubstring("virgil_last_success_timestamp_seconds"))
+	})
+
+	It("serves the metrics for Prometheus", func() {
+		recorder.Generated(virgil.Result{}, time.Second)
+		response := httptest.NewRecorder()
+		recorder.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		Expect(response.Header().Get("Content-Type")).To(Equal("text/plain; version=0.0.4; charset=utf-8"))
+		Expect(response.Body.String()).To(ContainSubstring("virgil_generation_duration_seconds 1\n"))
+	})
+
+	Describe("#Textfile", func() {
+		It("writes the metrics after every run", func() {
+			tempDir, err := os.MkdirTemp("", "virgil-metrics")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(tempDir)
+			path := filepath.Join(tempDir, "virgil.prom")
+			generator = virgil.NewGenerator(secGroups, cells, virgil.WithObserver(recorder.Textfile(path, nil)))
+			_, err = generator.Generate(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			data, err := os.ReadFile(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(ContainSubstring("virgil_generations_total 1\n"))
+		})
+
+		It("passes write errors to onError", func() {
+			var writeErr error
+			observer := recorder.Textfile(filepath.Join(os.TempDir(), "virgil-missing", "virgil.prom"), func(err error) {
+				writeErr = err
+			})
+			observer.Failed(errors.New("CF unavailable"), time.Second)
+			Expect(writeErr).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,166 @@
+package query
+
+import (
+	"fmt"
+	"github.com/FidelityInternational/virgil/render"
+	"github.com/FidelityInternational/virgil/utility"
+	"github.com/FidelityInternational/virgil/verify"
+	"github.com/cloudfoundry/go-cfclient/v3/resource"
+	"gopkg.in/yaml.v2"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Protocols - the protocols a query can ask about
+var Protocols = []string{"tcp", "udp", "icmp"}
+
+// Query - a single packet, from an app on a cell to a destination
+type Query struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Protocol    string `json:"protocol"`
+	Port        string `json:"port,omitempty"`
+}
+
+// String - describes the query as "protocol port source -> destination"
+func (q Query) String() string {
+	parts := []string{q.Protocol}
+	if q.Port != "" {
+		parts = append(parts, q.Port)
+	}
+	return fmt.Sprintf("%s %s -> %s", strings.Join(parts, " "), q.Source, q.Destination)
+}
+
+// Validate - returns an error unless the source and destination are IP addresses, the protocol is supported and
+// tcp and udp queries have a single port
+func (q Query) Validate() error {
+	for _, address := range []struct{ name, value string }{{"Source", q.Source}, {"Destination", q.Destination}} {
+		parsed, err := render.ParseAddress(address.value)
+		if err != nil || parsed.Start != parsed.End {
+			return fmt.Errorf("%s %s was invalid, it must be a single IP address", address.name, address.value)
+		}
+	}
+	if !containsString(Protocols, q.Protocol) {
+		return fmt.Errorf("Protocol %s is not supported, valid protocols are %s", q.Protocol, strings.Join(Protocols, ", "))
+	}
+	if q.Protocol == "icmp" {
+		if q.Port != "" {
+			return fmt.Errorf("Port cannot be set for icmp")
+		}
+		return nil
+	}
+	if q.Port == "" {
+		return fmt.Errorf("Port must be set for %s queries", q.Protocol)
+	}
+	if port, err := strconv.Atoi(q.Port); err != nil || port < 1 || port > 65535 {
+		return fmt.Errorf("Port %s was invalid, %s queries need a single port", q.Port, q.Protocol)
+	}
+	return nil
+}
+
+// Match - a firewall rule allowing the query, with the security groups it was generated from
+type Match struct {
+	Rule           utility.FirewallRule `json:"rule"`
+	SecurityGroups []string             `json:"security_groups"`
+}
+
+// Answer - whether the policy allows the query and the rules that allow it
+type Answer struct {
+	Query   Query   `json:"query"`
+	Allowed bool    `json:"allowed"`
+	Matches []Match `json:"matches"`
+}
+
+// Evaluate - answers whether the firewall rules allow the query. Security groups are only named when secGroups
+// is given, a saved policy does not record them
+func Evaluate(firewallRules utility.FirewallRules, secGroups []resource.SecurityGroup, query Query) Answer {
+	answer := Answer{Query: query, Matches: []Match{}}
+	packet := utility.FirewallTuple{Source: query.Source, Destination: query.Destination, Protocol: query.Protocol, Port: query.Port}
+	for _, rule := range firewallRules.FirewallRules {
+		if !allows(rule.Source, packet.Source) {
+			continue
+		}
+		var matched bool
+		secGroupNames := []string{}
+		for _, destination := range rule.Destination {
+			allow := utility.FirewallTuple{Destination: destination, Protocol: rule.Protocol, Port: rule.Port}
+			if verify.Covers(allow, packet) {
+				matched = true
+				secGroupNames = append(secGroupNames, utility.GetProvenance(rule.Protocol, destination, provenancePort(rule, query), secGroups)...)
+			}
+		}
+		if matched {
+			utility.RemoveDuplicates(&secGroupNames)
+			answer.Matches = append(answer.Matches, Match{Rule: rule, SecurityGroups: secGroupNames})
+		}
+	}
+	answer.Allowed = len(answer.Matches) > 0
+	return answer
+}
+
+// ParsePolicy - parses a policy previously written by virgil in the yaml or json format
+func ParsePolicy(data []byte) (utility.FirewallRules, error) {
+	var firewallRules utility.FirewallRules
+	if err := yaml.Unmarshal(data, &firewallRules); err != nil {
+		return utility.FirewallRules{}, err
+	}
+	if firewallRules.SchemaVersion == "" {
+		return utility.FirewallRules{}, fmt.Errorf("Policy has no schema_version and is not a virgil policy")
+	}
+	return firewallRules, nil
+}
+
+// WriteTo - writes the answer as text, followed by the matching rules and their security groups
+func (a Answer) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	if a.Allowed {
+		fmt.Fprintf(&b, "ALLOW\t%s\n", a.Query)
+	} else {
+		fmt.Fprintf(&b, "DENY\t%s\n", a.Query)
+	}
+	for _, match := range a.Matches {
+		rule := match.Rule.Protocol
+		if match.Rule.Port != "" {
+			rule = fmt.Sprintf("%s %s", rule, match.Rule.Port)
+		}
+		fmt.Fprintf(&b, "  %s to %s", rule, strings.Join(match.Rule.Destination, ","))
+		if len(match.SecurityGroups) > 0 {
+			fmt.Fprintf(&b, " (%s)", strings.Join(match.SecurityGroups, ", "))
+		}
+		fmt.Fprintln(&b)
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// provenancePort - returns the queried port when the rule has ports, so only the security groups that open that port
+// are named rather than every group merged into the rule's compressed port range
+func provenancePort(rule utility.FirewallRule, query Query) string {
+	if rule.Port == "" || query.Port == "" {
+		return rule.Port
+	}
+	return query.Port
+}
+
+// allows - returns true when any of the sources contains the address, a rule without sources allows every source
+func allows(sources []string, address string) bool {
+	if len(sources) == 0 {
+		return true
+	}
+	for _, source := range sources {
+		if verify.Covers(utility.FirewallTuple{Protocol: "all", Source: source}, utility.FirewallTuple{Protocol: "all", Source: address}) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(xs []string, x string) bool {
+	for _, s := range xs {
+		if s == x {
+			return true
+		}
+	}
+	return false
+}
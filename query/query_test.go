@@ -0,0 +1,119 @@
+package query_test
+
+import (
+	"bytes"
+	"github.com/FidelityInternational/virgil/fakes"
+	"github.com/FidelityInternational/virgil/query"
+	"github.com/FidelityInternational/virgil/utility"
+	"github.com/cloudfoundry/go-cfclient/v3/resource"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Query", func() {
+	var (
+		firewallRules utility.FirewallRules
+		secGroups     []resource.SecurityGroup
+	)
+
+	BeforeEach(func() {
+		secGroups = []resource.SecurityGroup{
+			fakes.SecurityGroup("postgres", fakes.Rule("tcp", "10.20.0.0/16", "5432")),
+			fakes.SecurityGroup("internal", fakes.Rule("all", "10.20.3.0/24", "")),
+			fakes.SecurityGroup("web", fakes.Rule("tcp", "10.30.0.1", "443")),
+		}
+		firewallRules = utility.GetFirewallRules([]string{"10.0.1.0/24"}, secGroups)
+	})
+
+	Describe("#Validate", func() {
+		It("accepts a tcp query with a port", func() {
+			Expect(query.Query{Source: "10.0.1.5", Destination: "10.20.3.4", Protocol: "tcp", Port: "5432"}.Validate()).To(Succeed())
+			Expect(query.Query{Source: "10.0.1.5", Destination: "10.20.3.4", Protocol: "icmp"}.Validate()).To(Succeed())
+		})
+
+		It("rejects networks, unknown protocols and missing ports", func() {
+			Expect(query.Query{Source: "10.0.1.0/24", Destination: "10.20.3.4", Protocol: "tcp", Port: "5432"}.Validate()).To(MatchError("Source 10.0.1.0/24 was invalid, it must be a single IP address"))
+			Expect(query.Query{Source: "10.0.1.5", Destination: "10.20.3.4", Protocol: "sctp", Port: "5432"}.Validate()).To(MatchError("Protocol sctp is not supported, valid protocols are tcp, udp, icmp"))
+			Expect(query.Query{Source: "10.0.1.5", Destination: "10.20.3.4", Protocol: "udp"}.Validate()).To(MatchError("Port must be set for udp queries"))
+			Expect(query.Query{Source: "10.0.1.5", Destination: "10.20.3.4", Protocol: "tcp", Port: "80-90"}.Validate()).To(MatchError("Port 80-90 was invalid, tcp queries need a single port"))
+			Expect(query.Query{Source: "10.0.1.5", Destination: "10.20.3.4", Protocol: "icmp", Port: "1"}.Validate()).To(MatchError("Port cannot be set for icmp"))
+		})
+
+		It("rejects ports outside 1-65535", func() {
+			Expect(query.Query{Source: "10.0.1.5", Destination: "10.20.3.4", Protocol: "tcp", Port: "0"}.Validate()).To(MatchError("Port 0 was invalid, tcp queries need a single port"))
+			Expect(query.Query{Source: "10.0.1.5", Destination: "10.20.3.4", Protocol: "udp", Port: "65536"}.Validate()).To(MatchError("Port 65536 was invalid, udp queries need a single port"))
+			Expect(query.Query{Source: "10.0.1.5", Destination: "10.20.3.4", Protocol: "tcp", Port: "1"}.Validate()).To(Succeed())
+			Expect(query.Query{Source: "10.0.1.5", Destination: "10.20.3.4", Protocol: "tcp", Port: "65535"}.Validate()).To(Succeed())
+		})
+	})
+
+	Describe("#Evaluate", func() {
+		It("allows a packet matching rules and names their security groups", func() {
+			answer := query.Evaluate(firewallRules, secGroups, query.Query{Source: "10.0.1.5", Destination: "10.20.3.4", Protocol: "tcp", Port: "5432"})
+			Expect(answer.Allowed).To(BeTrue())
+			Expect(answer.Matches).To(HaveLen(2))
+			Expect(answer.Matches[0].Rule.Protocol).To(Equal("all"))
+			Expect(answer.Matches[0].SecurityGroups).To(Equal([]string{"internal"}))
+			Expect(answer.Matches[1].SecurityGroups).To(Equal([]string{"postgres"}))
+		})
+
+		It("denies packets from other sources, to other destinations or on other ports", func() {
+			for _, q := range []query.Query{
+				{Source: "10.0.2.5", Destination: "10.20.3.4", Protocol: "tcp", Port: "5432"},
+				{Source: "10.0.1.5", Destination: "10.40.3.4", Protocol: "tcp", Port: "5432"},
+				{Source: "10.0.1.5", Destination: "10.30.0.1", Protocol: "tcp", Port: "80"},
+				{Source: "10.0.1.5", Destination: "10.30.0.1", Protocol: "udp", Port: "443"},
+			} {
+				answer := query.Evaluate(firewallRules, secGroups, q)
+				Expect(answer.Allowed).To(BeFalse(), q.String())
+				Expect(answer.Matches).To(BeEmpty())
+			}
+		})
+
+		It("only names the security groups that open the queried port of a compressed range", func() {
+			adjacent := []resource.SecurityGroup{
+				fakes.SecurityGroup("a", fakes.Rule("tcp", "10.40.0.1", "5000")),
+				fakes.SecurityGroup("b", fakes.Rule("tcp", "10.40.0.1", "5001")),
+			}
+			rules := utility.GetFirewallRules([]string{"10.0.1.0/24"}, adjacent)
+			Expect(rules.FirewallRules).To(HaveLen(1))
+			Expect(rules.FirewallRules[0].Port).To(Equal("5000-5001"))
+			answer := query.Evaluate(rules, adjacent, query.Query{Source: "10.0.1.5", Destination: "10.40.0.1", Protocol: "tcp", Port: "5001"})
+			Expect(answer.Allowed).To(BeTrue())
+			Expect(answer.Matches).To(HaveLen(1))
+			Expect(answer.Matches[0].SecurityGroups).To(Equal([]string{"b"}))
+		})
+
+		It("matches a saved policy without security groups", func() {
+			answer := query.Evaluate(firewallRules, nil, query.Query{Source: "10.0.1.5", Destination: "10.20.3.4", Protocol: "icmp"})
+			Expect(answer.Allowed).To(BeTrue())
+			Expect(answer.Matches[0].SecurityGroups).To(BeEmpty())
+		})
+	})
+
+	Describe("#ParsePolicy", func() {
+		It("parses a yaml or json policy written by virgil", func() {
+			policy, err := query.ParsePolicy([]byte(`{"schema_version": "1", "firewall_rules": [{"protocol": "tcp", "port": "443", "source": ["10.0.1.0/24"], "destination": ["10.30.0.1"]}]}`))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(policy.FirewallRules).To(HaveLen(1))
+		})
+
+		It("rejects documents without a schema version", func() {
+			_, err := query.ParsePolicy([]byte("firewall_rules: []\n"))
+			Expect(err).To(MatchError("Policy has no schema_version and is not a virgil policy"))
+		})
+	})
+
+	Describe("#WriteTo", func() {
+		It("writes the answer and the matching rules", func() {
+			var out bytes.Buffer
+			_, err := query.Evaluate(firewallRules, secGroups, query.Query{Source: "10.0.1.5", Destination: "10.30.0.1", Protocol: "tcp", Port: "443"}).WriteTo(&out)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out.String()).To(Equal("ALLOW\ttcp 443 10.0.1.5 -> 10.30.0.1\n  tcp 443 to 10.30.0.1 (web)\n"))
+			out.Reset()
+			_, err = query.Evaluate(firewallRules, secGroups, query.Query{Source: "10.0.1.5", Destination: "10.30.0.1", Protocol: "tcp", Port: "80"}).WriteTo(&out)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out.String()).To(Equal("DENY\ttcp 80 10.0.1.5 -> 10.30.0.1\n"))
+		})
+	})
+})
@@ -0,0 +1,197 @@
+package render
+
+import (
+	"fmt"
+	"github.com/FidelityInternational/virgil/utility"
+	"net"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// AddressKind - the shape of a source or destination address
+type AddressKind int
+
+const (
+	// Host - a single IP address
+	Host AddressKind = iota
+	// Network - a CIDR block
+	Network
+	// Range - a CF style start-end address range
+	Range
+)
+
+// Address - a parsed firewall rule source or destination
+type Address struct {
+	Kind   AddressKind
+	Prefix netip.Prefix
+	Start  netip.Addr
+	End    netip.Addr
+}
+
+// ParseAddress - parses an IP, CIDR or CF style "start-end" range into an Address
+func ParseAddress(address string) (Address, error) {
+	address = strings.TrimSpace(address)
+	if strings.Contains(address, "-") {
+		startFinish := strings.Split(address, "-")
+		if len(startFinish) != 2 {
+			return Address{}, fmt.Errorf("Address range %s was invalid", address)
+		}
+		start, err := netip.ParseAddr(strings.TrimSpace(startFinish[0]))
+		if err != nil {
+			return Address{}, fmt.Errorf("Address range %s was invalid", address)
+		}
+		end, err := netip.ParseAddr(strings.TrimSpace(startFinish[1]))
+		if err != nil || end.Less(start) || start.BitLen() != end.BitLen() {
+			return Address{}, fmt.Errorf("Address range %s was invalid", address)
+		}
+		return Address{Kind: Range, Start: start, End: end}, nil
+	}
+	if strings.Contains(address, "/") {
+		prefix, err := netip.ParsePrefix(address)
+		if err != nil {
+			return Address{}, fmt.Errorf("Address %s was invalid", address)
+		}
+		prefix = prefix.Masked()
+		if prefix.Bits() == prefix.Addr().BitLen() {
+			return Address{Kind: Host, Prefix: prefix, Start: prefix.Addr(), End: prefix.Addr()}, nil
+		}
+		return Address{Kind: Network, Prefix: prefix, Start: prefix.Addr(), End: lastAddr(prefix)}, nil
+	}
+	addr, err := netip.ParseAddr(address)
+	if err != nil {
+		return Address{}, fmt.Errorf("Address %s was invalid", address)
+	}
+	return Address{Kind: Host, Prefix: netip.PrefixFrom(addr, addr.BitLen()), Start: addr, End: addr}, nil
+}
+
+// String - returns the canonical form of the address, ranges as start-end and hosts without a prefix length
+func (a Address) String() string {
+	switch a.Kind {
+	case Range:
+		return fmt.Sprintf("%s-%s", a.Start, a.End)
+	case Host:
+		return a.Prefix.Addr().String()
+	}
+	return a.Prefix.String()
+}
+
+// CIDR - returns the address in CIDR notation, hosts are given an explicit /32 (or /128)
+func (a Address) CIDR() string {
+	return a.Prefix.String()
+}
+
+// CIDRs - returns the address as a list of CIDR blocks, ranges are split into the smallest covering set of blocks
+func (a Address) CIDRs() []string {
+	if a.Kind != Range {
+		return []string{a.CIDR()}
+	}
+	var cidrs []string
+	start := a.Start
+	for {
+		bits := start.BitLen()
+		for bits > 0 {
+			prefix := netip.PrefixFrom(start, bits-1).Masked()
+			if prefix.Addr() != start || a.End.Less(lastAddr(prefix)) {
+				break
+			}
+			bits--
+		}
+		prefix := netip.PrefixFrom(start, bits)
+		cidrs = append(cidrs, prefix.String())
+		last := lastAddr(prefix)
+		if !last.Less(a.End) || !last.Next().IsValid() {
+			return cidrs
+		}
+		start = last.Next()
+	}
+}
+
+// Contains - returns true when every address in other is within the address
+func (a Address) Contains(other Address) bool {
+	return a.Start.BitLen() == other.Start.BitLen() && !other.Start.Less(a.Start) && !a.End.Less(other.End)
+}
+
+// Overlaps - returns true when any address in other is within the address
+func (a Address) Overlaps(other Address) bool {
+	return a.Start.BitLen() == other.Start.BitLen() && !other.End.Less(a.Start) && !a.End.Less(other.Start)
+}
+
+// Mask - returns the dotted decimal network mask for a Host or Network address
+func (a Address) Mask() string {
+	if !a.Prefix.Addr().Is4() {
+		return strconv.Itoa(a.Prefix.Bits())
+	}
+	return net.IP(net.CIDRMask(a.Prefix.Bits(), 32)).String()
+}
+
+// CIDRToMask - converts a CIDR or bare IP into its network address and dotted decimal mask
+func CIDRToMask(cidr string) (string, string, error) {
+	address, err := ParseAddress(cidr)
+	if err != nil {
+		return "", "", err
+	}
+	if address.Kind == Range {
+		return "", "", fmt.Errorf("Address %s is a range and has no mask", cidr)
+	}
+	return address.Prefix.Addr().String(), address.Mask(), nil
+}
+
+// SplitPortRange - splits a firewall rule port such as 443 or 8080-8082 into its start and end ports
+func SplitPortRange(port string) (int, int, error) {
+	startFinish := strings.Split(port, "-")
+	start, err := strconv.Atoi(strings.TrimSpace(startFinish[0]))
+	if err != nil || len(startFinish) > 2 {
+		return 0, 0, fmt.Errorf("Port %s was invalid", port)
+	}
+	if len(startFinish) == 1 {
+		return start, start, nil
+	}
+	end, err := strconv.Atoi(strings.TrimSpace(startFinish[1]))
+	if err != nil || end < start {
+		return 0, 0, fmt.Errorf("Port %s was invalid", port)
+	}
+	return start, end, nil
+}
+
+// SortAddresses - sorts addresses numerically, anything that cannot be parsed is sorted last as a string
+func SortAddresses(addresses []string) {
+	sort.SliceStable(addresses, func(i, j int) bool {
+		addressI, errI := ParseAddress(addresses[i])
+		addressJ, errJ := ParseAddress(addresses[j])
+		if errI != nil || errJ != nil {
+			if errI == nil {
+				return true
+			}
+			if errJ == nil {
+				return false
+			}
+			return addresses[i] < addresses[j]
+		}
+		if addressI.Start != addressJ.Start {
+			return addressI.Start.Less(addressJ.Start)
+		}
+		return addressI.End.Less(addressJ.End)
+	})
+}
+
+// uniqueAddresses - returns the sorted, de-duplicated addresses used across the given lists
+func uniqueAddresses(lists ...[]string) []string {
+	var addresses []string
+	for _, list := range lists {
+		addresses = append(addresses, list...)
+	}
+	utility.RemoveDuplicates(&addresses)
+	SortAddresses(addresses)
+	return addresses
+}
+
+func lastAddr(prefix netip.Prefix) netip.Addr {
+	bytes := prefix.Addr().AsSlice()
+	for bit := prefix.Bits(); bit < len(bytes)*8; bit++ {
+		bytes[bit/8] |= 1 << (7 - uint(bit%8))
+	}
+	addr, _ := netip.AddrFromSlice(bytes)
+	return addr
+}
@@ -0,0 +1,124 @@
+package render_test
+
+import (
+	"github.com/FidelityInternational/virgil/render"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("#ParseAddress", func() {
+	Context("when the address is valid", func() {
+		It("parses hosts, networks and ranges", func() {
+			host, err := render.ParseAddress("10.0.0.1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(host.Kind).To(Equal(render.Host))
+			Expect(host.String()).To(Equal("10.0.0.1"))
+			Expect(host.CIDR()).To(Equal("10.0.0.1/32"))
+
+			network, err := render.ParseAddress("10.0.0.5/24")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(network.Kind).To(Equal(render.Network))
+			Expect(network.String()).To(Equal("10.0.0.0/24"))
+			Expect(network.End.String()).To(Equal("10.0.0.255"))
+
+			addressRange, err := render.ParseAddress("10.0.0.1-10.0.0.9")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(addressRange.Kind).To(Equal(render.Range))
+			Expect(addressRange.String()).To(Equal("10.0.0.1-10.0.0.9"))
+		})
+	})
+
+	Context("when the address is not valid", func() {
+		It("returns an error", func() {
+			_, err := render.ParseAddress("10.0.0.9-10.0.0.1")
+			Expect(err).To(MatchError("Address range 10.0.0.9-10.0.0.1 was invalid"))
+			_, err = render.ParseAddress("10.0.0.1/33")
+			Expect(err).To(MatchError("Address 10.0.0.1/33 was invalid"))
+			_, err = render.ParseAddress("not_an_ip")
+			Expect(err).To(MatchError("Address not_an_ip was invalid"))
+		})
+	})
+})
+
+var _ = Describe("Address#CIDRs", func() {
+	It("splits ranges into the smallest covering set of CIDR blocks", func() {
+		addressRange, err := render.ParseAddress("10.0.0.1-10.0.0.9")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(addressRange.CIDRs()).To(Equal([]string{"10.0.0.1/32", "10.0.0.2/31", "10.0.0.4/30", "10.0.0.8/31"}))
+		addressRange, err = render.ParseAddress("10.0.0.0-10.0.1.255")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(addressRange.CIDRs()).To(Equal([]string{"10.0.0.0/23"}))
+		addressRange, err = render.ParseAddress("0.0.0.0-255.255.255.255")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(addressRange.CIDRs()).To(Equal([]string{"0.0.0.0/0"}))
+	})
+
+	It("returns hosts and networks as a single CIDR", func() {
+		host, err := render.ParseAddress("10.0.0.1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(host.CIDRs()).To(Equal([]string{"10.0.0.1/32"}))
+	})
+})
+
+var _ = Describe("#Contains and #Overlaps", func() {
+	parse := func(address string) render.Address {
+		parsed, err := render.ParseAddress(address)
+		Expect(err).ToNot(HaveOccurred())
+		return parsed
+	}
+
+	It("compares networks, ranges and hosts", func() {
+		network := parse("10.1.0.0/16")
+		Expect(network.Contains(parse("10.1.2.0/24"))).To(BeTrue())
+		Expect(network.Contains(parse("10.1.255.1-10.2.0.1"))).To(BeFalse())
+		Expect(network.Overlaps(parse("10.1.255.1-10.2.0.1"))).To(BeTrue())
+		Expect(network.Overlaps(parse("10.2.0.1"))).To(BeFalse())
+		Expect(network.Overlaps(parse("::1"))).To(BeFalse())
+	})
+})
+
+var _ = Describe("#CIDRToMask", func() {
+	It("returns the network address and dotted decimal mask", func() {
+		network, mask, err := render.CIDRToMask("10.1.2.0/23")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(network).To(Equal("10.1.2.0"))
+		Expect(mask).To(Equal("255.255.254.0"))
+		network, mask, err = render.CIDRToMask("10.1.2.3")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(network).To(Equal("10.1.2.3"))
+		Expect(mask).To(Equal("255.255.255.255"))
+	})
+
+	It("returns an error for ranges", func() {
+		_, _, err := render.CIDRToMask("10.0.0.1-10.0.0.2")
+		Expect(err).To(MatchError("Address 10.0.0.1-10.0.0.2 is a range and has no mask"))
+	})
+})
+
+var _ = Describe("#SplitPortRange", func() {
+	It("splits single ports and ranges", func() {
+		start, end, err := render.SplitPortRange("443")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(start).To(Equal(443))
+		Expect(end).To(Equal(443))
+		start, end, err = render.SplitPortRange("8080-8082")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(start).To(Equal(8080))
+		Expect(end).To(Equal(8082))
+	})
+
+	It("returns an error for invalid ports", func() {
+		_, _, err := render.SplitPortRange("")
+		Expect(err).To(MatchError("Port  was invalid"))
+		_, _, err = render.SplitPortRange("9-1")
+		Expect(err).To(MatchError("Port 9-1 was invalid"))
+	})
+})
+
+var _ = Describe("#SortAddresses", func() {
+	It("sorts addresses numerically", func() {
+		addresses := []string{"10.0.0.10", "invalid", "10.0.0.0/8", "10.0.0.2", "9.9.9.9-9.9.9.10"}
+		render.SortAddresses(addresses)
+		Expect(addresses).To(Equal([]string{"9.9.9.9-9.9.9.10", "10.0.0.0/8", "10.0.0.2", "10.0.0.10", "invalid"}))
+	})
+})
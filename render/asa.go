@@ -0,0 +1,106 @@
+package render
+
+import (
+	"fmt"
+	"github.com/FidelityInternational/virgil/utility"
+	"io"
+	"strings"
+)
+
+// asaMaxNameLength - Cisco ASA object and object-group names are limited to 64 characters
+const asaMaxNameLength = 64
+
+// ASAOptions - settings for the Cisco ASA / FTD renderer, empty values fall back to sensible defaults
+type ASAOptions struct {
+	Prefix     string
+	AccessList string
+}
+
+// CiscoASA - writes the firewall rules as Cisco ASA (or FTD FlexConfig) object-group network/service
+// definitions followed by extended access-list entries referencing them
+func CiscoASA(w io.Writer, firewallRules utility.FirewallRules, options ASAOptions) error {
+	prefix := defaultString(options.Prefix, "virgil")
+	accessList := defaultString(options.AccessList, fmt.Sprintf("%s-egress", prefix))
+	groups := newGroupNamer(prefix, asaMaxNameLength)
+	var objects, networkGroups, serviceGroups, accessListEntries []string
+	seenObjects := make(map[string]bool)
+	seenServices := make(map[string]bool)
+
+	// networkGroup returns the object-group name for a set of addresses, defining it when first seen
+	networkGroup := func(addresses []string, kind string) (string, error) {
+		var members []string
+		for _, value := range uniqueAddresses(addresses) {
+			address, err := ParseAddress(value)
+			if err != nil {
+				return "", err
+			}
+			members = append(members, address.String())
+		}
+		utility.RemoveDuplicates(&members)
+		name, created := groups.name(kind, members)
+		if !created {
+			return name, nil
+		}
+		lines := []string{fmt.Sprintf("object-group network %s", name)}
+		for _, member := range members {
+			address, _ := ParseAddress(member)
+			switch {
+			case address.Kind == Range:
+				object := SanitiseName(fmt.Sprintf("%s-range-%s", prefix, address), asaMaxNameLength)
+				if !seenObjects[object] {
+					objects = append(objects, fmt.Sprintf("object network %s\n range %s %s", object, address.Start, address.End))
+					seenObjects[object] = true
+				}
+				lines = append(lines, fmt.Sprintf(" network-object object %s", object))
+			case !address.Prefix.Addr().Is4():
+				lines = append(lines, fmt.Sprintf(" network-object %s", address.CIDR()))
+			case address.Kind == Host:
+				lines = append(lines, fmt.Sprintf(" network-object host %s", address))
+			default:
+				lines = append(lines, fmt.Sprintf(" network-object %s %s", address.Prefix.Addr(), address.Mask()))
+			}
+		}
+		networkGroups = append(networkGroups, strings.Join(lines, "\n"))
+		return name, nil
+	}
+
+	for _, rule := range firewallRules.FirewallRules {
+		protocol := strings.ToLower(rule.Protocol)
+		sources, err := networkGroup(rule.Source, "sources")
+		if err != nil {
+			return err
+		}
+		destinations, err := networkGroup(rule.Destination, "dst")
+		if err != nil {
+			return err
+		}
+		if protocol == "all" {
+			accessListEntries = append(accessListEntries, fmt.Sprintf("access-list %s extended permit ip object-group %s object-group %s", accessList, sources, destinations))
+			continue
+		}
+		start, end, err := SplitPortRange(rule.Port)
+		if err != nil {
+			return err
+		}
+		service := SanitiseName(fmt.Sprintf("%s-%s-%s", prefix, protocol, rule.Port), asaMaxNameLength)
+		if !seenServices[service] {
+			portObject := fmt.Sprintf(" port-object eq %d", start)
+			if start != end {
+				portObject = fmt.Sprintf(" port-object range %d %d", start, end)
+			}
+			serviceGroups = append(serviceGroups, fmt.Sprintf("object-group service %s %s\n%s", service, protocol, portObject))
+			seenServices[service] = true
+		}
+		accessListEntries = append(accessListEntries, fmt.Sprintf("access-list %s extended permit %s object-group %s object-group %s object-group %s", accessList, protocol, sources, destinations, service))
+	}
+	utility.RemoveDuplicates(&accessListEntries)
+
+	var sections []string
+	for _, section := range [][]string{objects, networkGroups, serviceGroups, accessListEntries} {
+		if len(section) != 0 {
+			sections = append(sections, strings.Join(section, "\n"))
+		}
+	}
+	_, err := fmt.Fprintln(w, strings.Join(sections, "\n!\n"))
+	return err
+}
@@ -0,0 +1,47 @@
+package render_test
+
+import (
+	"bytes"
+	"github.com/FidelityInternational/virgil/render"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("#CiscoASA", func() {
+	var output string
+
+	BeforeEach(func() {
+		var buffer bytes.Buffer
+		Expect(render.CiscoASA(&buffer, testFirewallRules(), render.ASAOptions{})).To(Succeed())
+		output = buffer.String()
+	})
+
+	It("defines range objects", func() {
+		Expect(output).To(ContainSubstring("object network virgil-range-10.2.0.1-10.2.0.9\n range 10.2.0.1 10.2.0.9\n"))
+	})
+
+	It("defines network object-groups using mask notation", func() {
+		Expect(output).To(ContainSubstring("object-group network virgil-sources\n network-object host 10.0.16.1\n network-object host 10.0.16.2\n"))
+		Expect(output).To(MatchRegexp(`object-group network virgil-dst-[0-9a-f]{8}\n network-object 10.1.0.0 255.255.0.0\n network-object host 192.168.1.10\n`))
+		Expect(output).To(MatchRegexp(`object-group network virgil-dst-[0-9a-f]{8}\n network-object object virgil-range-10.2.0.1-10.2.0.9\n`))
+		Expect(output).To(MatchRegexp(`object-group network virgil-dst-[0-9a-f]{8}\n network-object 172.16.0.0 255.240.0.0\n`))
+	})
+
+	It("defines service object-groups with eq and range port objects", func() {
+		Expect(output).To(ContainSubstring("object-group service virgil-tcp-443 tcp\n port-object eq 443\n"))
+		Expect(output).To(ContainSubstring("object-group service virgil-tcp-8080-8082 tcp\n port-object range 8080 8082\n"))
+		Expect(output).To(ContainSubstring("object-group service virgil-udp-53 udp\n port-object eq 53\n"))
+	})
+
+	It("writes extended access-list entries, rendering protocol all as ip", func() {
+		Expect(output).To(MatchRegexp(`access-list virgil-egress extended permit tcp object-group virgil-sources object-group virgil-dst-[0-9a-f]{8} object-group virgil-tcp-443\n`))
+		Expect(output).To(MatchRegexp(`access-list virgil-egress extended permit udp object-group virgil-sources object-group virgil-dst-[0-9a-f]{8} object-group virgil-udp-53\n`))
+		Expect(output).To(MatchRegexp(`access-list virgil-egress extended permit ip object-group virgil-sources object-group virgil-dst-[0-9a-f]{8}\n`))
+	})
+
+	It("uses the access-list name from the options", func() {
+		var buffer bytes.Buffer
+		Expect(render.CiscoASA(&buffer, testFirewallRules(), render.ASAOptions{AccessList: "CF-OUT"})).To(Succeed())
+		Expect(buffer.String()).To(ContainSubstring("access-list CF-OUT extended permit"))
+	})
+})
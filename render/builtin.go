@@ -0,0 +1,289 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/FidelityInternational/virgil/utility"
+	"gopkg.in/yaml.v2"
+	"io"
+)
+
+func init() {
+	Register(YAMLRenderer{})
+	Register(JSONRenderer{})
+	Register(PANOSRenderer{})
+	Register(ASARenderer{})
+	Register(SRXRenderer{})
+	Register(FortiGateRenderer{})
+	Register(CheckPointRenderer{})
+	Register(NSXTRenderer{})
+	Register(KubernetesRenderer{Flavour: "kubernetes"})
+	Register(KubernetesRenderer{Flavour: "calico"})
+	Register(KubernetesRenderer{Flavour: "cilium"})
+	Register(TableRenderer{Format: "csv"})
+	Register(TableRenderer{Format: "xlsx"})
+	Register(ReportRenderer{Format: "html"})
+	Register(ReportRenderer{Format: "markdown"})
+	Register(TemplateRenderer{})
+}
+
+// YAMLRenderer - writes firewall rules in virgil's YAML schema
+type YAMLRenderer struct{}
+
+// Name - returns "yaml"
+func (YAMLRenderer) Name() string { return "yaml" }
+
+// FileExtension - returns "yml"
+func (YAMLRenderer) FileExtension() string { return "yml" }
+
+// Render - writes the firewall rules as a YAML document
+func (YAMLRenderer) Render(w io.Writer, firewallRules utility.FirewallRules, metadata Metadata) error {
+	yml, err := yaml.Marshal(&firewallRules)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "---\n%s", yml)
+	return err
+}
+
+// JSONRenderer - writes firewall rules in virgil's schema as JSON
+type JSONRenderer struct{}
+
+// Name - returns "json"
+func (JSONRenderer) Name() string { return "json" }
+
+// FileExtension - returns "json"
+func (JSONRenderer) FileExtension() string { return "json" }
+
+// Render - writes the firewall rules as an indented JSON document
+func (JSONRenderer) Render(w io.Writer, firewallRules utility.FirewallRules, metadata Metadata) error {
+	output, err := json.MarshalIndent(firewallRules, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", output)
+	return err
+}
+
+// PANOSRenderer - renders with PANOSXML, or PANOSSet when Output is "set"
+type PANOSRenderer struct {
+	Output  string
+	Options PANOSOptions
+}
+
+// Name - returns "panos"
+func (PANOSRenderer) Name() string { return "panos" }
+
+// FileExtension - returns "txt" for set commands, otherwise "xml"
+func (r PANOSRenderer) FileExtension() string {
+	if r.Output == "set" {
+		return "txt"
+	}
+	return "xml"
+}
+
+// Render - writes the firewall rules as PAN-OS configuration
+func (r PANOSRenderer) Render(w io.Writer, firewallRules utility.FirewallRules, metadata Metadata) error {
+	if r.Output == "set" {
+		return PANOSSet(w, firewallRules, r.Options)
+	}
+	return PANOSXML(w, firewallRules, r.Options)
+}
+
+// ASARenderer - renders with CiscoASA
+type ASARenderer struct {
+	Options ASAOptions
+}
+
+// Name - returns "cisco-asa"
+func (ASARenderer) Name() string { return "cisco-asa" }
+
+// FileExtension - returns "txt"
+func (ASARenderer) FileExtension() string { return "txt" }
+
+// Render - writes the firewall rules as Cisco ASA configuration
+func (r ASARenderer) Render(w io.Writer, firewallRules utility.FirewallRules, metadata Metadata) error {
+	return CiscoASA(w, firewallRules, r.Options)
+}
+
+// SRXRenderer - renders with JuniperSRX
+type SRXRenderer struct {
+	Options SRXOptions
+}
+
+// Name - returns "juniper-srx"
+func (SRXRenderer) Name() string { return "juniper-srx" }
+
+// FileExtension - returns "txt"
+func (SRXRenderer) FileExtension() string { return "txt" }
+
+// Render - writes the firewall rules as Junos set statements
+func (r SRXRenderer) Render(w io.Writer, firewallRules utility.FirewallRules, metadata Metadata) error {
+	return JuniperSRX(w, firewallRules, r.Options)
+}
+
+// FortiGateRenderer - renders with FortiGateCLI, or FortiGateREST when Output is "rest"
+type FortiGateRenderer struct {
+	Output  string
+	Options FortiGateOptions
+}
+
+// Name - returns "fortigate"
+func (FortiGateRenderer) Name() string { return "fortigate" }
+
+// FileExtension - returns "json" for REST payloads, otherwise "conf"
+func (r FortiGateRenderer) FileExtension() string {
+	if r.Output == "rest" {
+		return "json"
+	}
+	return "conf"
+}
+
+// Render - writes the firewall rules as FortiOS configuration
+func (r FortiGateRenderer) Render(w io.Writer, firewallRules utility.FirewallRules, metadata Metadata) error {
+	if r.Output == "rest" {
+		return FortiGateREST(w, firewallRules, r.Options)
+	}
+	return FortiGateCLI(w, firewallRules, r.Options)
+}
+
+// CheckPointRenderer - renders with CheckPointBatch, or CheckPointMgmtCLI when Output is "mgmt_cli"
+type CheckPointRenderer struct {
+	Output  string
+	Options CheckPointOptions
+}
+
+// Name - returns "checkpoint"
+func (CheckPointRenderer) Name() string { return "checkpoint" }
+
+// FileExtension - returns "sh" for mgmt_cli scripts, otherwise "json"
+func (r CheckPointRenderer) FileExtension() string {
+	if r.Output == "mgmt_cli" {
+		return "sh"
+	}
+	return "json"
+}
+
+// Render - writes the firewall rules as Check Point Management API commands
+func (r CheckPointRenderer) Render(w io.Writer, firewallRules utility.FirewallRules, metadata Metadata) error {
+	if r.Output == "mgmt_cli" {
+		return CheckPointMgmtCLI(w, firewallRules, r.Options)
+	}
+	return CheckPointBatch(w, firewallRules, r.Options)
+}
+
+// NSXTRenderer - renders with NSXT
+type NSXTRenderer struct {
+	Options NSXTOptions
+}
+
+// Name - returns "nsx-t"
+func (NSXTRenderer) Name() string { return "nsx-t" }
+
+// FileExtension - returns "json"
+func (NSXTRenderer) FileExtension() string { return "json" }
+
+// Render - writes the firewall rules as NSX-T Policy API JSON
+func (r NSXTRenderer) Render(w io.Writer, firewallRules utility.FirewallRules, metadata Metadata) error {
+	return NSXT(w, firewallRules, r.Options)
+}
+
+// KubernetesRenderer - renders Kubernetes ("kubernetes"), Calico ("calico") or Cilium ("cilium") policies.
+// Without a Mapping every firewall rule is written to a single policy in Namespace (default "default"),
+// otherwise the metadata's security groups are split between the mapped namespaces
+type KubernetesRenderer struct {
+	Flavour   string
+	Namespace string
+	Mapping   NamespaceMapping
+	Options   KubernetesOptions
+}
+
+// Name - returns the flavour of policy
+func (r KubernetesRenderer) Name() string { return r.Flavour }
+
+// FileExtension - returns "yaml"
+func (KubernetesRenderer) FileExtension() string { return "yaml" }
+
+// Render - writes a policy document per namespace
+func (r KubernetesRenderer) Render(w io.Writer, firewallRules utility.FirewallRules, metadata Metadata) error {
+	namespaces := []NamespaceRules{{Namespace: defaultString(r.Namespace, "default"), FirewallRules: firewallRules}}
+	if r.Mapping != nil {
+		namespaces = SplitByNamespace(metadata.SecurityGroups, r.Mapping)
+	}
+	switch r.Flavour {
+	case "calico":
+		return CalicoGlobalNetworkPolicy(w, namespaces, r.Options)
+	case "cilium":
+		return CiliumNetworkPolicy(w, namespaces, r.Options)
+	}
+	return KubernetesNetworkPolicy(w, namespaces, r.Options)
+}
+
+// TableRenderer - renders with CSV ("csv") or XLSX ("xlsx"), using the metadata's security groups for provenance
+type TableRenderer struct {
+	Format  string
+	Options TableOptions
+}
+
+// Name - returns the table format
+func (r TableRenderer) Name() string { return r.Format }
+
+// FileExtension - returns the table format
+func (r TableRenderer) FileExtension() string { return r.Format }
+
+// Render - writes the firewall rules as a spreadsheet
+func (r TableRenderer) Render(w io.Writer, firewallRules utility.FirewallRules, metadata Metadata) error {
+	options := r.Options
+	if options.SecurityGroups == nil {
+		options.SecurityGroups = metadata.SecurityGroups
+	}
+	if r.Format == "xlsx" {
+		return XLSX(w, firewallRules, options)
+	}
+	return CSV(w, firewallRules, options)
+}
+
+// ReportRenderer - renders with HTMLReport ("html") or MarkdownReport ("markdown")
+type ReportRenderer struct {
+	Format  string
+	Options ReportOptions
+}
+
+// Name - returns the report format
+func (r ReportRenderer) Name() string { return r.Format }
+
+// FileExtension - returns "html" or "md"
+func (r ReportRenderer) FileExtension() string {
+	if r.Format == "markdown" {
+		return "md"
+	}
+	return "html"
+}
+
+// Render - writes a report of the run
+func (r ReportRenderer) Render(w io.Writer, firewallRules utility.FirewallRules, metadata Metadata) error {
+	if r.Format == "markdown" {
+		return MarkdownReport(w, firewallRules, metadata, r.Options)
+	}
+	return HTMLReport(w, firewallRules, metadata, r.Options)
+}
+
+// TemplateRenderer - renders with CustomTemplate
+type TemplateRenderer struct {
+	Source    string
+	Extension string
+}
+
+// Name - returns "template"
+func (TemplateRenderer) Name() string { return "template" }
+
+// FileExtension - returns Extension, defaulting to "txt"
+func (r TemplateRenderer) FileExtension() string { return defaultString(r.Extension, "txt") }
+
+// Render - executes the template over the firewall rules
+func (r TemplateRenderer) Render(w io.Writer, firewallRules utility.FirewallRules, metadata Metadata) error {
+	if r.Source == "" {
+		return fmt.Errorf("A template must be given for the template format")
+	}
+	return CustomTemplate(w, firewallRules, metadata, r.Source)
+}
@@ -0,0 +1,90 @@
+package render_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/FidelityInternational/virgil/render"
+	"github.com/FidelityInternational/virgil/utility"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+	"strings"
+)
+
+var _ = Describe("Built in renderers", func() {
+	renderWith := func(renderer render.Renderer) string {
+		var buffer bytes.Buffer
+		Expect(renderer.Render(&buffer, testFirewallRules(), render.Metadata{})).To(Succeed())
+		return buffer.String()
+	}
+
+	Describe("YAMLRenderer", func() {
+		It("writes virgil's YAML schema", func() {
+			output := renderWith(render.YAMLRenderer{})
+			Expect(output).To(HavePrefix("---\nschema_version: \"1\"\nfirewall_rules:\n- port: \"443\"\n"))
+			var rules utility.FirewallRules
+			Expect(yaml.Unmarshal([]byte(output), &rules)).To(Succeed())
+			Expect(rules).To(Equal(testFirewallRules()))
+		})
+	})
+
+	Describe("JSONRenderer", func() {
+		It("writes virgil's schema as JSON", func() {
+			output := renderWith(render.JSONRenderer{})
+			Expect(output).To(ContainSubstring(`"schema_version": "1"`))
+			var rules utility.FirewallRules
+			Expect(json.Unmarshal([]byte(output), &rules)).To(Succeed())
+			Expect(rules).To(Equal(testFirewallRules()))
+		})
+	})
+
+	Describe("merged foundations", func() {
+		mergedRules := utility.FirewallRules{SchemaVersion: "1", FirewallRules: []utility.FirewallRule{
+			{Port: "443", Protocol: "tcp", Destination: []string{"10.1.0.1"}, Source: []string{"10.0.16.1"}},
+			{Port: "443", Protocol: "tcp", Destination: []string{"10.2.0.1"}, Source: []string{"10.0.32.1"}},
+		}}
+
+		It("keeps the first policy name and numbers the policies of other rules sharing a protocol and port", func() {
+			for _, renderer := range []render.Renderer{
+				render.PANOSRenderer{},
+				render.PANOSRenderer{Output: "set"},
+				render.SRXRenderer{},
+				render.FortiGateRenderer{},
+				render.FortiGateRenderer{Output: "rest"},
+				render.CheckPointRenderer{},
+				render.CheckPointRenderer{Output: "mgmt_cli"},
+				render.NSXTRenderer{},
+			} {
+				var buffer bytes.Buffer
+				Expect(renderer.Render(&buffer, mergedRules, render.Metadata{})).To(Succeed())
+				output := buffer.String()
+				Expect(output).To(ContainSubstring("virgil-tcp-443-2"), renderer.Name())
+				Expect(output).ToNot(ContainSubstring("virgil-tcp-443-3"), renderer.Name())
+			}
+		})
+
+		It("writes an access list entry for each rule sharing a protocol and port", func() {
+			var buffer bytes.Buffer
+			Expect(render.ASARenderer{}.Render(&buffer, mergedRules, render.Metadata{})).To(Succeed())
+			Expect(strings.Count(buffer.String(), "extended permit tcp")).To(Equal(2))
+		})
+	})
+
+	It("selects vendor output styles", func() {
+		Expect(renderWith(render.PANOSRenderer{Output: "set"})).To(HavePrefix("set address "))
+		Expect(render.PANOSRenderer{Output: "set"}.FileExtension()).To(Equal("txt"))
+		Expect(renderWith(render.PANOSRenderer{})).To(HavePrefix("<?xml"))
+		Expect(render.PANOSRenderer{}.FileExtension()).To(Equal("xml"))
+		Expect(renderWith(render.FortiGateRenderer{Output: "rest"})).To(HavePrefix("["))
+		Expect(renderWith(render.CheckPointRenderer{Output: "mgmt_cli"})).To(HavePrefix("#!/bin/sh"))
+		Expect(renderWith(render.KubernetesRenderer{Flavour: "cilium"})).To(ContainSubstring("namespace: default\n"))
+		Expect(renderWith(render.ReportRenderer{Format: "markdown"})).To(HavePrefix("# Firewall Policy Report"))
+		Expect(render.ReportRenderer{Format: "markdown"}.FileExtension()).To(Equal("md"))
+	})
+
+	It("requires a template source for the template renderer", func() {
+		var buffer bytes.Buffer
+		Expect(render.TemplateRenderer{}.Render(&buffer, testFirewallRules(), render.Metadata{})).To(MatchError("A template must be given for the template format"))
+		Expect(renderWith(render.TemplateRenderer{Source: "{{ len .FirewallRules }}"})).To(Equal("4"))
+	})
+})
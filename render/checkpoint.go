@@ -0,0 +1,193 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/FidelityInternational/virgil/utility"
+	"io"
+	"strings"
+)
+
+// checkpointMaxNameLength - keeps generated Check Point object names a manageable length
+const checkpointMaxNameLength = 100
+
+// CheckPointOptions - settings for the Check Point renderers, empty values fall back to sensible defaults
+type CheckPointOptions struct {
+	Prefix   string
+	Layer    string
+	Position string
+}
+
+type checkpointField struct {
+	Key   string
+	Value interface{}
+}
+
+// checkpointPayload - a Management API payload that keeps its fields in order when marshalled
+type checkpointPayload []checkpointField
+
+// MarshalJSON - writes the payload as a JSON object in field order
+func (p checkpointPayload) MarshalJSON() ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteString("{")
+	for i, field := range p {
+		if i != 0 {
+			buffer.WriteString(",")
+		}
+		key, _ := json.Marshal(field.Key)
+		value, err := json.Marshal(field.Value)
+		if err != nil {
+			return nil, err
+		}
+		buffer.Write(key)
+		buffer.WriteString(":")
+		buffer.Write(value)
+	}
+	buffer.WriteString("}")
+	return buffer.Bytes(), nil
+}
+
+type checkpointCommand struct {
+	Command string            `json:"command"`
+	Payload checkpointPayload `json:"payload"`
+}
+
+// CheckPointBatch - writes the firewall rules as a JSON list of Check Point Management API commands
+// (add-host, add-network, add-address-range, add-group, add-service-tcp/udp and add-access-rule)
+func CheckPointBatch(w io.Writer, firewallRules utility.FirewallRules, options CheckPointOptions) error {
+	commands, err := buildCheckPointCommands(firewallRules, options)
+	if err != nil {
+		return err
+	}
+	output, err := json.MarshalIndent(commands, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", output)
+	return err
+}
+
+// CheckPointMgmtCLI - writes the firewall rules as a mgmt_cli shell script to be run on the management server
+func CheckPointMgmtCLI(w io.Writer, firewallRules utility.FirewallRules, options CheckPointOptions) error {
+	commands, err := buildCheckPointCommands(firewallRules, options)
+	if err != nil {
+		return err
+	}
+	lines := []string{"#!/bin/sh", "set -e", "mgmt_cli login -r true > id.txt"}
+	for _, command := range commands {
+		arguments := []string{"mgmt_cli", strings.Replace(command.Command, "-", " ", 1)}
+		for _, field := range command.Payload {
+			switch value := field.Value.(type) {
+			case []string:
+				for i, member := range value {
+					arguments = append(arguments, fmt.Sprintf("%s.%d", field.Key, i+1), fmt.Sprintf("%q", member))
+				}
+			default:
+				arguments = append(arguments, field.Key, fmt.Sprintf("%q", fmt.Sprint(value)))
+			}
+		}
+		lines = append(lines, strings.Join(append(arguments, "-s", "id.txt"), " "))
+	}
+	lines = append(lines, "mgmt_cli publish -s id.txt", "mgmt_cli logout -s id.txt")
+	_, err = fmt.Fprintln(w, strings.Join(lines, "\n"))
+	return err
+}
+
+func buildCheckPointCommands(firewallRules utility.FirewallRules, options CheckPointOptions) ([]checkpointCommand, error) {
+	var objects, groupCommands, services, rules []checkpointCommand
+	prefix := defaultString(options.Prefix, "virgil")
+	layer := defaultString(options.Layer, "Network")
+	position := defaultString(options.Position, "bottom")
+	groups := newGroupNamer(prefix, checkpointMaxNameLength)
+	objectNames := make(map[string]string)
+	seenServices := make(map[string]bool)
+	seenRules := make(map[string]bool)
+
+	// members returns the object or group name for a set of addresses, defining them when first seen
+	members := func(values []string, kind string) ([]string, error) {
+		var names []string
+		for _, value := range uniqueAddresses(values) {
+			address, err := ParseAddress(value)
+			if err != nil {
+				return nil, err
+			}
+			name, ok := objectNames[address.String()]
+			if !ok {
+				switch address.Kind {
+				case Host:
+					name = SanitiseName(fmt.Sprintf("%s-host-%s", prefix, address), checkpointMaxNameLength)
+					objects = append(objects, checkpointCommand{Command: "add-host", Payload: checkpointPayload{
+						{"name", name}, {"ip-address", address.String()},
+					}})
+				case Network:
+					name = SanitiseName(fmt.Sprintf("%s-net-%s", prefix, address), checkpointMaxNameLength)
+					objects = append(objects, checkpointCommand{Command: "add-network", Payload: checkpointPayload{
+						{"name", name}, {"subnet", address.Prefix.Addr().String()}, {"mask-length", address.Prefix.Bits()},
+					}})
+				case Range:
+					name = SanitiseName(fmt.Sprintf("%s-range-%s", prefix, address), checkpointMaxNameLength)
+					objects = append(objects, checkpointCommand{Command: "add-address-range", Payload: checkpointPayload{
+						{"name", name}, {"ip-address-first", address.Start.String()}, {"ip-address-last", address.End.String()},
+					}})
+				}
+				objectNames[address.String()] = name
+			}
+			names = append(names, name)
+		}
+		utility.RemoveDuplicates(&names)
+		if len(names) == 1 {
+			return names, nil
+		}
+		name, created := groups.name(kind, names)
+		if created {
+			groupCommands = append(groupCommands, checkpointCommand{Command: "add-group", Payload: checkpointPayload{
+				{"name", name}, {"members", names},
+			}})
+		}
+		return []string{name}, nil
+	}
+
+	for _, rule := range firewallRules.FirewallRules {
+		protocol := strings.ToLower(rule.Protocol)
+		sources, err := members(rule.Source, "sources")
+		if err != nil {
+			return nil, err
+		}
+		destinations, err := members(rule.Destination, "dst")
+		if err != nil {
+			return nil, err
+		}
+		service := "Any"
+		ruleName := SanitiseName(fmt.Sprintf("%s-all-%s", prefix, ShortHash(destinations...)), checkpointMaxNameLength)
+		if protocol != "all" {
+			if _, _, err := SplitPortRange(rule.Port); err != nil {
+				return nil, err
+			}
+			service = SanitiseName(fmt.Sprintf("%s-%s-%s", prefix, protocol, rule.Port), checkpointMaxNameLength)
+			ruleName = service
+			if !seenServices[service] {
+				services = append(services, checkpointCommand{Command: fmt.Sprintf("add-service-%s", protocol), Payload: checkpointPayload{
+					{"name", service}, {"port", rule.Port},
+				}})
+				seenServices[service] = true
+			}
+		}
+		ruleName = UniqueName(ruleName, checkpointMaxNameLength, seenRules)
+		rules = append(rules, checkpointCommand{Command: "add-access-rule", Payload: checkpointPayload{
+			{"layer", layer},
+			{"position", position},
+			{"name", ruleName},
+			{"source", sources},
+			{"destination", destinations},
+			{"service", []string{service}},
+			{"action", "Accept"},
+		}})
+	}
+
+	var commands []checkpointCommand
+	for _, section := range [][]checkpointCommand{objects, groupCommands, services, rules} {
+		commands = append(commands, section...)
+	}
+	return commands, nil
+}
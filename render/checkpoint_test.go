@@ -0,0 +1,69 @@
+package render_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/FidelityInternational/virgil/render"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("#CheckPointBatch", func() {
+	var commands []map[string]interface{}
+
+	BeforeEach(func() {
+		var buffer bytes.Buffer
+		Expect(render.CheckPointBatch(&buffer, testFirewallRules(), render.CheckPointOptions{})).To(Succeed())
+		commands = nil
+		Expect(json.Unmarshal(buffer.Bytes(), &commands)).To(Succeed())
+	})
+
+	It("defines hosts, networks and address ranges", func() {
+		Expect(commands).To(ContainElement(map[string]interface{}{
+			"command": "add-host",
+			"payload": map[string]interface{}{"name": "virgil-host-10.0.16.1", "ip-address": "10.0.16.1"},
+		}))
+		Expect(commands).To(ContainElement(map[string]interface{}{
+			"command": "add-network",
+			"payload": map[string]interface{}{"name": "virgil-net-10.1.0.0_16", "subnet": "10.1.0.0", "mask-length": float64(16)},
+		}))
+		Expect(commands).To(ContainElement(map[string]interface{}{
+			"command": "add-address-range",
+			"payload": map[string]interface{}{"name": "virgil-range-10.2.0.1-10.2.0.9", "ip-address-first": "10.2.0.1", "ip-address-last": "10.2.0.9"},
+		}))
+	})
+
+	It("defines groups and services", func() {
+		Expect(commands).To(ContainElement(map[string]interface{}{
+			"command": "add-group",
+			"payload": map[string]interface{}{"name": "virgil-sources", "members": []interface{}{"virgil-host-10.0.16.1", "virgil-host-10.0.16.2"}},
+		}))
+		Expect(commands).To(ContainElement(map[string]interface{}{
+			"command": "add-service-udp",
+			"payload": map[string]interface{}{"name": "virgil-udp-53", "port": "53"},
+		}))
+	})
+
+	It("finishes with access rules", func() {
+		last := commands[len(commands)-1]
+		Expect(last["command"]).To(Equal("add-access-rule"))
+		Expect(last["payload"]).To(HaveKeyWithValue("service", []interface{}{"Any"}))
+		Expect(last["payload"]).To(HaveKeyWithValue("layer", "Network"))
+		Expect(last["payload"]).To(HaveKeyWithValue("action", "Accept"))
+	})
+})
+
+var _ = Describe("#CheckPointMgmtCLI", func() {
+	It("writes a mgmt_cli script", func() {
+		var buffer bytes.Buffer
+		Expect(render.CheckPointMgmtCLI(&buffer, testFirewallRules(), render.CheckPointOptions{Layer: "CF"})).To(Succeed())
+		output := buffer.String()
+		Expect(output).To(HavePrefix("#!/bin/sh\nset -e\nmgmt_cli login -r true > id.txt\n"))
+		Expect(output).To(ContainSubstring(`mgmt_cli add host name "virgil-host-10.0.16.1" ip-address "10.0.16.1" -s id.txt`))
+		Expect(output).To(ContainSubstring(`mgmt_cli add network name "virgil-net-10.1.0.0_16" subnet "10.1.0.0" mask-length "16" -s id.txt`))
+		Expect(output).To(ContainSubstring(`mgmt_cli add group name "virgil-sources" members.1 "virgil-host-10.0.16.1" members.2 "virgil-host-10.0.16.2" -s id.txt`))
+		Expect(output).To(ContainSubstring(`mgmt_cli add service-tcp name "virgil-tcp-8080-8082" port "8080-8082" -s id.txt`))
+		Expect(output).To(ContainSubstring(`mgmt_cli add access-rule layer "CF" position "bottom" name "virgil-tcp-8080-8082" source.1 "virgil-sources" destination.1 "virgil-host-192.168.1.10" service.1 "virgil-tcp-8080-8082" action "Accept" -s id.txt`))
+		Expect(output).To(HaveSuffix("mgmt_cli publish -s id.txt\nmgmt_cli logout -s id.txt\n"))
+	})
+})
@@ -0,0 +1,250 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/FidelityInternational/virgil/utility"
+	"io"
+	"strings"
+)
+
+const (
+	// fortigateMaxObjectNameLength - FortiOS limits address, address group and service names to 79 characters
+	fortigateMaxObjectNameLength = 79
+	// fortigateMaxPolicyNameLength - FortiOS limits firewall policy names to 35 characters
+	fortigateMaxPolicyNameLength = 35
+)
+
+// FortiGateOptions - settings for the FortiGate renderers, empty values fall back to sensible defaults
+type FortiGateOptions struct {
+	Prefix       string
+	SrcInterface string
+	DstInterface string
+}
+
+type fortigateName struct {
+	Name string `json:"name"`
+}
+
+type fortigateAddress struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Subnet  string `json:"subnet,omitempty"`
+	StartIP string `json:"start-ip,omitempty"`
+	EndIP   string `json:"end-ip,omitempty"`
+}
+
+type fortigateAddressGroup struct {
+	Name   string          `json:"name"`
+	Member []fortigateName `json:"member"`
+}
+
+type fortigateService struct {
+	Name         string `json:"name"`
+	TCPPortRange string `json:"tcp-portrange,omitempty"`
+	UDPPortRange string `json:"udp-portrange,omitempty"`
+}
+
+type fortigatePolicy struct {
+	Name     string          `json:"name"`
+	SrcIntf  []fortigateName `json:"srcintf"`
+	DstIntf  []fortigateName `json:"dstintf"`
+	SrcAddr  []fortigateName `json:"srcaddr"`
+	DstAddr  []fortigateName `json:"dstaddr"`
+	Service  []fortigateName `json:"service"`
+	Action   string          `json:"action"`
+	Schedule string          `json:"schedule"`
+}
+
+type fortigatePolicySet struct {
+	Addresses     []fortigateAddress
+	AddressGroups []fortigateAddressGroup
+	Services      []fortigateService
+	Policies      []fortigatePolicy
+}
+
+// fortigateRequest - a single FortiOS REST API call
+type fortigateRequest struct {
+	Method string      `json:"method"`
+	Path   string      `json:"path"`
+	Body   interface{} `json:"body"`
+}
+
+// FortiGateCLI - writes the firewall rules as FortiOS "config firewall address", "addrgrp",
+// "service custom" and "policy" blocks
+func FortiGateCLI(w io.Writer, firewallRules utility.FirewallRules, options FortiGateOptions) error {
+	policySet, err := buildFortiGatePolicySet(firewallRules, options)
+	if err != nil {
+		return err
+	}
+	var lines []string
+	lines = append(lines, "config firewall address")
+	for _, address := range policySet.Addresses {
+		lines = append(lines, fmt.Sprintf("    edit %q", address.Name))
+		if address.Type == "iprange" {
+			lines = append(lines, "        set type iprange", fmt.Sprintf("        set start-ip %s", address.StartIP), fmt.Sprintf("        set end-ip %s", address.EndIP))
+		} else {
+			lines = append(lines, fmt.Sprintf("        set subnet %s", address.Subnet))
+		}
+		lines = append(lines, "    next")
+	}
+	lines = append(lines, "end", "config firewall addrgrp")
+	for _, group := range policySet.AddressGroups {
+		lines = append(lines, fmt.Sprintf("    edit %q", group.Name), fmt.Sprintf("        set member %s", fortigateList(group.Member)), "    next")
+	}
+	lines = append(lines, "end", "config firewall service custom")
+	for _, service := range policySet.Services {
+		lines = append(lines, fmt.Sprintf("    edit %q", service.Name))
+		if service.TCPPortRange != "" {
+			lines = append(lines, fmt.Sprintf("        set tcp-portrange %s", service.TCPPortRange))
+		}
+		if service.UDPPortRange != "" {
+			lines = append(lines, fmt.Sprintf("        set udp-portrange %s", service.UDPPortRange))
+		}
+		lines = append(lines, "    next")
+	}
+	lines = append(lines, "end", "config firewall policy")
+	for _, policy := range policySet.Policies {
+		lines = append(lines,
+			"    edit 0",
+			fmt.Sprintf("        set name %q", policy.Name),
+			fmt.Sprintf("        set srcintf %s", fortigateList(policy.SrcIntf)),
+			fmt.Sprintf("        set dstintf %s", fortigateList(policy.DstIntf)),
+			fmt.Sprintf("        set srcaddr %s", fortigateList(policy.SrcAddr)),
+			fmt.Sprintf("        set dstaddr %s", fortigateList(policy.DstAddr)),
+			fmt.Sprintf("        set action %s", policy.Action),
+			fmt.Sprintf("        set schedule %q", policy.Schedule),
+			fmt.Sprintf("        set service %s", fortigateList(policy.Service)),
+			"    next",
+		)
+	}
+	lines = append(lines, "end")
+	_, err = fmt.Fprintln(w, strings.Join(lines, "\n"))
+	return err
+}
+
+// FortiGateREST - writes the firewall rules as a JSON list of FortiOS REST API requests
+// creating the same objects and policies as FortiGateCLI
+func FortiGateREST(w io.Writer, firewallRules utility.FirewallRules, options FortiGateOptions) error {
+	policySet, err := buildFortiGatePolicySet(firewallRules, options)
+	if err != nil {
+		return err
+	}
+	var requests []fortigateRequest
+	for _, address := range policySet.Addresses {
+		requests = append(requests, fortigateRequest{Method: "POST", Path: "/api/v2/cmdb/firewall/address", Body: address})
+	}
+	for _, group := range policySet.AddressGroups {
+		requests = append(requests, fortigateRequest{Method: "POST", Path: "/api/v2/cmdb/firewall/addrgrp", Body: group})
+	}
+	for _, service := range policySet.Services {
+		requests = append(requests, fortigateRequest{Method: "POST", Path: "/api/v2/cmdb/firewall.service/custom", Body: service})
+	}
+	for _, policy := range policySet.Policies {
+		requests = append(requests, fortigateRequest{Method: "POST", Path: "/api/v2/cmdb/firewall/policy", Body: policy})
+	}
+	output, err := json.MarshalIndent(requests, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", output)
+	return err
+}
+
+func buildFortiGatePolicySet(firewallRules utility.FirewallRules, options FortiGateOptions) (fortigatePolicySet, error) {
+	var policySet fortigatePolicySet
+	prefix := defaultString(options.Prefix, "virgil")
+	srcIntf := []fortigateName{{Name: defaultString(options.SrcInterface, "any")}}
+	dstIntf := []fortigateName{{Name: defaultString(options.DstInterface, "any")}}
+	groups := newGroupNamer(prefix, fortigateMaxObjectNameLength)
+	addressNames := make(map[string]string)
+	seenServices := make(map[string]bool)
+	seenPolicies := make(map[string]bool)
+
+	// members returns the address or address group names for a set of addresses, defining them when first seen
+	members := func(values []string, kind string) ([]fortigateName, error) {
+		var names []string
+		for _, value := range uniqueAddresses(values) {
+			address, err := ParseAddress(value)
+			if err != nil {
+				return nil, err
+			}
+			if !address.Start.Is4() {
+				return nil, fmt.Errorf("Address %s is not IPv4 and is not supported by the FortiGate renderer", value)
+			}
+			name, ok := addressNames[address.String()]
+			if !ok {
+				name = SanitiseName(fmt.Sprintf("%s-%s", prefix, address), fortigateMaxObjectNameLength)
+				entry := fortigateAddress{Name: name, Type: "ipmask", Subnet: fmt.Sprintf("%s %s", address.Prefix.Addr(), address.Mask())}
+				if address.Kind == Range {
+					entry = fortigateAddress{Name: name, Type: "iprange", StartIP: address.Start.String(), EndIP: address.End.String()}
+				}
+				policySet.Addresses = append(policySet.Addresses, entry)
+				addressNames[address.String()] = name
+			}
+			names = append(names, name)
+		}
+		utility.RemoveDuplicates(&names)
+		if len(names) == 1 {
+			return []fortigateName{{Name: names[0]}}, nil
+		}
+		name, created := groups.name(kind, names)
+		if created {
+			group := fortigateAddressGroup{Name: name}
+			for _, member := range names {
+				group.Member = append(group.Member, fortigateName{Name: member})
+			}
+			policySet.AddressGroups = append(policySet.AddressGroups, group)
+		}
+		return []fortigateName{{Name: name}}, nil
+	}
+
+	for _, rule := range firewallRules.FirewallRules {
+		protocol := strings.ToLower(rule.Protocol)
+		sources, err := members(rule.Source, "sources")
+		if err != nil {
+			return fortigatePolicySet{}, err
+		}
+		destinations, err := members(rule.Destination, "dst")
+		if err != nil {
+			return fortigatePolicySet{}, err
+		}
+		service := "ALL"
+		policyName := SanitiseName(fmt.Sprintf("%s-all-%s", prefix, ShortHash(destinations[0].Name)), fortigateMaxPolicyNameLength)
+		if protocol != "all" {
+			if _, _, err := SplitPortRange(rule.Port); err != nil {
+				return fortigatePolicySet{}, err
+			}
+			service = SanitiseName(fmt.Sprintf("%s-%s-%s", prefix, protocol, rule.Port), fortigateMaxObjectNameLength)
+			policyName = SanitiseName(fmt.Sprintf("%s-%s-%s", prefix, protocol, rule.Port), fortigateMaxPolicyNameLength)
+			if !seenServices[service] {
+				entry := fortigateService{Name: service, TCPPortRange: rule.Port}
+				if protocol == "udp" {
+					entry = fortigateService{Name: service, UDPPortRange: rule.Port}
+				}
+				policySet.Services = append(policySet.Services, entry)
+				seenServices[service] = true
+			}
+		}
+		policyName = UniqueName(policyName, fortigateMaxPolicyNameLength, seenPolicies)
+		policySet.Policies = append(policySet.Policies, fortigatePolicy{
+			Name:     policyName,
+			SrcIntf:  srcIntf,
+			DstIntf:  dstIntf,
+			SrcAddr:  sources,
+			DstAddr:  destinations,
+			Service:  []fortigateName{{Name: service}},
+			Action:   "accept",
+			Schedule: "always",
+		})
+	}
+	return policySet, nil
+}
+
+func fortigateList(names []fortigateName) string {
+	var quoted []string
+	for _, name := range names {
+		quoted = append(quoted, fmt.Sprintf("%q", name.Name))
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,74 @@
+package render_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/FidelityInternational/virgil/render"
+	"github.com/FidelityInternational/virgil/utility"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"strings"
+)
+
+var _ = Describe("#FortiGateCLI", func() {
+	var output string
+
+	BeforeEach(func() {
+		var buffer bytes.Buffer
+		Expect(render.FortiGateCLI(&buffer, testFirewallRules(), render.FortiGateOptions{DstInterface: "port2"})).To(Succeed())
+		output = buffer.String()
+	})
+
+	It("writes address and address group blocks", func() {
+		Expect(output).To(HavePrefix("config firewall address\n"))
+		Expect(output).To(ContainSubstring("    edit \"virgil-10.1.0.0_16\"\n        set subnet 10.1.0.0 255.255.0.0\n    next\n"))
+		Expect(output).To(ContainSubstring("    edit \"virgil-10.2.0.1-10.2.0.9\"\n        set type iprange\n        set start-ip 10.2.0.1\n        set end-ip 10.2.0.9\n    next\n"))
+		Expect(output).To(ContainSubstring("config firewall addrgrp\n    edit \"virgil-sources\"\n        set member \"virgil-10.0.16.1\" \"virgil-10.0.16.2\"\n    next\n"))
+	})
+
+	It("writes custom service blocks", func() {
+		Expect(output).To(ContainSubstring("    edit \"virgil-tcp-8080-8082\"\n        set tcp-portrange 8080-8082\n    next\n"))
+		Expect(output).To(ContainSubstring("    edit \"virgil-udp-53\"\n        set udp-portrange 53\n    next\n"))
+	})
+
+	It("writes policy blocks", func() {
+		Expect(output).To(ContainSubstring("config firewall policy\n    edit 0\n"))
+		Expect(output).To(ContainSubstring("        set name \"virgil-tcp-443\"\n        set srcintf \"any\"\n        set dstintf \"port2\"\n        set srcaddr \"virgil-sources\"\n"))
+		Expect(output).To(ContainSubstring("        set service \"ALL\"\n"))
+		Expect(output).To(HaveSuffix("end\n"))
+	})
+
+	It("keeps policy names within the FortiOS length limit", func() {
+		var buffer bytes.Buffer
+		options := render.FortiGateOptions{Prefix: "a-very-long-prefix-for-cloud-foundry"}
+		Expect(render.FortiGateCLI(&buffer, testFirewallRules(), options)).To(Succeed())
+		for _, line := range strings.Split(buffer.String(), "\n") {
+			if strings.HasPrefix(line, "        set name ") {
+				Expect(len(strings.Trim(strings.TrimPrefix(line, "        set name "), `"`))).To(BeNumerically("<=", 35))
+			}
+		}
+	})
+
+	It("returns an error for IPv6 addresses", func() {
+		var buffer bytes.Buffer
+		rules := utility.FirewallRules{FirewallRules: []utility.FirewallRule{{Protocol: "all", Destination: []string{"2001:db8::/32"}}}}
+		Expect(render.FortiGateCLI(&buffer, rules, render.FortiGateOptions{})).To(MatchError("Address 2001:db8::/32 is not IPv4 and is not supported by the FortiGate renderer"))
+	})
+})
+
+var _ = Describe("#FortiGateREST", func() {
+	It("writes a list of REST API requests", func() {
+		var buffer bytes.Buffer
+		Expect(render.FortiGateREST(&buffer, testFirewallRules(), render.FortiGateOptions{})).To(Succeed())
+		var requests []map[string]interface{}
+		Expect(json.Unmarshal(buffer.Bytes(), &requests)).To(Succeed())
+		Expect(requests[0]["method"]).To(Equal("POST"))
+		Expect(requests[0]["path"]).To(Equal("/api/v2/cmdb/firewall/address"))
+		Expect(requests[0]["body"]).To(HaveKeyWithValue("subnet", "10.0.16.1 255.255.255.255"))
+		last := requests[len(requests)-1]
+		Expect(last["path"]).To(Equal("/api/v2/cmdb/firewall/policy"))
+		Expect(last["body"]).To(HaveKeyWithValue("action", "accept"))
+		Expect(buffer.String()).To(ContainSubstring(`"path": "/api/v2/cmdb/firewall.service/custom"`))
+		Expect(buffer.String()).To(ContainSubstring(`"path": "/api/v2/cmdb/firewall/addrgrp"`))
+	})
+})
@@ -0,0 +1,38 @@
+package render
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// groupNamer - hands out stable names for the distinct address sets used by firewall rules. The
+// first set of sources is given a fixed name so cell scale-outs change group membership rather
+// than the group name, every other set is named after a hash of its members
+type groupNamer struct {
+	prefix    string
+	maxLength int
+	names     map[string]string
+	sources   bool
+}
+
+func newGroupNamer(prefix string, maxLength int) *groupNamer {
+	return &groupNamer{prefix: prefix, maxLength: maxLength, names: make(map[string]string)}
+}
+
+// name - returns the group name for the members and whether it is the first time the set was seen
+func (n *groupNamer) name(kind string, members []string) (string, bool) {
+	members = append([]string{}, members...)
+	sort.Strings(members)
+	key := fmt.Sprintf("%s:%s", kind, strings.Join(members, ","))
+	if name, ok := n.names[key]; ok {
+		return name, false
+	}
+	name := SanitiseName(fmt.Sprintf("%s-%s-%s", n.prefix, kind, ShortHash(members...)), n.maxLength)
+	if kind == "sources" && !n.sources {
+		name = SanitiseName(fmt.Sprintf("%s-sources", n.prefix), n.maxLength)
+		n.sources = true
+	}
+	n.names[key] = name
+	return name, true
+}
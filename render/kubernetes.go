@@ -0,0 +1,275 @@
+package render
+
+import (
+	"fmt"
+	"github.com/FidelityInternational/virgil/utility"
+	"github.com/cloudfoundry/go-cfclient/v3/resource"
+	"gopkg.in/yaml.v2"
+	"io"
+	"sort"
+	"strings"
+)
+
+// KubernetesOptions - settings for the Kubernetes, Calico and Cilium renderers
+type KubernetesOptions struct {
+	PolicyName string
+}
+
+// NamespaceRules - the firewall rules that apply to workloads in a single Kubernetes namespace
+type NamespaceRules struct {
+	Namespace     string
+	FirewallRules utility.FirewallRules
+}
+
+// NamespaceMapping - maps each Kubernetes namespace to the GUIDs of the CF spaces whose security groups it inherits
+type NamespaceMapping map[string][]string
+
+// ParseNamespaceMapping - reads a YAML document mapping namespaces to lists of CF spaces
+func ParseNamespaceMapping(data []byte) (NamespaceMapping, error) {
+	var mapping NamespaceMapping
+	if err := yaml.Unmarshal(data, &mapping); err != nil {
+		return nil, fmt.Errorf("Namespace mapping was invalid: %s", err)
+	}
+	return mapping, nil
+}
+
+// SplitByNamespace - returns the firewall rules for each mapped namespace, built from the security groups
+// applied to running apps in its spaces (including globally enabled groups), sorted by namespace
+func SplitByNamespace(secGroups []resource.SecurityGroup, mapping NamespaceMapping) []NamespaceRules {
+	var namespaces []NamespaceRules
+	for namespace, spaceGUIDs := range mapping {
+		namespaces = append(namespaces, NamespaceRules{
+			Namespace:     namespace,
+			FirewallRules: utility.GetFirewallRules(nil, utility.GetRunningSecGroups(secGroups, spaceGUIDs)),
+		})
+	}
+	sort.Slice(namespaces, func(i, j int) bool { return namespaces[i].Namespace < namespaces[j].Namespace })
+	return namespaces
+}
+
+type kubernetesMetadata struct {
+	Name      string `yaml:"name"`
+	Namespace string `yaml:"namespace,omitempty"`
+}
+
+type kubernetesIPBlock struct {
+	CIDR string `yaml:"cidr"`
+}
+
+type kubernetesPeer struct {
+	IPBlock kubernetesIPBlock `yaml:"ipBlock"`
+}
+
+type kubernetesPort struct {
+	Protocol string `yaml:"protocol"`
+	Port     int    `yaml:"port"`
+	EndPort  int    `yaml:"endPort,omitempty"`
+}
+
+type kubernetesEgressRule struct {
+	To    []kubernetesPeer `yaml:"to"`
+	Ports []kubernetesPort `yaml:"ports,omitempty"`
+}
+
+type kubernetesNetworkPolicySpec struct {
+	PodSelector struct{}               `yaml:"podSelector"`
+	PolicyTypes []string               `yaml:"policyTypes"`
+	Egress      []kubernetesEgressRule `yaml:"egress"`
+}
+
+type kubernetesNetworkPolicy struct {
+	APIVersion string                      `yaml:"apiVersion"`
+	Kind       string                      `yaml:"kind"`
+	Metadata   kubernetesMetadata          `yaml:"metadata"`
+	Spec       kubernetesNetworkPolicySpec `yaml:"spec"`
+}
+
+type calicoEntityRule struct {
+	Nets  []string `yaml:"nets"`
+	Ports []string `yaml:"ports,omitempty"`
+}
+
+type calicoRule struct {
+	Action      string           `yaml:"action"`
+	Protocol    string           `yaml:"protocol,omitempty"`
+	Destination calicoEntityRule `yaml:"destination"`
+}
+
+type calicoGlobalNetworkPolicySpec struct {
+	NamespaceSelector string       `yaml:"namespaceSelector"`
+	Types             []string     `yaml:"types"`
+	Egress            []calicoRule `yaml:"egress"`
+}
+
+type calicoGlobalNetworkPolicy struct {
+	APIVersion string                        `yaml:"apiVersion"`
+	Kind       string                        `yaml:"kind"`
+	Metadata   kubernetesMetadata            `yaml:"metadata"`
+	Spec       calicoGlobalNetworkPolicySpec `yaml:"spec"`
+}
+
+type ciliumPort struct {
+	Port     string `yaml:"port"`
+	EndPort  int    `yaml:"endPort,omitempty"`
+	Protocol string `yaml:"protocol"`
+}
+
+type ciliumPortRule struct {
+	Ports []ciliumPort `yaml:"ports"`
+}
+
+type ciliumEgressRule struct {
+	ToCIDR  []string         `yaml:"toCIDR"`
+	ToPorts []ciliumPortRule `yaml:"toPorts,omitempty"`
+}
+
+type ciliumNetworkPolicySpec struct {
+	EndpointSelector struct{}           `yaml:"endpointSelector"`
+	Egress           []ciliumEgressRule `yaml:"egress"`
+}
+
+type ciliumNetworkPolicy struct {
+	APIVersion string                  `yaml:"apiVersion"`
+	Kind       string                  `yaml:"kind"`
+	Metadata   kubernetesMetadata      `yaml:"metadata"`
+	Spec       ciliumNetworkPolicySpec `yaml:"spec"`
+}
+
+// KubernetesNetworkPolicy - writes a networking.k8s.io/v1 NetworkPolicy per namespace allowing egress to
+// each firewall rule's destinations as ipBlocks, with port ranges expressed using endPort
+func KubernetesNetworkPolicy(w io.Writer, namespaces []NamespaceRules, options KubernetesOptions) error {
+	var documents []interface{}
+	for _, namespace := range namespaces {
+		policy := kubernetesNetworkPolicy{
+			APIVersion: "networking.k8s.io/v1",
+			Kind:       "NetworkPolicy",
+			Metadata:   kubernetesMetadata{Name: defaultString(options.PolicyName, "virgil-egress"), Namespace: namespace.Namespace},
+			Spec:       kubernetesNetworkPolicySpec{PolicyTypes: []string{"Egress"}, Egress: []kubernetesEgressRule{}},
+		}
+		for _, rule := range namespace.FirewallRules.FirewallRules {
+			cidrs, err := destinationCIDRs(rule)
+			if err != nil {
+				return err
+			}
+			egressRule := kubernetesEgressRule{}
+			for _, cidr := range cidrs {
+				egressRule.To = append(egressRule.To, kubernetesPeer{IPBlock: kubernetesIPBlock{CIDR: cidr}})
+			}
+			if !strings.EqualFold(rule.Protocol, "all") {
+				start, end, err := SplitPortRange(rule.Port)
+				if err != nil {
+					return err
+				}
+				port := kubernetesPort{Protocol: strings.ToUpper(rule.Protocol), Port: start}
+				if end != start {
+					port.EndPort = end
+				}
+				egressRule.Ports = []kubernetesPort{port}
+			}
+			policy.Spec.Egress = append(policy.Spec.Egress, egressRule)
+		}
+		documents = append(documents, policy)
+	}
+	return writeYAMLDocuments(w, documents)
+}
+
+// CalicoGlobalNetworkPolicy - writes a projectcalico.org/v3 GlobalNetworkPolicy per namespace, selecting the
+// namespace by name and allowing egress to each firewall rule's destination nets and ports
+func CalicoGlobalNetworkPolicy(w io.Writer, namespaces []NamespaceRules, options KubernetesOptions) error {
+	var documents []interface{}
+	for _, namespace := range namespaces {
+		policy := calicoGlobalNetworkPolicy{
+			APIVersion: "projectcalico.org/v3",
+			Kind:       "GlobalNetworkPolicy",
+			Metadata:   kubernetesMetadata{Name: KubernetesName(fmt.Sprintf("%s-%s", defaultString(options.PolicyName, "virgil-egress"), namespace.Namespace))},
+			Spec: calicoGlobalNetworkPolicySpec{
+				NamespaceSelector: fmt.Sprintf("kubernetes.io/metadata.name == '%s'", namespace.Namespace),
+				Types:             []string{"Egress"},
+				Egress:            []calicoRule{},
+			},
+		}
+		for _, rule := range namespace.FirewallRules.FirewallRules {
+			cidrs, err := destinationCIDRs(rule)
+			if err != nil {
+				return err
+			}
+			calicoEgress := calicoRule{Action: "Allow", Destination: calicoEntityRule{Nets: cidrs}}
+			if !strings.EqualFold(rule.Protocol, "all") {
+				start, end, err := SplitPortRange(rule.Port)
+				if err != nil {
+					return err
+				}
+				port := fmt.Sprint(start)
+				if end != start {
+					port = fmt.Sprintf("%d:%d", start, end)
+				}
+				calicoEgress.Protocol = strings.ToUpper(rule.Protocol)
+				calicoEgress.Destination.Ports = []string{port}
+			}
+			policy.Spec.Egress = append(policy.Spec.Egress, calicoEgress)
+		}
+		documents = append(documents, policy)
+	}
+	return writeYAMLDocuments(w, documents)
+}
+
+// CiliumNetworkPolicy - writes a cilium.io/v2 CiliumNetworkPolicy per namespace allowing egress to each
+// firewall rule's destinations using toCIDR and toPorts
+func CiliumNetworkPolicy(w io.Writer, namespaces []NamespaceRules, options KubernetesOptions) error {
+	var documents []interface{}
+	for _, namespace := range namespaces {
+		policy := ciliumNetworkPolicy{
+			APIVersion: "cilium.io/v2",
+			Kind:       "CiliumNetworkPolicy",
+			Metadata:   kubernetesMetadata{Name: defaultString(options.PolicyName, "virgil-egress"), Namespace: namespace.Namespace},
+			Spec:       ciliumNetworkPolicySpec{Egress: []ciliumEgressRule{}},
+		}
+		for _, rule := range namespace.FirewallRules.FirewallRules {
+			cidrs, err := destinationCIDRs(rule)
+			if err != nil {
+				return err
+			}
+			egressRule := ciliumEgressRule{ToCIDR: cidrs}
+			if !strings.EqualFold(rule.Protocol, "all") {
+				start, end, err := SplitPortRange(rule.Port)
+				if err != nil {
+					return err
+				}
+				port := ciliumPort{Port: fmt.Sprint(start), Protocol: strings.ToUpper(rule.Protocol)}
+				if end != start {
+					port.EndPort = end
+				}
+				egressRule.ToPorts = []ciliumPortRule{{Ports: []ciliumPort{port}}}
+			}
+			policy.Spec.Egress = append(policy.Spec.Egress, egressRule)
+		}
+		documents = append(documents, policy)
+	}
+	return writeYAMLDocuments(w, documents)
+}
+
+func destinationCIDRs(rule utility.FirewallRule) ([]string, error) {
+	var cidrs []string
+	for _, destination := range uniqueAddresses(rule.Destination) {
+		address, err := ParseAddress(destination)
+		if err != nil {
+			return nil, err
+		}
+		cidrs = append(cidrs, address.CIDRs()...)
+	}
+	utility.RemoveDuplicates(&cidrs)
+	return cidrs, nil
+}
+
+func writeYAMLDocuments(w io.Writer, documents []interface{}) error {
+	for _, document := range documents {
+		output, err := yaml.Marshal(document)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "---\n%s", output); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package render_test
+
+import (
+	"bytes"
+	"github.com/FidelityInternational/virgil/render"
+	"github.com/FidelityInternational/virgil/utility"
+	"github.com/cloudfoundry/go-cfclient/v3/resource"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("#ParseNamespaceMapping", func() {
+	It("parses a namespace to spaces mapping", func() {
+		mapping, err := render.ParseNamespaceMapping([]byte("payments:\n- space-1\n- org/space\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(mapping).To(Equal(render.NamespaceMapping{"payments": {"space-1", "org/space"}}))
+	})
+
+	It("returns an error for invalid documents", func() {
+		_, err := render.ParseNamespaceMapping([]byte("- a\n- b\n"))
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("#SplitByNamespace", func() {
+	It("builds firewall rules from the running security groups of each namespace's spaces", func() {
+		secGroups := []resource.SecurityGroup{
+			{
+				Name:            "global",
+				GloballyEnabled: resource.SecurityGroupGloballyEnabled{Running: utility.BoolPtr(true), Staging: utility.BoolPtr(false)},
+				Rules:           []resource.SecurityGroupRule{{Protocol: "tcp", Ports: utility.StringPtr("53"), Destination: "10.0.0.2"}},
+			},
+			{
+				Name:            "payments",
+				GloballyEnabled: resource.SecurityGroupGloballyEnabled{Running: utility.BoolPtr(false), Staging: utility.BoolPtr(false)},
+				Rules:           []resource.SecurityGroupRule{{Protocol: "tcp", Ports: utility.StringPtr("5432"), Destination: "10.9.0.0/24"}},
+				Relationships: resource.SecurityGroupsRelationships{
+					RunningSpaces: resource.ToManyRelationships{Data: []resource.Relationship{{GUID: "space-1"}}},
+				},
+			},
+		}
+		namespaces := render.SplitByNamespace(secGroups, render.NamespaceMapping{"web": {"space-2"}, "payments": {"space-1"}})
+		Expect(namespaces).To(HaveLen(2))
+		Expect(namespaces[0].Namespace).To(Equal("payments"))
+		Expect(namespaces[0].FirewallRules.FirewallRules).To(HaveLen(2))
+		Expect(namespaces[1].Namespace).To(Equal("web"))
+		Expect(namespaces[1].FirewallRules.FirewallRules).To(Equal([]utility.FirewallRule{{Port: "53", Protocol: "tcp", Destination: []string{"10.0.0.2"}}}))
+	})
+})
+
+var _ = Describe("Kubernetes renderers", func() {
+	var namespaces []render.NamespaceRules
+
+	BeforeEach(func() {
+		namespaces = []render.NamespaceRules{{Namespace: "payments", FirewallRules: testFirewallRules()}}
+	})
+
+	Describe("#KubernetesNetworkPolicy", func() {
+		It("writes NetworkPolicy egress rules with ipBlocks and endPort", func() {
+			var buffer bytes.Buffer
+			Expect(render.KubernetesNetworkPolicy(&buffer, namespaces, render.KubernetesOptions{})).To(Succeed())
+			output := buffer.String()
+			Expect(output).To(HavePrefix("---\napiVersion: networking.k8s.io/v1\nkind: NetworkPolicy\nmetadata:\n  name: virgil-egress\n  namespace: payments\nspec:\n  podSelector: {}\n  policyTypes:\n  - Egress\n"))
+			Expect(output).To(ContainSubstring("  - to:\n    - ipBlock:\n        cidr: 192.168.1.10/32\n    ports:\n    - protocol: TCP\n      port: 8080\n      endPort: 8082\n"))
+			Expect(output).To(ContainSubstring("    - ipBlock:\n        cidr: 10.2.0.8/31\n    ports:\n    - protocol: UDP\n      port: 53\n"))
+			Expect(output).To(ContainSubstring("  - to:\n    - ipBlock:\n        cidr: 172.16.0.0/12\n"))
+		})
+	})
+
+	Describe("#CalicoGlobalNetworkPolicy", func() {
+		It("writes a GlobalNetworkPolicy selecting the namespace", func() {
+			var buffer bytes.Buffer
+			Expect(render.CalicoGlobalNetworkPolicy(&buffer, namespaces, render.KubernetesOptions{PolicyName: "cf"})).To(Succeed())
+			output := buffer.String()
+			Expect(output).To(ContainSubstring("kind: GlobalNetworkPolicy\nmetadata:\n  name: cf-payments\n"))
+			Expect(output).To(ContainSubstring("  namespaceSelector: kubernetes.io/metadata.name == 'payments'\n"))
+			Expect(output).To(ContainSubstring("  - action: Allow\n    protocol: TCP\n    destination:\n      nets:\n      - 192.168.1.10/32\n      ports:\n      - 8080:8082\n"))
+			Expect(output).To(ContainSubstring("  - action: Allow\n    destination:\n      nets:\n      - 172.16.0.0/12\n"))
+		})
+
+		It("writes a DNS-1123 name for mixed-case namespaces and policy names", func() {
+			var buffer bytes.Buffer
+			namespaces[0].Namespace = "Payments_EU"
+			Expect(render.CalicoGlobalNetworkPolicy(&buffer, namespaces, render.KubernetesOptions{PolicyName: "CF Egress"})).To(Succeed())
+			output := buffer.String()
+			Expect(output).To(ContainSubstring("metadata:\n  name: cf-egress-payments-eu\n"))
+			Expect(output).To(ContainSubstring("  namespaceSelector: kubernetes.io/metadata.name == 'Payments_EU'\n"))
+		})
+	})
+
+	Describe("#CiliumNetworkPolicy", func() {
+		It("writes a CiliumNetworkPolicy with toCIDR and toPorts", func() {
+			var buffer bytes.Buffer
+			Expect(render.CiliumNetworkPolicy(&buffer, namespaces, render.KubernetesOptions{})).To(Succeed())
+			output := buffer.String()
+			Expect(output).To(ContainSubstring("apiVersion: cilium.io/v2\nkind: CiliumNetworkPolicy\nmetadata:\n  name: virgil-egress\n  namespace: payments\nspec:\n  endpointSelector: {}\n"))
+			Expect(output).To(ContainSubstring("  - toCIDR:\n    - 192.168.1.10/32\n    toPorts:\n    - ports:\n      - port: \"8080\"\n        endPort: 8082\n        protocol: TCP\n"))
+			Expect(output).To(ContainSubstring("  - toCIDR:\n    - 172.16.0.0/12\n"))
+		})
+	})
+})
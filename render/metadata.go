@@ -0,0 +1,17 @@
+package render
+
+import (
+	"github.com/FidelityInternational/virgil/utility"
+	"github.com/cloudfoundry/go-cfclient/v3/resource"
+	"time"
+)
+
+// Metadata - details of the run that produced a set of firewall rules
+type Metadata struct {
+	GeneratedAt         time.Time
+	Foundation          string
+	Deployments         []string
+	TotalSecurityGroups int
+	SecurityGroups      []resource.SecurityGroup
+	SkippedRules        []utility.SkippedRule
+}
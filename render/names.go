@@ -0,0 +1,55 @@
+package render
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	invalidNameCharacters           = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+	invalidKubernetesNameCharacters = regexp.MustCompile(`[^a-z0-9.-]+`)
+)
+
+// SanitiseName - replaces characters that firewall vendors commonly reject with underscores and
+// truncates the name to maxLength, appending a hash of the full name so truncated names stay unique
+func SanitiseName(name string, maxLength int) string {
+	name = invalidNameCharacters.ReplaceAllString(strings.TrimSpace(name), "_")
+	if maxLength <= 0 || len(name) <= maxLength {
+		return name
+	}
+	hash := ShortHash(name)
+	if maxLength <= len(hash) {
+		return hash[:maxLength]
+	}
+	return strings.TrimRight(name[:maxLength-len(hash)-1], "_-.") + "-" + hash
+}
+
+// KubernetesName - lowercases the name and replaces characters a DNS-1123 subdomain does not allow with "-", so
+// names built from user input such as namespaces or policy names are accepted as object names
+func KubernetesName(name string) string {
+	name = invalidKubernetesNameCharacters.ReplaceAllString(strings.ToLower(strings.TrimSpace(name)), "-")
+	return SanitiseName(strings.Trim(name, "-."), 253)
+}
+
+// UniqueName - returns name, or name with an ordinal suffix such as "-2" when used already has it, and records the
+// name returned in used. Rules sharing a protocol and port, such as those of merged foundations, each get a policy
+// while the first keeps its plain name so reruns do not rename it
+func UniqueName(name string, maxLength int, used map[string]bool) string {
+	unique := name
+	for i := 2; used[unique]; i++ {
+		suffix := fmt.Sprintf("-%d", i)
+		unique = SanitiseName(name, maxLength-len(suffix)) + suffix
+	}
+	used[unique] = true
+	return unique
+}
+
+// ShortHash - returns a stable 8 character digest of the given values, used to name objects
+// such as destination groups so reruns against the same security groups produce the same names
+func ShortHash(values ...string) string {
+	digest := sha1.Sum([]byte(strings.Join(values, "\n")))
+	return hex.EncodeToString(digest[:])[:8]
+}
@@ -0,0 +1,59 @@
+package render_test
+
+import (
+	"github.com/FidelityInternational/virgil/render"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"strings"
+)
+
+var _ = Describe("#SanitiseName", func() {
+	It("replaces unsupported characters", func() {
+		Expect(render.SanitiseName("virgil 10.0.0.0/24", 0)).To(Equal("virgil_10.0.0.0_24"))
+	})
+
+	It("truncates long names with a stable hash suffix", func() {
+		name := render.SanitiseName("virgil-a-very-long-object-name-that-goes-on", 20)
+		Expect(name).To(HaveLen(20))
+		Expect(name).To(HavePrefix("virgil-a-ve-"))
+		Expect(render.SanitiseName("virgil-a-very-long-object-name-that-goes-on", 20)).To(Equal(name))
+		Expect(render.SanitiseName("virgil-a-very-long-object-name-that-goes-off", 20)).ToNot(Equal(name))
+	})
+})
+
+var _ = Describe("#KubernetesName", func() {
+	It("lowercases the name and replaces characters DNS-1123 does not allow", func() {
+		Expect(render.KubernetesName("Virgil_Egress-Payments EU.")).To(Equal("virgil-egress-payments-eu"))
+	})
+
+	It("truncates long names to 253 characters", func() {
+		name := render.KubernetesName(strings.Repeat("A", 300))
+		Expect(name).To(HaveLen(253))
+		Expect(name).To(MatchRegexp(`^[a-z0-9][a-z0-9.-]*[a-z0-9]$`))
+	})
+})
+
+var _ = Describe("#UniqueName", func() {
+	It("numbers names that have been used", func() {
+		used := make(map[string]bool)
+		Expect(render.UniqueName("virgil-tcp-443", 0, used)).To(Equal("virgil-tcp-443"))
+		Expect(render.UniqueName("virgil-tcp-443", 0, used)).To(Equal("virgil-tcp-443-2"))
+		Expect(render.UniqueName("virgil-tcp-443", 0, used)).To(Equal("virgil-tcp-443-3"))
+		Expect(render.UniqueName("virgil-udp-53", 0, used)).To(Equal("virgil-udp-53"))
+	})
+
+	It("keeps numbered names within the maximum length", func() {
+		used := map[string]bool{"virgil-tcp-8080-8082": true}
+		name := render.UniqueName("virgil-tcp-8080-8082", 20, used)
+		Expect(name).To(HaveLen(20))
+		Expect(name).To(HaveSuffix("-2"))
+	})
+})
+
+var _ = Describe("#ShortHash", func() {
+	It("returns a stable 8 character digest", func() {
+		Expect(render.ShortHash("a", "b")).To(HaveLen(8))
+		Expect(render.ShortHash("a", "b")).To(Equal(render.ShortHash("a", "b")))
+		Expect(render.ShortHash("a", "b")).ToNot(Equal(render.ShortHash("b", "a")))
+	})
+})
@@ -0,0 +1,193 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/FidelityInternational/virgil/utility"
+	"io"
+	"strings"
+)
+
+// nsxtMaxIDLength - NSX-T Policy API object IDs are limited to 255 characters, keep them well short of that
+const nsxtMaxIDLength = 128
+
+// NSXTOptions - settings for the NSX-T renderer, empty values fall back to sensible defaults
+type NSXTOptions struct {
+	Prefix   string
+	Domain   string
+	PolicyID string
+	Category string
+}
+
+type nsxtIPAddressExpression struct {
+	ResourceType string   `json:"resource_type"`
+	IPAddresses  []string `json:"ip_addresses"`
+}
+
+type nsxtGroup struct {
+	ID           string                    `json:"id"`
+	ResourceType string                    `json:"resource_type"`
+	DisplayName  string                    `json:"display_name"`
+	Expression   []nsxtIPAddressExpression `json:"expression"`
+}
+
+type nsxtServiceEntry struct {
+	ID               string   `json:"id"`
+	ResourceType     string   `json:"resource_type"`
+	L4Protocol       string   `json:"l4_protocol"`
+	DestinationPorts []string `json:"destination_ports"`
+}
+
+type nsxtService struct {
+	ID             string             `json:"id"`
+	ResourceType   string             `json:"resource_type"`
+	DisplayName    string             `json:"display_name"`
+	ServiceEntries []nsxtServiceEntry `json:"service_entries"`
+}
+
+type nsxtRule struct {
+	ID                string   `json:"id"`
+	ResourceType      string   `json:"resource_type"`
+	DisplayName       string   `json:"display_name"`
+	SequenceNumber    int      `json:"sequence_number"`
+	SourceGroups      []string `json:"source_groups"`
+	DestinationGroups []string `json:"destination_groups"`
+	Services          []string `json:"services"`
+	Scope             []string `json:"scope"`
+	Action            string   `json:"action"`
+	Direction         string   `json:"direction"`
+}
+
+type nsxtSecurityPolicy struct {
+	ID           string     `json:"id"`
+	ResourceType string     `json:"resource_type"`
+	DisplayName  string     `json:"display_name"`
+	Category     string     `json:"category"`
+	Rules        []nsxtRule `json:"rules"`
+}
+
+type nsxtChild struct {
+	ResourceType   string              `json:"resource_type"`
+	Domain         *nsxtDomain         `json:"Domain,omitempty"`
+	Group          *nsxtGroup          `json:"Group,omitempty"`
+	Service        *nsxtService        `json:"Service,omitempty"`
+	SecurityPolicy *nsxtSecurityPolicy `json:"SecurityPolicy,omitempty"`
+}
+
+type nsxtDomain struct {
+	ID           string      `json:"id"`
+	ResourceType string      `json:"resource_type"`
+	Children     []nsxtChild `json:"children"`
+}
+
+type nsxtInfra struct {
+	ResourceType string      `json:"resource_type"`
+	Children     []nsxtChild `json:"children"`
+}
+
+// NSXT - writes the firewall rules as an NSX-T Policy API hierarchical document to PATCH into
+// /policy/api/v1/infra. Groups, Services and the SecurityPolicy use IDs derived from their contents
+// so re-applying an unchanged policy updates the existing objects rather than duplicating them
+func NSXT(w io.Writer, firewallRules utility.FirewallRules, options NSXTOptions) error {
+	prefix := defaultString(options.Prefix, "virgil")
+	domain := defaultString(options.Domain, "default")
+	policyID := defaultString(options.PolicyID, fmt.Sprintf("%s-egress", prefix))
+	groups := newGroupNamer(prefix, nsxtMaxIDLength)
+	policy := nsxtSecurityPolicy{
+		ID:           policyID,
+		ResourceType: "SecurityPolicy",
+		DisplayName:  policyID,
+		Category:     defaultString(options.Category, "Application"),
+	}
+	var domainChildren, infraChildren []nsxtChild
+	seenServices := make(map[string]bool)
+	seenRules := make(map[string]bool)
+
+	// groupPath returns the policy path of the group holding the addresses, defining it when first seen
+	groupPath := func(values []string, kind string) (string, error) {
+		var addresses []string
+		for _, value := range uniqueAddresses(values) {
+			address, err := ParseAddress(value)
+			if err != nil {
+				return "", err
+			}
+			addresses = append(addresses, address.String())
+		}
+		utility.RemoveDuplicates(&addresses)
+		id, created := groups.name(kind, addresses)
+		if created {
+			domainChildren = append(domainChildren, nsxtChild{ResourceType: "ChildGroup", Group: &nsxtGroup{
+				ID:           id,
+				ResourceType: "Group",
+				DisplayName:  id,
+				Expression:   []nsxtIPAddressExpression{{ResourceType: "IPAddressExpression", IPAddresses: addresses}},
+			}})
+		}
+		return fmt.Sprintf("/infra/domains/%s/groups/%s", domain, id), nil
+	}
+
+	for _, rule := range firewallRules.FirewallRules {
+		protocol := strings.ToLower(rule.Protocol)
+		sources, err := groupPath(rule.Source, "sources")
+		if err != nil {
+			return err
+		}
+		destinations, err := groupPath(rule.Destination, "dst")
+		if err != nil {
+			return err
+		}
+		services := []string{"ANY"}
+		ruleID := SanitiseName(fmt.Sprintf("%s-all-%s", prefix, ShortHash(destinations)), nsxtMaxIDLength)
+		if protocol != "all" {
+			if _, _, err := SplitPortRange(rule.Port); err != nil {
+				return err
+			}
+			serviceID := SanitiseName(fmt.Sprintf("%s-%s-%s", prefix, protocol, rule.Port), nsxtMaxIDLength)
+			ruleID = serviceID
+			services = []string{fmt.Sprintf("/infra/services/%s", serviceID)}
+			if !seenServices[serviceID] {
+				infraChildren = append(infraChildren, nsxtChild{ResourceType: "ChildService", Service: &nsxtService{
+					ID:           serviceID,
+					ResourceType: "Service",
+					DisplayName:  serviceID,
+					ServiceEntries: []nsxtServiceEntry{{
+						ID:               fmt.Sprintf("%s-%s", protocol, rule.Port),
+						ResourceType:     "L4PortSetServiceEntry",
+						L4Protocol:       strings.ToUpper(protocol),
+						DestinationPorts: []string{rule.Port},
+					}},
+				}})
+				seenServices[serviceID] = true
+			}
+		}
+		ruleID = UniqueName(ruleID, nsxtMaxIDLength, seenRules)
+		policy.Rules = append(policy.Rules, nsxtRule{
+			ID:                ruleID,
+			ResourceType:      "Rule",
+			DisplayName:       ruleID,
+			SequenceNumber:    (len(policy.Rules) + 1) * 10,
+			SourceGroups:      []string{sources},
+			DestinationGroups: []string{destinations},
+			Services:          services,
+			Scope:             []string{"ANY"},
+			Action:            "ALLOW",
+			Direction:         "OUT",
+		})
+	}
+
+	domainChildren = append(domainChildren, nsxtChild{ResourceType: "ChildSecurityPolicy", SecurityPolicy: &policy})
+	infra := nsxtInfra{
+		ResourceType: "Infra",
+		Children: append(infraChildren, nsxtChild{ResourceType: "ChildDomain", Domain: &nsxtDomain{
+			ID:           domain,
+			ResourceType: "Domain",
+			Children:     domainChildren,
+		}}),
+	}
+	output, err := json.MarshalIndent(infra, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", output)
+	return err
+}
@@ -0,0 +1,98 @@
+package render_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/FidelityInternational/virgil/render"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type nsxtDocument struct {
+	ResourceType string `json:"resource_type"`
+	Children     []struct {
+		ResourceType string `json:"resource_type"`
+		Service      *struct {
+			ID             string `json:"id"`
+			ServiceEntries []struct {
+				ResourceType     string   `json:"resource_type"`
+				L4Protocol       string   `json:"l4_protocol"`
+				DestinationPorts []string `json:"destination_ports"`
+			} `json:"service_entries"`
+		} `json:"Service"`
+		Domain *struct {
+			ID       string `json:"id"`
+			Children []struct {
+				ResourceType string `json:"resource_type"`
+				Group        *struct {
+					ID         string `json:"id"`
+					Expression []struct {
+						ResourceType string   `json:"resource_type"`
+						IPAddresses  []string `json:"ip_addresses"`
+					} `json:"expression"`
+				} `json:"Group"`
+				SecurityPolicy *struct {
+					ID    string `json:"id"`
+					Rules []struct {
+						ID                string   `json:"id"`
+						SequenceNumber    int      `json:"sequence_number"`
+						SourceGroups      []string `json:"source_groups"`
+						DestinationGroups []string `json:"destination_groups"`
+						Services          []string `json:"services"`
+						Action            string   `json:"action"`
+					} `json:"rules"`
+				} `json:"SecurityPolicy"`
+			} `json:"children"`
+		} `json:"Domain"`
+	} `json:"children"`
+}
+
+var _ = Describe("#NSXT", func() {
+	var (
+		document nsxtDocument
+		raw      []byte
+	)
+
+	BeforeEach(func() {
+		var buffer bytes.Buffer
+		Expect(render.NSXT(&buffer, testFirewallRules(), render.NSXTOptions{})).To(Succeed())
+		raw = buffer.Bytes()
+		document = nsxtDocument{}
+		Expect(json.Unmarshal(raw, &document)).To(Succeed())
+	})
+
+	It("writes an Infra document with services and a domain", func() {
+		Expect(document.ResourceType).To(Equal("Infra"))
+		Expect(document.Children).To(HaveLen(4))
+		service := document.Children[1].Service
+		Expect(service.ID).To(Equal("virgil-tcp-8080-8082"))
+		Expect(service.ServiceEntries[0].ResourceType).To(Equal("L4PortSetServiceEntry"))
+		Expect(service.ServiceEntries[0].L4Protocol).To(Equal("TCP"))
+		Expect(service.ServiceEntries[0].DestinationPorts).To(Equal([]string{"8080-8082"}))
+		Expect(document.Children[3].Domain.ID).To(Equal("default"))
+	})
+
+	It("writes groups with IP address expressions and a security policy", func() {
+		children := document.Children[3].Domain.Children
+		Expect(children[0].Group.ID).To(Equal("virgil-sources"))
+		Expect(children[0].Group.Expression[0].ResourceType).To(Equal("IPAddressExpression"))
+		Expect(children[0].Group.Expression[0].IPAddresses).To(Equal([]string{"10.0.16.1", "10.0.16.2"}))
+		policy := children[len(children)-1].SecurityPolicy
+		Expect(policy.ID).To(Equal("virgil-egress"))
+		Expect(policy.Rules).To(HaveLen(4))
+		Expect(policy.Rules[0].ID).To(Equal("virgil-tcp-443"))
+		Expect(policy.Rules[0].SequenceNumber).To(Equal(10))
+		Expect(policy.Rules[0].SourceGroups).To(Equal([]string{"/infra/domains/default/groups/virgil-sources"}))
+		Expect(policy.Rules[0].Services).To(Equal([]string{"/infra/services/virgil-tcp-443"}))
+		Expect(policy.Rules[3].Services).To(Equal([]string{"ANY"}))
+		Expect(policy.Rules[3].Action).To(Equal("ALLOW"))
+	})
+
+	It("produces identical IDs on every run", func() {
+		var buffer bytes.Buffer
+		rules := testFirewallRules()
+		rules.FirewallRules[0].Destination = []string{"192.168.1.10", "10.1.0.0/16"}
+		Expect(render.NSXT(&buffer, rules, render.NSXTOptions{})).To(Succeed())
+		Expect(buffer.Bytes()).To(Equal(raw))
+	})
+})
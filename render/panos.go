@@ -0,0 +1,235 @@
+package render
+
+import (
+	"encoding/xml"
+	"fmt"
+	"github.com/FidelityInternational/virgil/utility"
+	"io"
+	"sort"
+	"strings"
+)
+
+// panosMaxNameLength - PAN-OS rejects object and rule names longer than 63 characters
+const panosMaxNameLength = 63
+
+// PANOSOptions - settings for the PAN-OS renderers, empty values fall back to sensible defaults
+type PANOSOptions struct {
+	Prefix   string
+	Vsys     string
+	FromZone string
+	ToZone   string
+}
+
+type panosMembers struct {
+	Members []string `xml:"member"`
+}
+
+type panosAddress struct {
+	Name      string `xml:"name,attr"`
+	IPNetmask string `xml:"ip-netmask,omitempty"`
+	IPRange   string `xml:"ip-range,omitempty"`
+}
+
+type panosAddressGroup struct {
+	Name   string       `xml:"name,attr"`
+	Static panosMembers `xml:"static"`
+}
+
+type panosPort struct {
+	Port string `xml:"port"`
+}
+
+type panosService struct {
+	Name     string     `xml:"name,attr"`
+	Protocol string     `xml:"-"`
+	Port     string     `xml:"-"`
+	TCP      *panosPort `xml:"protocol>tcp,omitempty"`
+	UDP      *panosPort `xml:"protocol>udp,omitempty"`
+}
+
+type panosRule struct {
+	Name        string       `xml:"name,attr"`
+	From        panosMembers `xml:"from"`
+	To          panosMembers `xml:"to"`
+	Source      panosMembers `xml:"source"`
+	Destination panosMembers `xml:"destination"`
+	Application panosMembers `xml:"application"`
+	Service     panosMembers `xml:"service"`
+	Action      string       `xml:"action"`
+}
+
+type panosVsys struct {
+	Name          string              `xml:"name,attr"`
+	Addresses     []panosAddress      `xml:"address>entry"`
+	AddressGroups []panosAddressGroup `xml:"address-group>entry"`
+	Services      []panosService      `xml:"service>entry"`
+	Rules         []panosRule         `xml:"rulebase>security>rules>entry"`
+}
+
+type panosDevice struct {
+	Name string      `xml:"name,attr"`
+	Vsys []panosVsys `xml:"vsys>entry"`
+}
+
+type panosConfig struct {
+	XMLName xml.Name      `xml:"config"`
+	Devices []panosDevice `xml:"devices>entry"`
+}
+
+// PANOSXML - writes the firewall rules as a PAN-OS configuration document containing address
+// objects, address groups, service objects and security rules, suitable for "load config partial"
+func PANOSXML(w io.Writer, firewallRules utility.FirewallRules, options PANOSOptions) error {
+	vsys, err := buildPANOSVsys(firewallRules, options)
+	if err != nil {
+		return err
+	}
+	config := panosConfig{
+		Devices: []panosDevice{{Name: "localhost.localdomain", Vsys: []panosVsys{vsys}}},
+	}
+	output, err := xml.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s%s\n", xml.Header, output)
+	return err
+}
+
+// PANOSSet - writes the firewall rules as PAN-OS "set" CLI commands
+func PANOSSet(w io.Writer, firewallRules utility.FirewallRules, options PANOSOptions) error {
+	vsys, err := buildPANOSVsys(firewallRules, options)
+	if err != nil {
+		return err
+	}
+	var lines []string
+	for _, address := range vsys.Addresses {
+		if address.IPRange != "" {
+			lines = append(lines, fmt.Sprintf("set address %s ip-range %s", address.Name, address.IPRange))
+		} else {
+			lines = append(lines, fmt.Sprintf("set address %s ip-netmask %s", address.Name, address.IPNetmask))
+		}
+	}
+	for _, group := range vsys.AddressGroups {
+		lines = append(lines, fmt.Sprintf("set address-group %s static %s", group.Name, panosList(group.Static.Members)))
+	}
+	for _, service := range vsys.Services {
+		lines = append(lines, fmt.Sprintf("set service %s protocol %s port %s", service.Name, service.Protocol, service.Port))
+	}
+	for _, rule := range vsys.Rules {
+		lines = append(lines, fmt.Sprintf(
+			"set rulebase security rules %s from %s to %s source %s destination %s application %s service %s action %s",
+			rule.Name,
+			panosList(rule.From.Members),
+			panosList(rule.To.Members),
+			panosList(rule.Source.Members),
+			panosList(rule.Destination.Members),
+			panosList(rule.Application.Members),
+			panosList(rule.Service.Members),
+			rule.Action,
+		))
+	}
+	_, err = fmt.Fprintln(w, strings.Join(lines, "\n"))
+	return err
+}
+
+func buildPANOSVsys(firewallRules utility.FirewallRules, options PANOSOptions) (panosVsys, error) {
+	prefix := defaultString(options.Prefix, "virgil")
+	vsys := panosVsys{Name: defaultString(options.Vsys, "vsys1")}
+	addressNames := make(map[string]string)
+	groups := newGroupNamer(prefix, panosMaxNameLength)
+	serviceNames := make(map[string]bool)
+	ruleNames := make(map[string]bool)
+
+	var allSources, allDestinations []string
+	for _, rule := range firewallRules.FirewallRules {
+		allSources = append(allSources, rule.Source...)
+		allDestinations = append(allDestinations, rule.Destination...)
+	}
+	for _, value := range uniqueAddresses(allSources, allDestinations) {
+		address, err := ParseAddress(value)
+		if err != nil {
+			return panosVsys{}, err
+		}
+		if _, ok := addressNames[address.String()]; ok {
+			addressNames[value] = addressNames[address.String()]
+			continue
+		}
+		name := SanitiseName(fmt.Sprintf("%s-%s", prefix, address), panosMaxNameLength)
+		entry := panosAddress{Name: name}
+		if address.Kind == Range {
+			entry.IPRange = address.String()
+		} else {
+			entry.IPNetmask = address.CIDR()
+		}
+		vsys.Addresses = append(vsys.Addresses, entry)
+		addressNames[address.String()] = name
+		addressNames[value] = name
+	}
+
+	// groupMembers resolves a list of addresses to a single address object or an address group
+	groupMembers := func(addresses []string, kind string) []string {
+		var members []string
+		for _, address := range addresses {
+			members = append(members, addressNames[address])
+		}
+		utility.RemoveDuplicates(&members)
+		sort.Strings(members)
+		if len(members) == 1 {
+			return members
+		}
+		name, created := groups.name(kind, members)
+		if !created {
+			return []string{name}
+		}
+		vsys.AddressGroups = append(vsys.AddressGroups, panosAddressGroup{Name: name, Static: panosMembers{Members: members}})
+		return []string{name}
+	}
+
+	for _, rule := range firewallRules.FirewallRules {
+		protocol := strings.ToLower(rule.Protocol)
+		sources := groupMembers(rule.Source, "sources")
+		destinations := groupMembers(rule.Destination, "dst")
+		service := "any"
+		ruleName := SanitiseName(fmt.Sprintf("%s-%s-%s", prefix, protocol, rule.Port), panosMaxNameLength)
+		if protocol == "all" {
+			ruleName = SanitiseName(fmt.Sprintf("%s-all-%s", prefix, ShortHash(destinations...)), panosMaxNameLength)
+		} else {
+			service = SanitiseName(fmt.Sprintf("%s-%s-%s", prefix, protocol, rule.Port), panosMaxNameLength)
+			if !serviceNames[service] {
+				entry := panosService{Name: service, Protocol: protocol, Port: rule.Port}
+				if protocol == "udp" {
+					entry.UDP = &panosPort{Port: rule.Port}
+				} else {
+					entry.TCP = &panosPort{Port: rule.Port}
+				}
+				vsys.Services = append(vsys.Services, entry)
+				serviceNames[service] = true
+			}
+		}
+		ruleName = UniqueName(ruleName, panosMaxNameLength, ruleNames)
+		vsys.Rules = append(vsys.Rules, panosRule{
+			Name:        ruleName,
+			From:        panosMembers{Members: []string{defaultString(options.FromZone, "any")}},
+			To:          panosMembers{Members: []string{defaultString(options.ToZone, "any")}},
+			Source:      panosMembers{Members: sources},
+			Destination: panosMembers{Members: destinations},
+			Application: panosMembers{Members: []string{"any"}},
+			Service:     panosMembers{Members: []string{service}},
+			Action:      "allow",
+		})
+	}
+	return vsys, nil
+}
+
+func panosList(members []string) string {
+	if len(members) == 1 {
+		return members[0]
+	}
+	return fmt.Sprintf("[ %s ]", strings.Join(members, " "))
+}
+
+func defaultString(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,69 @@
+package render_test
+
+import (
+	"bytes"
+	"github.com/FidelityInternational/virgil/render"
+	"github.com/FidelityInternational/virgil/utility"
+	"github.com/cloudfoundry/go-cfclient/v3/resource"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("#PANOSSet", func() {
+	It("writes address, group, service and security rule set commands", func() {
+		var buffer bytes.Buffer
+		Expect(render.PANOSSet(&buffer, testFirewallRules(), render.PANOSOptions{ToZone: "untrust"})).To(Succeed())
+		output := buffer.String()
+		Expect(output).To(ContainSubstring("set address virgil-10.0.16.1 ip-netmask 10.0.16.1/32\n"))
+		Expect(output).To(ContainSubstring("set address virgil-10.1.0.0_16 ip-netmask 10.1.0.0/16\n"))
+		Expect(output).To(ContainSubstring("set address virgil-10.2.0.1-10.2.0.9 ip-range 10.2.0.1-10.2.0.9\n"))
+		Expect(output).To(ContainSubstring("set address-group virgil-sources static [ virgil-10.0.16.1 virgil-10.0.16.2 ]\n"))
+		Expect(output).To(ContainSubstring("set service virgil-tcp-8080-8082 protocol tcp port 8080-8082\n"))
+		Expect(output).To(ContainSubstring("set service virgil-udp-53 protocol udp port 53\n"))
+		Expect(output).To(ContainSubstring("set rulebase security rules virgil-tcp-8080-8082 from any to untrust source virgil-sources destination virgil-192.168.1.10 application any service virgil-tcp-8080-8082 action allow\n"))
+		Expect(output).To(MatchRegexp(`set rulebase security rules virgil-tcp-443 from any to untrust source virgil-sources destination virgil-dst-[0-9a-f]{8} application any service virgil-tcp-443 action allow`))
+		Expect(output).To(MatchRegexp(`set rulebase security rules virgil-all-[0-9a-f]{8} from any to untrust source virgil-sources destination virgil-172.16.0.0_12 application any service any action allow`))
+	})
+
+	It("produces the same names regardless of destination order", func() {
+		var first, second bytes.Buffer
+		rules := testFirewallRules()
+		Expect(render.PANOSSet(&first, rules, render.PANOSOptions{})).To(Succeed())
+		rules.FirewallRules[0].Destination = []string{"192.168.1.10", "10.1.0.0/16"}
+		Expect(render.PANOSSet(&second, rules, render.PANOSOptions{})).To(Succeed())
+		Expect(first.String()).To(Equal(second.String()))
+	})
+
+	It("writes a single rule for security group rules whose protocols differ only in case", func() {
+		secGroups := []resource.SecurityGroup{
+			{Name: "upper", GloballyEnabled: resource.SecurityGroupGloballyEnabled{Running: utility.BoolPtr(true)}, Rules: []resource.SecurityGroupRule{{Protocol: "TCP", Ports: utility.StringPtr("443"), Destination: "10.1.0.1"}}},
+			{Name: "lower", GloballyEnabled: resource.SecurityGroupGloballyEnabled{Running: utility.BoolPtr(true)}, Rules: []resource.SecurityGroupRule{{Protocol: "tcp", Ports: utility.StringPtr("443"), Destination: "10.2.0.1"}}},
+		}
+		var buffer bytes.Buffer
+		Expect(render.PANOSSet(&buffer, utility.GetFirewallRules([]string{"10.0.16.1"}, secGroups), render.PANOSOptions{})).To(Succeed())
+		Expect(buffer.String()).To(MatchRegexp(`set rulebase security rules virgil-tcp-443 .* destination virgil-dst-[0-9a-f]{8} `))
+		Expect(buffer.String()).ToNot(ContainSubstring("virgil-tcp-443-2"))
+	})
+
+	It("returns an error for unparsable addresses", func() {
+		var buffer bytes.Buffer
+		rules := utility.FirewallRules{FirewallRules: []utility.FirewallRule{{Port: "1", Protocol: "tcp", Destination: []string{"nope"}}}}
+		Expect(render.PANOSSet(&buffer, rules, render.PANOSOptions{})).To(MatchError("Address nope was invalid"))
+	})
+})
+
+var _ = Describe("#PANOSXML", func() {
+	It("writes a PAN-OS configuration document", func() {
+		var buffer bytes.Buffer
+		Expect(render.PANOSXML(&buffer, testFirewallRules(), render.PANOSOptions{Prefix: "cf", Vsys: "vsys2"})).To(Succeed())
+		output := buffer.String()
+		Expect(output).To(HavePrefix(`<?xml version="1.0" encoding="UTF-8"?>`))
+		Expect(output).To(ContainSubstring(`<entry name="vsys2">`))
+		Expect(output).To(ContainSubstring(`<entry name="cf-10.2.0.1-10.2.0.9">`))
+		Expect(output).To(ContainSubstring(`<ip-range>10.2.0.1-10.2.0.9</ip-range>`))
+		Expect(output).To(ContainSubstring(`<entry name="cf-udp-53">`))
+		Expect(output).To(ContainSubstring(`<udp>`))
+		Expect(output).To(ContainSubstring(`<rulebase>`))
+		Expect(output).To(ContainSubstring(`<action>allow</action>`))
+	})
+})
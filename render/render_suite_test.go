@@ -0,0 +1,26 @@
+package render_test
+
+import (
+	"github.com/FidelityInternational/virgil/utility"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Render test suite")
+}
+
+func testFirewallRules() utility.FirewallRules {
+	source := []string{"10.0.16.1", "10.0.16.2"}
+	return utility.FirewallRules{
+		SchemaVersion: "1",
+		FirewallRules: []utility.FirewallRule{
+			{Port: "443", Protocol: "tcp", Destination: []string{"10.1.0.0/16", "192.168.1.10"}, Source: source},
+			{Port: "8080-8082", Protocol: "tcp", Destination: []string{"192.168.1.10"}, Source: source},
+			{Port: "53", Protocol: "udp", Destination: []string{"10.2.0.1-10.2.0.9"}, Source: source},
+			{Port: "", Protocol: "all", Destination: []string{"172.16.0.0/12"}, Source: source},
+		},
+	}
+}
@@ -0,0 +1,59 @@
+package render
+
+import (
+	"fmt"
+	"github.com/FidelityInternational/virgil/utility"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Renderer - writes firewall rules in a particular output format
+type Renderer interface {
+	// Name - the format name used to select the renderer, e.g. "yaml" or "panos"
+	Name() string
+	// FileExtension - the extension, without a leading dot, of files written by the renderer
+	FileExtension() string
+	// Render - writes the firewall rules and run metadata to w
+	Render(w io.Writer, firewallRules utility.FirewallRules, metadata Metadata) error
+}
+
+var (
+	registryMutex sync.RWMutex
+	registry      = make(map[string]Renderer)
+)
+
+// Register - adds a renderer to the registry, replacing any renderer already registered with the same name
+func Register(renderer Renderer) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	registry[renderer.Name()] = renderer
+}
+
+// Get - returns the renderer registered with the given name
+func Get(name string) (Renderer, error) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	renderer, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("Output format %s is not supported, valid formats are %s", name, strings.Join(namesLocked(), ", "))
+	}
+	return renderer, nil
+}
+
+// Names - returns the sorted names of all registered renderers
+func Names() []string {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	return namesLocked()
+}
+
+func namesLocked() []string {
+	var names []string
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,44 @@
+package render_test
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/FidelityInternational/virgil/render"
+	"github.com/FidelityInternational/virgil/utility"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io"
+)
+
+type countRenderer struct{}
+
+func (countRenderer) Name() string          { return "count" }
+func (countRenderer) FileExtension() string { return "txt" }
+func (countRenderer) Render(w io.Writer, firewallRules utility.FirewallRules, metadata render.Metadata) error {
+	_, err := fmt.Fprintf(w, "%s: %d", metadata.Foundation, len(firewallRules.FirewallRules))
+	return err
+}
+
+var _ = Describe("Renderer registry", func() {
+	It("has the built in renderers registered", func() {
+		Expect(render.Names()).To(Equal([]string{
+			"calico", "checkpoint", "cilium", "cisco-asa", "csv", "fortigate", "html", "json",
+			"juniper-srx", "kubernetes", "markdown", "nsx-t", "panos", "template", "xlsx", "yaml",
+		}))
+	})
+
+	It("registers and returns additional renderers", func() {
+		render.Register(countRenderer{})
+		renderer, err := render.Get("count")
+		Expect(err).ToNot(HaveOccurred())
+		var buffer bytes.Buffer
+		Expect(renderer.Render(&buffer, testFirewallRules(), render.Metadata{Foundation: "dc1"})).To(Succeed())
+		Expect(buffer.String()).To(Equal("dc1: 4"))
+		Expect(render.Names()).To(ContainElement("count"))
+	})
+
+	It("returns an error for unknown formats", func() {
+		_, err := render.Get("unknown")
+		Expect(err).To(MatchError(HavePrefix("Output format unknown is not supported, valid formats are calico, checkpoint,")))
+	})
+})
@@ -0,0 +1,161 @@
+package render
+
+import (
+	"embed"
+	"fmt"
+	"github.com/FidelityInternational/virgil/utility"
+	htmltemplate "html/template"
+	"io"
+	"net/netip"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates/report.html.tmpl templates/report.md.tmpl
+var reportTemplates embed.FS
+
+// wideDestinationBits - destinations with a prefix this short or shorter (65536+ addresses) are reported as risks
+const wideDestinationBits = 16
+
+// ReportOptions - settings for the report renderers, Template replaces the built in template when set
+type ReportOptions struct {
+	Template string
+}
+
+// ReportRule - a firewall rule in a report, with the security groups it came from
+type ReportRule struct {
+	utility.FirewallRule
+	SecurityGroups []string
+}
+
+// ReportProtocol - the firewall rules in a report for a single protocol
+type ReportProtocol struct {
+	Protocol string
+	Rules    []ReportRule
+}
+
+// ReportRisk - a destination in the policy that deserves a closer look
+type ReportRisk struct {
+	Protocol       string
+	Port           string
+	Destination    string
+	Reason         string
+	SecurityGroups []string
+}
+
+// ReportData - everything available to a report template
+type ReportData struct {
+	Metadata
+	FirewallRules       utility.FirewallRules
+	Protocols           []ReportProtocol
+	Risks               []ReportRisk
+	RuleCount           int
+	SourceCount         int
+	DestinationCount    int
+	UsedSecurityGroups  int
+	SkippedRuleWarnings []string
+}
+
+// NewReportData - summarises firewall rules and run metadata for a report template
+func NewReportData(firewallRules utility.FirewallRules, metadata Metadata) ReportData {
+	data := ReportData{
+		Metadata:           metadata,
+		FirewallRules:      firewallRules,
+		RuleCount:          len(firewallRules.FirewallRules),
+		UsedSecurityGroups: len(metadata.SecurityGroups),
+	}
+	var sources, destinations []string
+	protocols := make(map[string]*ReportProtocol)
+	for _, rule := range firewallRules.FirewallRules {
+		sources = append(sources, rule.Source...)
+		destinations = append(destinations, rule.Destination...)
+		protocol := strings.ToLower(rule.Protocol)
+		if protocols[protocol] == nil {
+			protocols[protocol] = &ReportProtocol{Protocol: protocol}
+		}
+		var provenance []string
+		for _, destination := range rule.Destination {
+			destinationProvenance := utility.GetProvenance(rule.Protocol, destination, rule.Port, metadata.SecurityGroups)
+			provenance = append(provenance, destinationProvenance...)
+			if reason := destinationRisk(destination); reason != "" {
+				data.Risks = append(data.Risks, ReportRisk{
+					Protocol:       rule.Protocol,
+					Port:           rule.Port,
+					Destination:    destination,
+					Reason:         reason,
+					SecurityGroups: destinationProvenance,
+				})
+			}
+		}
+		utility.RemoveDuplicates(&provenance)
+		protocols[protocol].Rules = append(protocols[protocol].Rules, ReportRule{FirewallRule: rule, SecurityGroups: provenance})
+	}
+	for _, protocol := range protocols {
+		data.Protocols = append(data.Protocols, *protocol)
+	}
+	sort.Slice(data.Protocols, func(i, j int) bool { return data.Protocols[i].Protocol < data.Protocols[j].Protocol })
+	utility.RemoveDuplicates(&sources)
+	utility.RemoveDuplicates(&destinations)
+	data.SourceCount = len(sources)
+	data.DestinationCount = len(destinations)
+	for _, skippedRule := range metadata.SkippedRules {
+		data.SkippedRuleWarnings = append(data.SkippedRuleWarnings, fmt.Sprintf("%s: %s rule to %s skipped - %s", skippedRule.SecurityGroup, skippedRule.Rule.Protocol, skippedRule.Rule.Destination, skippedRule.Reason))
+	}
+	return data
+}
+
+// HTMLReport - writes an HTML summary of the run using html/template
+func HTMLReport(w io.Writer, firewallRules utility.FirewallRules, metadata Metadata, options ReportOptions) error {
+	source, err := reportTemplate(options, "templates/report.html.tmpl")
+	if err != nil {
+		return err
+	}
+	tmpl, err := htmltemplate.New("report").Funcs(htmltemplate.FuncMap(TemplateFuncs())).Parse(source)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, NewReportData(firewallRules, metadata))
+}
+
+// MarkdownReport - writes a Markdown summary of the run using text/template
+func MarkdownReport(w io.Writer, firewallRules utility.FirewallRules, metadata Metadata, options ReportOptions) error {
+	source, err := reportTemplate(options, "templates/report.md.tmpl")
+	if err != nil {
+		return err
+	}
+	tmpl, err := texttemplate.New("report").Funcs(TemplateFuncs()).Parse(source)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, NewReportData(firewallRules, metadata))
+}
+
+func reportTemplate(options ReportOptions, builtIn string) (string, error) {
+	if options.Template != "" {
+		return options.Template, nil
+	}
+	source, err := reportTemplates.ReadFile(builtIn)
+	return string(source), err
+}
+
+// destinationRisk - returns why a destination is considered risky, or an empty string
+func destinationRisk(destination string) string {
+	address, err := ParseAddress(destination)
+	if err != nil {
+		return ""
+	}
+	bits := address.Start.BitLen()
+	for _, cidr := range address.CIDRs() {
+		if prefix, err := netip.ParsePrefix(cidr); err == nil && prefix.Bits() < bits {
+			bits = prefix.Bits()
+		}
+	}
+	switch {
+	case bits == 0:
+		return "Destination allows any address"
+	case bits <= wideDestinationBits && address.Start.Is4():
+		return fmt.Sprintf("Destination covers a /%d or wider", bits)
+	}
+	return ""
+}
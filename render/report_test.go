@@ -0,0 +1,98 @@
+package render_test
+
+import (
+	"bytes"
+	"github.com/FidelityInternational/virgil/render"
+	"github.com/FidelityInternational/virgil/utility"
+	"github.com/cloudfoundry/go-cfclient/v3/resource"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"time"
+)
+
+var _ = Describe("Reports", func() {
+	var (
+		rules    utility.FirewallRules
+		metadata render.Metadata
+	)
+
+	BeforeEach(func() {
+		rules = testFirewallRules()
+		rules.FirewallRules = append(rules.FirewallRules, utility.FirewallRule{Protocol: "all", Destination: []string{"0.0.0.0/0"}, Source: []string{"10.0.16.1"}})
+		metadata = render.Metadata{
+			GeneratedAt:         time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+			Foundation:          "sys.example.com",
+			Deployments:         []string{"cf-abc"},
+			TotalSecurityGroups: 3,
+			SecurityGroups: []resource.SecurityGroup{
+				{Name: "public", Rules: []resource.SecurityGroupRule{{Protocol: "all", Destination: "0.0.0.0/0"}}},
+				{Name: "apps", Rules: []resource.SecurityGroupRule{{Protocol: "tcp", Ports: utility.StringPtr("8080-8090"), Destination: "192.168.1.10"}}},
+			},
+			SkippedRules: []utility.SkippedRule{
+				{SecurityGroup: "broken", Rule: resource.SecurityGroupRule{Protocol: "tcp", Destination: "1.1.1.1"}, Reason: "Rule has no ports"},
+			},
+		}
+	})
+
+	Describe("#NewReportData", func() {
+		It("summarises the firewall rules", func() {
+			data := render.NewReportData(rules, metadata)
+			Expect(data.RuleCount).To(Equal(5))
+			Expect(data.SourceCount).To(Equal(2))
+			Expect(data.DestinationCount).To(Equal(5))
+			Expect(data.UsedSecurityGroups).To(Equal(2))
+			Expect(data.Protocols).To(HaveLen(3))
+			Expect(data.Protocols[0].Protocol).To(Equal("all"))
+			Expect(data.Protocols[1].Protocol).To(Equal("tcp"))
+			Expect(data.Protocols[1].Rules[1].SecurityGroups).To(Equal([]string{"apps"}))
+			Expect(data.SkippedRuleWarnings).To(Equal([]string{"broken: tcp rule to 1.1.1.1 skipped - Rule has no ports"}))
+		})
+
+		It("highlights wide destinations", func() {
+			data := render.NewReportData(rules, metadata)
+			Expect(data.Risks).To(Equal([]render.ReportRisk{
+				{Protocol: "tcp", Port: "443", Destination: "10.1.0.0/16", Reason: "Destination covers a /16 or wider"},
+				{Protocol: "all", Destination: "172.16.0.0/12", Reason: "Destination covers a /12 or wider"},
+				{Protocol: "all", Destination: "0.0.0.0/0", Reason: "Destination allows any address", SecurityGroups: []string{"public"}},
+			}))
+		})
+	})
+
+	Describe("#MarkdownReport", func() {
+		It("writes a Markdown report", func() {
+			var buffer bytes.Buffer
+			Expect(render.MarkdownReport(&buffer, rules, metadata, render.ReportOptions{})).To(Succeed())
+			output := buffer.String()
+			Expect(output).To(HavePrefix("# Firewall Policy Report - sys.example.com\n\nGenerated 2024-01-02 03:04:05 UTC from cf-abc.\n"))
+			Expect(output).To(ContainSubstring("| Firewall rules | 5 |\n"))
+			Expect(output).To(ContainSubstring("## tcp\n"))
+			Expect(output).To(ContainSubstring("| 8080-8082 | 192.168.1.10 | 10.0.16.1<br>10.0.16.2 | apps |\n"))
+			Expect(output).To(ContainSubstring("| all | any | 0.0.0.0/0 | Destination allows any address | public |\n"))
+			Expect(output).To(ContainSubstring("- broken: tcp rule to 1.1.1.1 skipped - Rule has no ports\n"))
+		})
+
+		It("uses an overriding template", func() {
+			var buffer bytes.Buffer
+			Expect(render.MarkdownReport(&buffer, rules, metadata, render.ReportOptions{Template: "{{ .Foundation }} has {{ .RuleCount }} rules"})).To(Succeed())
+			Expect(buffer.String()).To(Equal("sys.example.com has 5 rules"))
+		})
+	})
+
+	Describe("#HTMLReport", func() {
+		It("writes an HTML report", func() {
+			var buffer bytes.Buffer
+			Expect(render.HTMLReport(&buffer, rules, metadata, render.ReportOptions{})).To(Succeed())
+			output := buffer.String()
+			Expect(output).To(HavePrefix("<!DOCTYPE html>"))
+			Expect(output).To(ContainSubstring("<h1>Firewall Policy Report - sys.example.com</h1>"))
+			Expect(output).To(ContainSubstring("<tr><th>Firewall rules</th><td>5</td></tr>"))
+			Expect(output).To(ContainSubstring(`<tr class="risk"><td>all</td><td>any</td><td>0.0.0.0/0</td><td>Destination allows any address</td><td>public</td></tr>`))
+			Expect(output).To(ContainSubstring("<li>broken: tcp rule to 1.1.1.1 skipped - Rule has no ports</li>"))
+		})
+
+		It("returns template errors", func() {
+			var buffer bytes.Buffer
+			Expect(render.HTMLReport(&buffer, rules, metadata, render.ReportOptions{Template: "{{ .Missing"})).ToNot(Succeed())
+		})
+	})
+})
@@ -0,0 +1,104 @@
+package render
+
+import (
+	"fmt"
+	"github.com/FidelityInternational/virgil/utility"
+	"io"
+	"strings"
+)
+
+// srxMaxNameLength - Junos address, application and policy names are limited to 63 characters
+const srxMaxNameLength = 63
+
+// SRXOptions - settings for the Juniper SRX renderer, empty values fall back to sensible defaults
+type SRXOptions struct {
+	Prefix      string
+	AddressBook string
+	FromZone    string
+	ToZone      string
+}
+
+// JuniperSRX - writes the firewall rules as Junos "set" statements defining address-book entries,
+// deduplicated applications and a security policy per compressed port range
+func JuniperSRX(w io.Writer, firewallRules utility.FirewallRules, options SRXOptions) error {
+	prefix := defaultString(options.Prefix, "virgil")
+	addressBook := fmt.Sprintf("set security address-book %s", defaultString(options.AddressBook, "global"))
+	policies := fmt.Sprintf("set security policies from-zone %s to-zone %s", defaultString(options.FromZone, "trust"), defaultString(options.ToZone, "untrust"))
+	groups := newGroupNamer(prefix, srxMaxNameLength)
+	var addresses, addressSets, applications, policyLines []string
+	addressNames := make(map[string]string)
+	seenApplications := make(map[string]bool)
+	seenPolicies := make(map[string]bool)
+
+	// addressSet returns the address-book name for a set of addresses, defining entries when first seen
+	addressSet := func(values []string, kind string) (string, error) {
+		var members []string
+		for _, value := range uniqueAddresses(values) {
+			address, err := ParseAddress(value)
+			if err != nil {
+				return "", err
+			}
+			name, ok := addressNames[address.String()]
+			if !ok {
+				name = SanitiseName(fmt.Sprintf("%s-%s", prefix, address), srxMaxNameLength)
+				if address.Kind == Range {
+					addresses = append(addresses, fmt.Sprintf("%s address %s range-address %s to %s", addressBook, name, address.Start, address.End))
+				} else {
+					addresses = append(addresses, fmt.Sprintf("%s address %s %s", addressBook, name, address.CIDR()))
+				}
+				addressNames[address.String()] = name
+			}
+			members = append(members, name)
+		}
+		utility.RemoveDuplicates(&members)
+		if len(members) == 1 {
+			return members[0], nil
+		}
+		name, created := groups.name(kind, members)
+		if created {
+			for _, member := range members {
+				addressSets = append(addressSets, fmt.Sprintf("%s address-set %s address %s", addressBook, name, member))
+			}
+		}
+		return name, nil
+	}
+
+	for _, rule := range firewallRules.FirewallRules {
+		protocol := strings.ToLower(rule.Protocol)
+		sources, err := addressSet(rule.Source, "sources")
+		if err != nil {
+			return err
+		}
+		destinations, err := addressSet(rule.Destination, "dst")
+		if err != nil {
+			return err
+		}
+		application := "any"
+		policy := SanitiseName(fmt.Sprintf("%s-all-%s", prefix, ShortHash(destinations)), srxMaxNameLength)
+		if protocol != "all" {
+			if _, _, err := SplitPortRange(rule.Port); err != nil {
+				return err
+			}
+			application = SanitiseName(fmt.Sprintf("%s-%s-%s", prefix, protocol, rule.Port), srxMaxNameLength)
+			policy = application
+			if !seenApplications[application] {
+				applications = append(applications, fmt.Sprintf("set applications application %s protocol %s destination-port %s", application, protocol, rule.Port))
+				seenApplications[application] = true
+			}
+		}
+		policy = UniqueName(policy, srxMaxNameLength, seenPolicies)
+		policyLines = append(policyLines,
+			fmt.Sprintf("%s policy %s match source-address %s", policies, policy, sources),
+			fmt.Sprintf("%s policy %s match destination-address %s", policies, policy, destinations),
+			fmt.Sprintf("%s policy %s match application %s", policies, policy, application),
+			fmt.Sprintf("%s policy %s then permit", policies, policy),
+		)
+	}
+
+	var lines []string
+	for _, section := range [][]string{addresses, addressSets, applications, policyLines} {
+		lines = append(lines, section...)
+	}
+	_, err := fmt.Fprintln(w, strings.Join(lines, "\n"))
+	return err
+}
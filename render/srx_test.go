@@ -0,0 +1,40 @@
+package render_test
+
+import (
+	"bytes"
+	"github.com/FidelityInternational/virgil/render"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"strings"
+)
+
+var _ = Describe("#JuniperSRX", func() {
+	var output string
+
+	BeforeEach(func() {
+		var buffer bytes.Buffer
+		Expect(render.JuniperSRX(&buffer, testFirewallRules(), render.SRXOptions{FromZone: "cf", ToZone: "dc"})).To(Succeed())
+		output = buffer.String()
+	})
+
+	It("defines address-book entries and address-sets", func() {
+		Expect(output).To(ContainSubstring("set security address-book global address virgil-10.0.16.1 10.0.16.1/32\n"))
+		Expect(output).To(ContainSubstring("set security address-book global address virgil-10.2.0.1-10.2.0.9 range-address 10.2.0.1 to 10.2.0.9\n"))
+		Expect(output).To(ContainSubstring("set security address-book global address-set virgil-sources address virgil-10.0.16.1\n"))
+		Expect(output).To(ContainSubstring("set security address-book global address-set virgil-sources address virgil-10.0.16.2\n"))
+	})
+
+	It("defines each application once", func() {
+		Expect(output).To(ContainSubstring("set applications application virgil-tcp-8080-8082 protocol tcp destination-port 8080-8082\n"))
+		Expect(strings.Count(output, "set applications application virgil-udp-53 ")).To(Equal(1))
+	})
+
+	It("writes a policy per compressed port range in the configured zones", func() {
+		Expect(output).To(ContainSubstring("set security policies from-zone cf to-zone dc policy virgil-tcp-8080-8082 match source-address virgil-sources\n"))
+		Expect(output).To(ContainSubstring("set security policies from-zone cf to-zone dc policy virgil-tcp-8080-8082 match destination-address virgil-192.168.1.10\n"))
+		Expect(output).To(ContainSubstring("set security policies from-zone cf to-zone dc policy virgil-tcp-8080-8082 match application virgil-tcp-8080-8082\n"))
+		Expect(output).To(ContainSubstring("set security policies from-zone cf to-zone dc policy virgil-tcp-8080-8082 then permit\n"))
+		Expect(output).To(MatchRegexp(`policy virgil-tcp-443 match destination-address virgil-dst-[0-9a-f]{8}\n`))
+		Expect(output).To(MatchRegexp(`policy virgil-all-[0-9a-f]{8} match application any\n`))
+	})
+})
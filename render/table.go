@@ -0,0 +1,149 @@
+package render
+
+import (
+	"encoding/csv"
+	"fmt"
+	"github.com/FidelityInternational/virgil/utility"
+	"github.com/cloudfoundry/go-cfclient/v3/resource"
+	"github.com/xuri/excelize/v2"
+	"io"
+	"strings"
+)
+
+// TableColumns - the columns available to the CSV and XLSX renderers, in their default order
+var TableColumns = []string{"protocol", "port", "source", "destination", "security_groups"}
+
+// TableOptions - settings for the CSV and XLSX renderers. Explode writes a row per source, destination and port
+// instead of one per firewall rule, SecurityGroups are used to fill in the security_groups provenance column
+type TableOptions struct {
+	Explode        bool
+	Columns        []string
+	SecurityGroups []resource.SecurityGroup
+}
+
+// CSV - writes the firewall rules as comma separated values with a header row
+func CSV(w io.Writer, firewallRules utility.FirewallRules, options TableOptions) error {
+	rows, err := tableRows(firewallRules, options, 0)
+	if err != nil {
+		return err
+	}
+	writer := csv.NewWriter(w)
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// XLSX - writes the firewall rules as an Excel workbook with a single "Firewall Rules" sheet. Exploded rules that
+// need more rows than a sheet holds return an error before the workbook is written
+func XLSX(w io.Writer, firewallRules utility.FirewallRules, options TableOptions) error {
+	rows, err := tableRows(firewallRules, options, excelize.TotalRows)
+	if err != nil {
+		return err
+	}
+	workbook := excelize.NewFile()
+	defer workbook.Close()
+	const sheet = "Firewall Rules"
+	if err := workbook.SetSheetName("Sheet1", sheet); err != nil {
+		return err
+	}
+	for i, row := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return err
+		}
+		values := make([]interface{}, len(row))
+		for j, value := range row {
+			values[j] = value
+		}
+		if err := workbook.SetSheetRow(sheet, cell, &values); err != nil {
+			return err
+		}
+	}
+	if err := workbook.SetPanes(sheet, &excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}); err != nil {
+		return err
+	}
+	lastColumn, err := excelize.ColumnNumberToName(len(rows[0]))
+	if err != nil {
+		return err
+	}
+	if err := workbook.AutoFilter(sheet, fmt.Sprintf("A1:%s%d", lastColumn, len(rows)), nil); err != nil {
+		return err
+	}
+	_, err = workbook.WriteTo(w)
+	return err
+}
+
+// tableRows - returns the header and rows of the table, maxRows limits the rows of exploded rules when above zero
+func tableRows(firewallRules utility.FirewallRules, options TableOptions, maxRows int) ([][]string, error) {
+	columns := options.Columns
+	if len(columns) == 0 {
+		columns = TableColumns
+	}
+	for _, column := range columns {
+		if !containsColumn(column) {
+			return nil, fmt.Errorf("Column %s is not supported, valid columns are %s", column, strings.Join(TableColumns, ","))
+		}
+	}
+	rows := [][]string{columns}
+	appendRow := func(values map[string]string) {
+		var row []string
+		for _, column := range columns {
+			row = append(row, values[column])
+		}
+		rows = append(rows, row)
+	}
+	if options.Explode {
+		tuples := utility.ExplodeFirewallRules(firewallRules)
+		expandedPorts := make(map[string][]string)
+		total := len(rows)
+		for _, tuple := range tuples {
+			if _, ok := expandedPorts[tuple.Port]; !ok {
+				expandedPorts[tuple.Port] = []string{tuple.Port}
+				if expanded, err := utility.PortExpand(tuple.Port); tuple.Port != "" && err == nil {
+					expandedPorts[tuple.Port] = *expanded
+				}
+			}
+			total += len(expandedPorts[tuple.Port])
+		}
+		if maxRows > 0 && total > maxRows {
+			return nil, fmt.Errorf("Exploding the firewall rules needs %d rows, more than the %d a sheet holds, write them without --table-explode or as CSV", total, maxRows)
+		}
+		for _, tuple := range tuples {
+			for _, port := range expandedPorts[tuple.Port] {
+				appendRow(map[string]string{
+					"protocol":        tuple.Protocol,
+					"port":            port,
+					"source":          tuple.Source,
+					"destination":     tuple.Destination,
+					"security_groups": strings.Join(utility.GetProvenance(tuple.Protocol, tuple.Destination, port, options.SecurityGroups), "\n"),
+				})
+			}
+		}
+		return rows, nil
+	}
+	for _, rule := range firewallRules.FirewallRules {
+		var provenance []string
+		for _, destination := range rule.Destination {
+			provenance = append(provenance, utility.GetProvenance(rule.Protocol, destination, rule.Port, options.SecurityGroups)...)
+		}
+		utility.RemoveDuplicates(&provenance)
+		appendRow(map[string]string{
+			"protocol":        rule.Protocol,
+			"port":            rule.Port,
+			"source":          strings.Join(rule.Source, "\n"),
+			"destination":     strings.Join(rule.Destination, "\n"),
+			"security_groups": strings.Join(provenance, "\n"),
+		})
+	}
+	return rows, nil
+}
+
+func containsColumn(column string) bool {
+	for _, c := range TableColumns {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
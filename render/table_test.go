@@ -0,0 +1,95 @@
+package render_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"github.com/FidelityInternational/virgil/render"
+	"github.com/FidelityInternational/virgil/utility"
+	"github.com/cloudfoundry/go-cfclient/v3/resource"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/xuri/excelize/v2"
+)
+
+var _ = Describe("Table renderers", func() {
+	var (
+		rules     utility.FirewallRules
+		secGroups []resource.SecurityGroup
+	)
+
+	BeforeEach(func() {
+		rules = utility.FirewallRules{
+			SchemaVersion: "1",
+			FirewallRules: []utility.FirewallRule{
+				{Port: "443", Protocol: "tcp", Destination: []string{"10.1.0.0/16", "192.168.1.10"}, Source: []string{"10.0.16.1", "10.0.16.2"}},
+			},
+		}
+		secGroups = []resource.SecurityGroup{
+			{Name: "internal", Rules: []resource.SecurityGroupRule{{Protocol: "tcp", Ports: utility.StringPtr("443"), Destination: "10.1.0.0/16"}}},
+			{Name: "proxy", Rules: []resource.SecurityGroupRule{{Protocol: "tcp", Ports: utility.StringPtr("80,443"), Destination: "192.168.1.10"}}},
+		}
+	})
+
+	Describe("#CSV", func() {
+		It("writes one row per firewall rule with provenance", func() {
+			var buffer bytes.Buffer
+			Expect(render.CSV(&buffer, rules, render.TableOptions{SecurityGroups: secGroups})).To(Succeed())
+			records, err := csv.NewReader(&buffer).ReadAll()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(records).To(Equal([][]string{
+				{"protocol", "port", "source", "destination", "security_groups"},
+				{"tcp", "443", "10.0.16.1\n10.0.16.2", "10.1.0.0/16\n192.168.1.10", "internal\nproxy"},
+			}))
+		})
+
+		It("writes one row per tuple when exploded, with the selected columns", func() {
+			var buffer bytes.Buffer
+			options := render.TableOptions{Explode: true, Columns: []string{"source", "destination", "security_groups"}, SecurityGroups: secGroups}
+			Expect(render.CSV(&buffer, rules, options)).To(Succeed())
+			records, err := csv.NewReader(&buffer).ReadAll()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(records).To(Equal([][]string{
+				{"source", "destination", "security_groups"},
+				{"10.0.16.1", "10.1.0.0/16", "internal"},
+				{"10.0.16.1", "192.168.1.10", "proxy"},
+				{"10.0.16.2", "10.1.0.0/16", "internal"},
+				{"10.0.16.2", "192.168.1.10", "proxy"},
+			}))
+		})
+
+		It("writes a row per port of port ranges when exploded", func() {
+			rules.FirewallRules = append(rules.FirewallRules, utility.FirewallRule{Port: "8080-8082", Protocol: "tcp", Destination: []string{"10.2.0.1"}, Source: []string{"10.0.16.1"}})
+			var buffer bytes.Buffer
+			Expect(render.CSV(&buffer, rules, render.TableOptions{Explode: true, Columns: []string{"port", "destination"}})).To(Succeed())
+			records, err := csv.NewReader(&buffer).ReadAll()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(records[5:]).To(Equal([][]string{{"8080", "10.2.0.1"}, {"8081", "10.2.0.1"}, {"8082", "10.2.0.1"}}))
+		})
+
+		It("returns an error for unknown columns", func() {
+			var buffer bytes.Buffer
+			Expect(render.CSV(&buffer, rules, render.TableOptions{Columns: []string{"zone"}})).To(MatchError("Column zone is not supported, valid columns are protocol,port,source,destination,security_groups"))
+		})
+	})
+
+	Describe("#XLSX", func() {
+		It("writes a workbook with a Firewall Rules sheet", func() {
+			var buffer bytes.Buffer
+			Expect(render.XLSX(&buffer, rules, render.TableOptions{Explode: true, SecurityGroups: secGroups})).To(Succeed())
+			workbook, err := excelize.OpenReader(&buffer)
+			Expect(err).ToNot(HaveOccurred())
+			rows, err := workbook.GetRows("Firewall Rules")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rows).To(HaveLen(5))
+			Expect(rows[0]).To(Equal([]string{"protocol", "port", "source", "destination", "security_groups"}))
+			Expect(rows[4]).To(Equal([]string{"tcp", "443", "10.0.16.2", "192.168.1.10", "proxy"}))
+		})
+
+		It("returns an error when exploded rules need more rows than a sheet holds", func() {
+			rules.FirewallRules = []utility.FirewallRule{{Port: "1-65535", Protocol: "tcp", Destination: []string{"10.2.0.1", "10.2.0.2", "10.2.0.3", "10.2.0.4"}, Source: []string{"10.0.16.1", "10.0.16.2", "10.0.16.3", "10.0.16.4", "10.0.16.5"}}}
+			var buffer bytes.Buffer
+			Expect(render.XLSX(&buffer, rules, render.TableOptions{Explode: true})).To(MatchError("Exploding the firewall rules needs 1310701 rows, more than the 1048576 a sheet holds, write them without --table-explode or as CSV"))
+			Expect(buffer.Len()).To(BeZero())
+		})
+	})
+})
@@ -0,0 +1,107 @@
+package render
+
+import (
+	"github.com/FidelityInternational/virgil/utility"
+	"io"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+)
+
+// TemplateData - everything available to a user supplied template
+type TemplateData struct {
+	Metadata
+	SchemaVersion string
+	FirewallRules []utility.FirewallRule
+}
+
+// TemplateFuncs - helper functions available to user supplied and report templates
+func TemplateFuncs() texttemplate.FuncMap {
+	return texttemplate.FuncMap{
+		"join":  strings.Join,
+		"lower": strings.ToLower,
+		"upper": strings.ToUpper,
+		"replace": func(old, new, s string) string {
+			return strings.ReplaceAll(s, old, new)
+		},
+		"cidrToMask": func(cidr string) (string, error) {
+			network, mask, err := CIDRToMask(cidr)
+			if err != nil {
+				return "", err
+			}
+			return network + " " + mask, nil
+		},
+		"cidrNetwork": func(cidr string) (string, error) {
+			network, _, err := CIDRToMask(cidr)
+			return network, err
+		},
+		"cidrMask": func(cidr string) (string, error) {
+			_, mask, err := CIDRToMask(cidr)
+			return mask, err
+		},
+		"cidrs": func(address string) ([]string, error) {
+			parsed, err := ParseAddress(address)
+			if err != nil {
+				return nil, err
+			}
+			return parsed.CIDRs(), nil
+		},
+		"addressKind": func(address string) (string, error) {
+			parsed, err := ParseAddress(address)
+			if err != nil {
+				return "", err
+			}
+			return [...]string{"host", "network", "range"}[parsed.Kind], nil
+		},
+		"rangeStart": func(address string) (string, error) {
+			parsed, err := ParseAddress(address)
+			return parsed.Start.String(), err
+		},
+		"rangeEnd": func(address string) (string, error) {
+			parsed, err := ParseAddress(address)
+			return parsed.End.String(), err
+		},
+		"portStart": func(port string) (int, error) {
+			start, _, err := SplitPortRange(port)
+			return start, err
+		},
+		"portEnd": func(port string) (int, error) {
+			_, end, err := SplitPortRange(port)
+			return end, err
+		},
+		"isPortRange": func(port string) bool {
+			start, end, err := SplitPortRange(port)
+			return err == nil && start != end
+		},
+		"sortAddresses": func(addresses []string) []string {
+			sorted := append([]string{}, addresses...)
+			SortAddresses(sorted)
+			return sorted
+		},
+		"sortStrings": func(values []string) []string {
+			sorted := append([]string{}, values...)
+			sort.Strings(sorted)
+			return sorted
+		},
+		"uniq": func(values []string) []string {
+			unique := append([]string{}, values...)
+			utility.RemoveDuplicates(&unique)
+			return unique
+		},
+		"sanitise":  SanitiseName,
+		"shortHash": ShortHash,
+	}
+}
+
+// CustomTemplate - executes a user supplied text/template over the firewall rules and run metadata
+func CustomTemplate(w io.Writer, firewallRules utility.FirewallRules, metadata Metadata, source string) error {
+	tmpl, err := texttemplate.New("template").Funcs(TemplateFuncs()).Parse(source)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, TemplateData{
+		Metadata:      metadata,
+		SchemaVersion: firewallRules.SchemaVersion,
+		FirewallRules: firewallRules.FirewallRules,
+	})
+}
@@ -0,0 +1,46 @@
+package render_test
+
+import (
+	"bytes"
+	"github.com/FidelityInternational/virgil/render"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"time"
+)
+
+var _ = Describe("#CustomTemplate", func() {
+	var metadata = render.Metadata{
+		GeneratedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Foundation:  "sys.example.com",
+		Deployments: []string{"cf-abc"},
+	}
+
+	execute := func(source string) (string, error) {
+		var buffer bytes.Buffer
+		err := render.CustomTemplate(&buffer, testFirewallRules(), metadata, source)
+		return buffer.String(), err
+	}
+
+	It("executes the template over the firewall rules and metadata", func() {
+		output, err := execute(`{{ .Foundation }} {{ join .Deployments "," }} {{ .GeneratedAt.Year }} v{{ .SchemaVersion }} {{ len .FirewallRules }}`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output).To(Equal("sys.example.com cf-abc 2024 v1 4"))
+	})
+
+	It("provides address helpers", func() {
+		output, err := execute(`{{ cidrToMask "10.1.0.0/16" }}|{{ cidrNetwork "10.1.2.3" }}|{{ cidrMask "10.0.0.0/8" }}|{{ addressKind "10.2.0.1-10.2.0.9" }}|{{ rangeStart "10.2.0.1-10.2.0.9" }}|{{ rangeEnd "10.2.0.1-10.2.0.9" }}|{{ join (cidrs "10.0.0.1-10.0.0.3") "," }}`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output).To(Equal("10.1.0.0 255.255.0.0|10.1.2.3|255.0.0.0|range|10.2.0.1|10.2.0.9|10.0.0.1/32,10.0.0.2/31"))
+	})
+
+	It("provides port, sorting and naming helpers", func() {
+		output, err := execute(`{{ range .FirewallRules }}{{ if isPortRange .Port }}{{ portStart .Port }}:{{ portEnd .Port }} {{ end }}{{ end }}|{{ join (sortAddresses (index .FirewallRules 0).Destination) "," }}|{{ sanitise "a b/c" 0 }}|{{ upper "tcp" }}|{{ len (shortHash "x") }}|{{ join (sortStrings (uniq (index .FirewallRules 0).Source)) "," }}`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output).To(Equal("8080:8082 |10.1.0.0/16,192.168.1.10|a_b_c|TCP|8|10.0.16.1,10.0.16.2"))
+	})
+
+	It("returns an error when a helper fails", func() {
+		_, err := execute(`{{ cidrToMask "10.0.0.1-10.0.0.2" }}`)
+		Expect(err).To(MatchError(ContainSubstring("Address 10.0.0.1-10.0.0.2 is a range and has no mask")))
+	})
+})
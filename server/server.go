@@ -0,0 +1,221 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/FidelityInternational/virgil"
+	"github.com/FidelityInternational/virgil/render"
+	"github.com/FidelityInternational/virgil/utility"
+	"mime"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Server - serves the last successfully generated policy over HTTP, regenerating it on an interval
+// so a failing CF or BOSH API leaves the previous policy in place rather than blanking it
+type Server struct {
+	generator   *virgil.Generator
+	interval    time.Duration
+	mux         *http.ServeMux
+	mutex       sync.RWMutex
+	result      *virgil.Result
+	lastAttempt time.Time
+	lastSuccess time.Time
+	lastError   error
+}
+
+// Health - the body of the /healthz endpoint
+type Health struct {
+	Status      string     `json:"status"`
+	LastAttempt *time.Time `json:"last_attempt,omitempty"`
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+}
+
+// Metadata - the body of the /metadata endpoint
+type Metadata struct {
+	GeneratedAt         time.Time             `json:"generated_at"`
+	Foundation          string                `json:"foundation,omitempty"`
+	Deployments         []string              `json:"deployments"`
+	TotalSecurityGroups int                   `json:"total_security_groups"`
+	SecurityGroups      []string              `json:"security_groups"`
+	Sources             int                   `json:"sources"`
+	FirewallRules       int                   `json:"firewall_rules"`
+	SkippedRules        []utility.SkippedRule `json:"skipped_rules"`
+	Formats             []string              `json:"formats"`
+}
+
+// New - returns a Server regenerating the policy with generator every interval
+func New(generator *virgil.Generator, interval time.Duration) *Server {
+	s := &Server{generator: generator, interval: interval, mux: http.NewServeMux()}
+	s.mux.HandleFunc("/policy", s.policy)
+	s.mux.HandleFunc("/healthz", s.health)
+	s.mux.HandleFunc("/metadata", s.metadata)
+	return s
+}
+
+// Handle - adds a handler to the server, used to expose extra endpoints alongside the policy
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// Refresh - regenerates the policy, keeping the previous result if generation fails
+func (s *Server) Refresh(ctx context.Context) error {
+	result, err := s.generator.Generate(ctx)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.lastAttempt = time.Now().UTC()
+	s.lastError = err
+	if err != nil {
+		return err
+	}
+	s.result = &result
+	s.lastSuccess = s.lastAttempt
+	return nil
+}
+
+// Run - refreshes the policy immediately and then every interval until ctx is done, errors are
+// passed to onError when it is not nil
+func (s *Server) Run(ctx context.Context, onError func(error)) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if err := s.Refresh(ctx); err != nil && onError != nil && ctx.Err() == nil {
+			onError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ListenAndServe - runs the refresh loop and serves HTTP on addr until ctx is done
+func (s *Server) ListenAndServe(ctx context.Context, addr string, onError func(error)) error {
+	httpServer := &http.Server{Addr: addr, Handler: s}
+	go s.Run(ctx, onError)
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdownCtx)
+	}()
+	if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// ServeHTTP - serves the policy, health and metadata endpoints
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) current() *virgil.Result {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.result
+}
+
+func (s *Server) policy(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "yaml"
+	}
+	renderer, err := render.Get(format)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	result := s.current()
+	if result == nil {
+		http.Error(w, "No policy has been generated yet", http.StatusServiceUnavailable)
+		return
+	}
+	var output bytes.Buffer
+	if err := renderer.Render(&output, result.FirewallRules, result.Metadata); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType(renderer.FileExtension()))
+	w.Header().Set("Last-Modified", result.Metadata.GeneratedAt.Format(http.TimeFormat))
+	output.WriteTo(w)
+}
+
+func (s *Server) health(w http.ResponseWriter, r *http.Request) {
+	s.mutex.RLock()
+	health := Health{Status: "ok"}
+	if !s.lastAttempt.IsZero() {
+		lastAttempt := s.lastAttempt
+		health.LastAttempt = &lastAttempt
+	}
+	if !s.lastSuccess.IsZero() {
+		lastSuccess := s.lastSuccess
+		health.LastSuccess = &lastSuccess
+	}
+	if s.lastError != nil {
+		health.Status = "stale"
+		health.LastError = s.lastError.Error()
+	}
+	status := http.StatusOK
+	if s.result == nil {
+		health.Status = "unavailable"
+		status = http.StatusServiceUnavailable
+	}
+	s.mutex.RUnlock()
+	writeJSON(w, status, health)
+}
+
+func (s *Server) metadata(w http.ResponseWriter, r *http.Request) {
+	result := s.current()
+	if result == nil {
+		http.Error(w, "No policy has been generated yet", http.StatusServiceUnavailable)
+		return
+	}
+	metadata := Metadata{
+		GeneratedAt:         result.Metadata.GeneratedAt,
+		Foundation:          result.Metadata.Foundation,
+		Deployments:         result.Metadata.Deployments,
+		TotalSecurityGroups: result.Metadata.TotalSecurityGroups,
+		SecurityGroups:      []string{},
+		Sources:             len(result.Sources),
+		FirewallRules:       len(result.FirewallRules.FirewallRules),
+		SkippedRules:        result.Metadata.SkippedRules,
+		Formats:             render.Names(),
+	}
+	for _, secGroup := range result.Metadata.SecurityGroups {
+		metadata.SecurityGroups = append(metadata.SecurityGroups, secGroup.Name)
+	}
+	if metadata.SkippedRules == nil {
+		metadata.SkippedRules = []utility.SkippedRule{}
+	}
+	writeJSON(w, http.StatusOK, metadata)
+}
+
+// contentType - returns the media type for a renderer's file extension, falling back to plain text
+func contentType(extension string) string {
+	switch extension {
+	case "yml", "yaml":
+		return "application/yaml"
+	case "md":
+		return "text/markdown; charset=utf-8"
+	}
+	if mediaType := mime.TypeByExtension(fmt.Sprintf(".%s", extension)); mediaType != "" {
+		return mediaType
+	}
+	return "text/plain; charset=utf-8"
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,160 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/FidelityInternational/virgil"
+	"github.com/FidelityInternational/virgil/fakes"
+	"github.com/FidelityInternational/virgil/render"
+	"github.com/FidelityInternational/virgil/server"
+	"github.com/FidelityInternational/virgil/utility"
+	"github.com/cloudfoundry-community/gogobosh"
+	"github.com/cloudfoundry/go-cfclient/v3/resource"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+type failingRenderer struct{}
+
+func (failingRenderer) Name() string          { return "failing" }
+func (failingRenderer) FileExtension() string { return "txt" }
+func (failingRenderer) Render(w io.Writer, firewallRules utility.FirewallRules, metadata render.Metadata) error {
+	io.WriteString(w, "partial policy")
+	return errors.New("Rendering failed")
+}
+
+var _ = Describe("Server", func() {
+	var (
+		secGroups *fakes.SecurityGroupSource
+		cells     *fakes.CellSource
+		srv       *server.Server
+	)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		srv.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		return recorder
+	}
+
+	BeforeEach(func() {
+		secGroups = &fakes.SecurityGroupSource{SecGroups: []resource.SecurityGroup{
+			fakes.SecurityGroup("web", fakes.Rule("tcp", "10.1.0.0/16", "443")),
+			fakes.SecurityGroup("ping", fakes.Rule("icmp", "10.3.0.1", "")),
+		}}
+		cells = fakes.NewCellSource("cf-123", gogobosh.VM{JobName: "diego_cell", IPs: []string{"10.0.16.1"}})
+		srv = server.New(virgil.NewGenerator(secGroups, cells, virgil.WithFoundation("sys.example.com")), time.Minute)
+	})
+
+	Context("before a policy has been generated", func() {
+		It("reports unavailable", func() {
+			Expect(get("/healthz").Code).To(Equal(http.StatusServiceUnavailable))
+			Expect(get("/policy").Code).To(Equal(http.StatusServiceUnavailable))
+			Expect(get("/metadata").Code).To(Equal(http.StatusServiceUnavailable))
+		})
+	})
+
+	Context("after a successful refresh", func() {
+		BeforeEach(func() {
+			Expect(srv.Refresh(context.Background())).To(Succeed())
+		})
+
+		It("serves the policy as YAML by default", func() {
+			response := get("/policy")
+			Expect(response.Code).To(Equal(http.StatusOK))
+			Expect(response.Header().Get("Content-Type")).To(Equal("application/yaml"))
+			Expect(response.Body.String()).To(ContainSubstring("- 10.1.0.0/16"))
+		})
+
+		It("serves the policy in the requested format", func() {
+			response := get("/policy?format=json")
+			Expect(response.Code).To(Equal(http.StatusOK))
+			Expect(response.Header().Get("Content-Type")).To(Equal("application/json"))
+			Expect(response.Body.String()).To(ContainSubstring(`"schema_version": "1"`))
+		})
+
+		It("rejects unknown formats", func() {
+			response := get("/policy?format=pf")
+			Expect(response.Code).To(Equal(http.StatusBadRequest))
+			Expect(response.Body.String()).To(ContainSubstring("Output format pf is not supported"))
+		})
+
+		It("does not write a partial policy when rendering fails", func() {
+			render.Register(failingRenderer{})
+			response := get("/policy?format=failing")
+			Expect(response.Code).To(Equal(http.StatusInternalServerError))
+			Expect(response.Header().Get("Content-Type")).To(HavePrefix("text/plain"))
+			Expect(response.Body.String()).To(Equal("Rendering failed\n"))
+		})
+
+		It("reports healthy", func() {
+			response := get("/healthz")
+			Expect(response.Code).To(Equal(http.StatusOK))
+			var health server.Health
+			Expect(json.Unmarshal(response.Body.Bytes(), &health)).To(Succeed())
+			Expect(health.Status).To(Equal("ok"))
+			Expect(health.LastSuccess).ToNot(BeNil())
+		})
+
+		It("serves metadata", func() {
+			response := get("/metadata")
+			Expect(response.Code).To(Equal(http.StatusOK))
+			var metadata server.Metadata
+			Expect(json.Unmarshal(response.Body.Bytes(), &metadata)).To(Succeed())
+			Expect(metadata.Foundation).To(Equal("sys.example.com"))
+			Expect(metadata.Deployments).To(Equal([]string{"cf-123"}))
+			Expect(metadata.SecurityGroups).To(Equal([]string{"web", "ping"}))
+			Expect(metadata.Sources).To(Equal(1))
+			Expect(metadata.FirewallRules).To(Equal(1))
+			Expect(metadata.SkippedRules).To(HaveLen(1))
+			Expect(metadata.Formats).To(ContainElement("yaml"))
+		})
+
+		It("keeps serving the last good policy when a refresh fails", func() {
+			secGroups.Err = errors.New("CF unavailable")
+			Expect(srv.Refresh(context.Background())).To(MatchError("CF unavailable"))
+			response := get("/policy")
+			Expect(response.Code).To(Equal(http.StatusOK))
+			Expect(response.Body.String()).To(ContainSubstring("- 10.1.0.0/16"))
+			var health server.Health
+			response = get("/healthz")
+			Expect(response.Code).To(Equal(http.StatusOK))
+			Expect(json.Unmarshal(response.Body.Bytes(), &health)).To(Succeed())
+			Expect(health.Status).To(Equal("stale"))
+			Expect(health.LastError).To(Equal("CF unavailable"))
+		})
+	})
+
+	Describe("#Run", func() {
+		It("refreshes on the interval until the context is done", func() {
+			srv = server.New(virgil.NewGenerator(secGroups, cells), 10*time.Millisecond)
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			go func() {
+				srv.Run(ctx, nil)
+				close(done)
+			}()
+			Eventually(secGroups.Calls).Should(BeNumerically(">=", 3))
+			cancel()
+			Eventually(done).Should(BeClosed())
+		})
+
+		It("passes refresh errors to onError", func() {
+			secGroups.Err = errors.New("CF unavailable")
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			errs := make(chan error, 1)
+			go srv.Run(ctx, func(err error) {
+				select {
+				case errs <- err:
+				default:
+				}
+			})
+			Eventually(errs).Should(Receive(MatchError("CF unavailable")))
+		})
+	})
+})
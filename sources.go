@@ -0,0 +1,221 @@
+package virgil
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/cloudfoundry-community/gogobosh"
+	"github.com/cloudfoundry/go-cfclient/v3/client"
+	"github.com/cloudfoundry/go-cfclient/v3/resource"
+	"net/http"
+	"strings"
+)
+
+// SecurityGroupSource - provides the Cloud Foundry security groups firewall rules are generated from
+type SecurityGroupSource interface {
+	SecurityGroups(ctx context.Context) ([]resource.SecurityGroup, error)
+}
+
+// CellSource - provides the BOSH deployments and VMs used as firewall rule sources
+type CellSource interface {
+	Deployments(ctx context.Context) ([]gogobosh.Deployment, error)
+	DeploymentVMs(ctx context.Context, deployment string) ([]gogobosh.VM, error)
+}
+
+// NetworkSource - provides the BOSH deployment manifests and cloud configs subnet sources are derived from
+type NetworkSource interface {
+	DeploymentManifest(ctx context.Context, deployment string) (string, error)
+	CloudConfigs(ctx context.Context) ([]string, error)
+}
+
+// Space - a Cloud Foundry space with the name of its org and the number of apps in it, Org is empty
+// when the space's org could not be found
+type Space struct {
+	GUID string
+	Name string
+	Org  string
+	Apps int
+}
+
+// SpaceSource - provides the spaces security groups can be bound to
+type SpaceSource interface {
+	Spaces(ctx context.Context) ([]Space, error)
+}
+
+// CFSecurityGroupSource - a SecurityGroupSource backed by a go-cfclient v3 client
+type CFSecurityGroupSource struct {
+	Client *client.Client
+}
+
+// NewCFSecurityGroupSource - returns a SecurityGroupSource reading from the given CF API client
+func NewCFSecurityGroupSource(cfClient *client.Client) *CFSecurityGroupSource {
+	return &CFSecurityGroupSource{Client: cfClient}
+}
+
+// SecurityGroups - lists every security group visible to the CF client
+func (s *CFSecurityGroupSource) SecurityGroups(ctx context.Context) ([]resource.SecurityGroup, error) {
+	allSecGroups, err := s.Client.SecurityGroups.ListAll(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	secGroups := make([]resource.SecurityGroup, 0, len(allSecGroups))
+	for _, secGroup := range allSecGroups {
+		secGroups = append(secGroups, *secGroup)
+	}
+	return secGroups, nil
+}
+
+// Lifecycles - the app lifecycles security groups apply to
+var Lifecycles = []string{LifecycleRunning, LifecycleStaging}
+
+const (
+	// LifecycleRunning - security groups applied to running apps
+	LifecycleRunning = "running"
+	// LifecycleStaging - security groups applied to apps while they stage
+	LifecycleStaging = "staging"
+)
+
+// CFSpaceSecurityGroupSource - a SecurityGroupSource returning the security groups CF applies to a single space
+// for one lifecycle, including globally enabled groups
+type CFSpaceSecurityGroupSource struct {
+	Client    *client.Client
+	SpaceGUID string
+	Lifecycle string
+}
+
+// NewCFSpaceSecurityGroupSource - returns a SecurityGroupSource reading the security groups of a space for the
+// running or staging lifecycle from the given CF API client
+func NewCFSpaceSecurityGroupSource(cfClient *client.Client, spaceGUID, lifecycle string) *CFSpaceSecurityGroupSource {
+	return &CFSpaceSecurityGroupSource{Client: cfClient, SpaceGUID: spaceGUID, Lifecycle: lifecycle}
+}
+
+// SecurityGroups - lists the security groups applied to the space for the lifecycle
+func (s *CFSpaceSecurityGroupSource) SecurityGroups(ctx context.Context) ([]resource.SecurityGroup, error) {
+	var (
+		allSecGroups []*resource.SecurityGroup
+		err          error
+	)
+	switch s.Lifecycle {
+	case LifecycleRunning:
+		allSecGroups, err = s.Client.SecurityGroups.ListRunningForSpaceAll(ctx, s.SpaceGUID, nil)
+	case LifecycleStaging:
+		allSecGroups, err = s.Client.SecurityGroups.ListStagingForSpaceAll(ctx, s.SpaceGUID, nil)
+	default:
+		return nil, fmt.Errorf("Lifecycle %s is not supported, valid lifecycles are %s", s.Lifecycle, strings.Join(Lifecycles, ", "))
+	}
+	if err != nil {
+		return nil, err
+	}
+	secGroups := make([]resource.SecurityGroup, 0, len(allSecGroups))
+	for _, secGroup := range allSecGroups {
+		secGroups = append(secGroups, *secGroup)
+	}
+	return secGroups, nil
+}
+
+// CFSpaceSource - a SpaceSource backed by a go-cfclient v3 client
+type CFSpaceSource struct {
+	Client *client.Client
+}
+
+// NewCFSpaceSource - returns a SpaceSource reading from the given CF API client
+func NewCFSpaceSource(cfClient *client.Client) *CFSpaceSource {
+	return &CFSpaceSource{Client: cfClient}
+}
+
+// Spaces - lists every space visible to the CF client with its org, counting the apps in each space
+func (s *CFSpaceSource) Spaces(ctx context.Context) ([]Space, error) {
+	allSpaces, orgs, err := s.Client.Spaces.ListIncludeOrganizationsAll(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	apps, err := s.Client.Applications.ListAll(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	orgNames := make(map[string]string)
+	for _, org := range orgs {
+		orgNames[org.GUID] = org.Name
+	}
+	appCounts := make(map[string]int)
+	for _, app := range apps {
+		if app.Relationships.Space.Data != nil {
+			appCounts[app.Relationships.Space.Data.GUID]++
+		}
+	}
+	spaces := make([]Space, 0, len(allSpaces))
+	for _, space := range allSpaces {
+		org := ""
+		if space.Relationships != nil && space.Relationships.Organization != nil && space.Relationships.Organization.Data != nil {
+			org = orgNames[space.Relationships.Organization.Data.GUID]
+		}
+		spaces = append(spaces, Space{GUID: space.GUID, Name: space.Name, Org: org, Apps: appCounts[space.GUID]})
+	}
+	return spaces, nil
+}
+
+// BOSHCellSource - a CellSource and NetworkSource backed by a gogobosh client
+type BOSHCellSource struct {
+	Client *gogobosh.Client
+}
+
+// NewBOSHCellSource - returns a CellSource and NetworkSource reading from the given BOSH director client
+func NewBOSHCellSource(boshClient *gogobosh.Client) *BOSHCellSource {
+	return &BOSHCellSource{Client: boshClient}
+}
+
+// Deployments - lists the deployments on the BOSH director, gogobosh has no context support so
+// cancellation is only checked before the request is made
+func (s *BOSHCellSource) Deployments(ctx context.Context) ([]gogobosh.Deployment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.Client.GetDeployments()
+}
+
+// DeploymentVMs - lists the VMs of a BOSH deployment
+func (s *BOSHCellSource) DeploymentVMs(ctx context.Context, deployment string) ([]gogobosh.VM, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.Client.GetDeploymentVMs(deployment)
+}
+
+// DeploymentManifest - returns the manifest of a BOSH deployment
+func (s *BOSHCellSource) DeploymentManifest(ctx context.Context, deployment string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	manifest, err := s.Client.GetDeployment(deployment)
+	if err != nil {
+		return "", err
+	}
+	return manifest.Manifest, nil
+}
+
+// CloudConfigs - returns the content of the latest version of every cloud config on the BOSH director. gogobosh's
+// GetCloudConfig decodes the director's list of configs as a single config, so the request is made directly
+func (s *BOSHCellSource) CloudConfigs(ctx context.Context) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	resp, err := s.Client.DoRequest(s.Client.NewRequest("GET", "/configs?type=cloud&latest=true"))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Fetching the cloud config failed: %s", resp.Status)
+	}
+	var configs []struct {
+		Content string `json:"content"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&configs); err != nil {
+		return nil, err
+	}
+	contents := make([]string, 0, len(configs))
+	for _, config := range configs {
+		contents = append(contents, config.Content)
+	}
+	return contents, nil
+}
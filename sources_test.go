@@ -0,0 +1,184 @@
+package virgil_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/FidelityInternational/virgil"
+	"github.com/FidelityInternational/virgil/fakes"
+	"github.com/cloudfoundry-community/gogobosh"
+	"github.com/cloudfoundry/go-cfclient/v3/resource"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sources", func() {
+	var (
+		cfServer   *fakes.CFServer
+		boshServer *fakes.BOSHServer
+		secGroups  virgil.SecurityGroupSource
+		spaces     virgil.SpaceSource
+		cells      virgil.CellSource
+	)
+
+	BeforeEach(func() {
+		cfServer = fakes.NewCFServer([]resource.SecurityGroup{
+			fakes.SecurityGroup("web", fakes.Rule("tcp", "10.1.0.0/16", "443"), fakes.Rule("tcp", "10.1.0.0/16", "8080-8081")),
+			fakes.SecurityGroup("all", fakes.Rule("all", "172.16.0.0/12", "")),
+		})
+		boshServer = fakes.NewBOSHServer(map[string][]gogobosh.VM{
+			"cf-123": {
+				{JobName: "diego_cell", IPs: []string{"10.0.16.2"}},
+				{JobName: "diego_cell", IPs: []string{"10.0.16.1"}},
+				{JobName: "router", IPs: []string{"10.0.16.10"}},
+			},
+		})
+		cfClient, err := cfServer.Client()
+		Expect(err).ToNot(HaveOccurred())
+		boshClient, err := boshServer.Client()
+		Expect(err).ToNot(HaveOccurred())
+		secGroups = virgil.NewCFSecurityGroupSource(cfClient)
+		spaces = virgil.NewCFSpaceSource(cfClient)
+		cells = virgil.NewBOSHCellSource(boshClient)
+	})
+
+	AfterEach(func() {
+		cfServer.Close()
+		boshServer.Close()
+	})
+
+	Describe("CFSecurityGroupSource", func() {
+		It("lists security groups from the CF API", func() {
+			result, err := secGroups.SecurityGroups(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(HaveLen(2))
+			Expect(result[0].Name).To(Equal("web"))
+		})
+
+		It("returns CF API errors", func() {
+			cfServer.Fail(1)
+			_, err := secGroups.SecurityGroups(context.Background())
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("CFSpaceSecurityGroupSource", func() {
+		BeforeEach(func() {
+			bound := fakes.SecurityGroup("bound", fakes.Rule("tcp", "10.2.0.0/16", "5432"))
+			bound.GloballyEnabled.Running = new(bool)
+			bound.Relationships.StagingSpaces.Data = []resource.Relationship{{GUID: "dev-guid"}}
+			cfServer.SetSecurityGroups([]resource.SecurityGroup{fakes.SecurityGroup("web", fakes.Rule("tcp", "10.1.0.0/16", "443")), bound})
+		})
+
+		It("lists the security groups of a space for each lifecycle", func() {
+			cfClient, err := cfServer.Client()
+			Expect(err).ToNot(HaveOccurred())
+			running, err := virgil.NewCFSpaceSecurityGroupSource(cfClient, "dev-guid", virgil.LifecycleRunning).SecurityGroups(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(running).To(HaveLen(1))
+			Expect(running[0].Name).To(Equal("web"))
+			staging, err := virgil.NewCFSpaceSecurityGroupSource(cfClient, "dev-guid", virgil.LifecycleStaging).SecurityGroups(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(staging).To(HaveLen(1))
+			Expect(staging[0].Name).To(Equal("bound"))
+		})
+
+		It("returns an error for an unknown lifecycle", func() {
+			cfClient, err := cfServer.Client()
+			Expect(err).ToNot(HaveOccurred())
+			_, err = virgil.NewCFSpaceSecurityGroupSource(cfClient, "dev-guid", "tasks").SecurityGroups(context.Background())
+			Expect(err).To(MatchError("Lifecycle tasks is not supported, valid lifecycles are running, staging"))
+		})
+	})
+
+	Describe("CFSpaceSource", func() {
+		BeforeEach(func() {
+			cfServer.SetSpaces(
+				[]resource.Organization{fakes.Org("platform")},
+				[]resource.Space{fakes.Space("dev", "platform"), fakes.Space("prod", "platform"), fakes.Space("old", "deleted")},
+				[]resource.App{fakes.App("api", "dev"), fakes.App("worker", "dev"), fakes.App("web", "prod")},
+			)
+		})
+
+		It("lists spaces with their org and app count", func() {
+			result, err := spaces.Spaces(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal([]virgil.Space{
+				{GUID: "dev-guid", Name: "dev", Org: "platform", Apps: 2},
+				{GUID: "prod-guid", Name: "prod", Org: "platform", Apps: 1},
+				{GUID: "old-guid", Name: "old", Org: "", Apps: 0},
+			}))
+		})
+
+		It("returns CF API errors", func() {
+			cfServer.Fail(1)
+			_, err := spaces.Spaces(context.Background())
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("BOSHCellSource", func() {
+		It("lists deployments and VMs from the director", func() {
+			deployments, err := cells.Deployments(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deployments).To(HaveLen(1))
+			vms, err := cells.DeploymentVMs(context.Background(), "cf-123")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(vms).To(HaveLen(3))
+		})
+
+		It("returns an error when the context is done", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			_, err := cells.Deployments(ctx)
+			Expect(err).To(MatchError(context.Canceled))
+		})
+
+		It("fetches deployment manifests and cloud configs from the director", func() {
+			boshServer.SetManifest("cf-123", "name: cf-123\n")
+			boshServer.SetCloudConfig("networks: []\n")
+			networks := cells.(virgil.NetworkSource)
+			manifest, err := networks.DeploymentManifest(context.Background(), "cf-123")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(manifest).To(Equal("name: cf-123\n"))
+			cloudConfigs, err := networks.CloudConfigs(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cloudConfigs).To(Equal([]string{"networks: []\n"}))
+		})
+
+		It("returns an error when the cloud config cannot be fetched", func() {
+			boshServer.Fail(1)
+			_, err := cells.(virgil.NetworkSource).CloudConfigs(context.Background())
+			Expect(err).To(MatchError("Fetching the cloud config failed: 500 Internal Server Error"))
+		})
+	})
+
+	It("generates a policy end to end from the stub CF and BOSH servers", func() {
+		var output bytes.Buffer
+		Expect(virgil.NewGenerator(secGroups, cells).Render(context.Background(), &output)).To(Succeed())
+		Expect(output.String()).To(Equal(`---
+schema_version: "1"
+firewall_rules:
+- port: ""
+  destination:
+  - 172.16.0.0/12
+  protocol: all
+  source:
+  - 10.0.16.1
+  - 10.0.16.2
+- port: "443"
+  destination:
+  - 10.1.0.0/16
+  protocol: tcp
+  source:
+  - 10.0.16.1
+  - 10.0.16.2
+- port: 8080-8081
+  destination:
+  - 10.1.0.0/16
+  protocol: tcp
+  source:
+  - 10.0.16.1
+  - 10.0.16.2
+`))
+	})
+})
@@ -3,6 +3,8 @@ package utility
 import (
 	"fmt"
 	"github.com/cloudfoundry/go-cfclient/v3/resource"
+	"os"
+	"path/filepath"
 	"reflect"
 	"sort"
 	"strconv"
@@ -19,18 +21,54 @@ func BoolPtr(b bool) *bool {
 	return &b
 }
 
+// WriteFileAtomic - writes data to a temporary file beside path and renames it over path, so
+// readers such as firewall automation never see a partially written file
+func WriteFileAtomic(path string, data []byte) error {
+	tempFile, err := os.CreateTemp(filepath.Dir(path), fmt.Sprintf(".%s-*", filepath.Base(path)))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+	if _, err := tempFile.Write(data); err != nil {
+		tempFile.Close()
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tempFile.Name(), os.FileMode(0644)); err != nil {
+		return err
+	}
+	return os.Rename(tempFile.Name(), path)
+}
+
 // FirewallRules - A collection of Firewall Rules with version
 type FirewallRules struct {
-	SchemaVersion string         `yaml:"schema_version"`
-	FirewallRules []FirewallRule `yaml:"firewall_rules"`
+	SchemaVersion string         `yaml:"schema_version" json:"schema_version"`
+	FirewallRules []FirewallRule `yaml:"firewall_rules" json:"firewall_rules"`
 }
 
 // FirewallRule struct
 type FirewallRule struct {
-	Port        string
-	Destination []string
+	Port        string   `json:"port"`
+	Destination []string `json:"destination"`
+	Protocol    string   `json:"protocol"`
+	Source      []string `json:"source"`
+}
+
+// FirewallTuple - a single source, destination, protocol and port (or port range) allowed by a firewall rule
+type FirewallTuple struct {
+	Source      string
+	Destination string
 	Protocol    string
-	Source      []string
+	Port        string
+}
+
+// SkippedRule - a security group rule that could not be turned into a firewall rule, with the reason why
+type SkippedRule struct {
+	SecurityGroup string                     `json:"security_group"`
+	Rule          resource.SecurityGroupRule `json:"rule"`
+	Reason        string                     `json:"reason"`
 }
 
 // ByPort - implements sort.Interface for []FirewallRule bases on the Port field
@@ -84,11 +122,13 @@ func PortExpand(portString string) (*[]string, error) {
 	return &ports, nil
 }
 
-// ProcessRule - returns a concise list of firewall rules for one security group rule
+// ProcessRule - returns a concise list of firewall rules for one security group rule. Protocols are lowercased so
+// rules such as "TCP" and "tcp" on the same port are merged
 func ProcessRule(secGroupRule resource.SecurityGroupRule, firewallRules []FirewallRule, source []string) ([]FirewallRule, error) {
-	if strings.EqualFold(secGroupRule.Protocol, "all") {
+	protocol := strings.ToLower(secGroupRule.Protocol)
+	if protocol == "all" {
 		newRules := FirewallRule{
-			Protocol:    secGroupRule.Protocol,
+			Protocol:    protocol,
 			Destination: []string{secGroupRule.Destination},
 			Source:      source,
 		}
@@ -104,7 +144,7 @@ func ProcessRule(secGroupRule resource.SecurityGroupRule, firewallRules []Firewa
 			port := string(portRune)
 			var newRule = true
 			for i, rule := range firewallRules {
-				if rule.Port == port && rule.Protocol == secGroupRule.Protocol {
+				if rule.Port == port && rule.Protocol == protocol {
 					rule.Destination = append(rule.Destination, secGroupRule.Destination)
 					RemoveDuplicates(&rule.Destination)
 					firewallRules[i] = rule
@@ -114,7 +154,7 @@ func ProcessRule(secGroupRule resource.SecurityGroupRule, firewallRules []Firewa
 			if newRule {
 				newRules := FirewallRule{
 					Port:        port,
-					Protocol:    secGroupRule.Protocol,
+					Protocol:    protocol,
 					Destination: []string{secGroupRule.Destination},
 					Source:      source,
 				}
@@ -125,6 +165,65 @@ func ProcessRule(secGroupRule resource.SecurityGroupRule, firewallRules []Firewa
 	return firewallRules, nil
 }
 
+// ExplodeFirewallRules - expands firewall rules into one tuple per source and destination, ports are kept as ranges
+func ExplodeFirewallRules(firewallRules FirewallRules) []FirewallTuple {
+	var tuples []FirewallTuple
+	for _, rule := range firewallRules.FirewallRules {
+		sources := rule.Source
+		if len(sources) == 0 {
+			sources = []string{""}
+		}
+		for _, source := range sources {
+			for _, destination := range rule.Destination {
+				tuples = append(tuples, FirewallTuple{
+					Source:      source,
+					Destination: destination,
+					Protocol:    rule.Protocol,
+					Port:        rule.Port,
+				})
+			}
+		}
+	}
+	return tuples
+}
+
+// GetProvenance - returns the names of the security groups with a rule allowing protocol to destination on any port in portString
+func GetProvenance(protocol, destination, portString string, secGroups []resource.SecurityGroup) []string {
+	var names []string
+	ports := make(map[string]bool)
+	if expanded, err := PortExpand(portString); err == nil {
+		for _, port := range *expanded {
+			ports[port] = true
+		}
+	}
+	for _, secGroup := range secGroups {
+		for _, secGroupRule := range secGroup.Rules {
+			if !strings.EqualFold(secGroupRule.Protocol, protocol) || secGroupRule.Destination != destination {
+				continue
+			}
+			if strings.EqualFold(protocol, "all") {
+				names = append(names, secGroup.Name)
+				continue
+			}
+			if secGroupRule.Ports == nil {
+				continue
+			}
+			rulePorts, err := PortExpand(*secGroupRule.Ports)
+			if err != nil {
+				continue
+			}
+			for _, port := range *rulePorts {
+				if ports[port] {
+					names = append(names, secGroup.Name)
+					break
+				}
+			}
+		}
+	}
+	RemoveDuplicates(&names)
+	return names
+}
+
 // RemoveDuplicates - removes duplicated from array of strings
 func RemoveDuplicates(xs *[]string) {
 	found := make(map[string]bool)
@@ -150,6 +249,50 @@ func GetUsedSecGroups(allSecGroups []resource.SecurityGroup) []resource.Security
 	return secGroups
 }
 
+// GetRunningSecGroups - Returns the security groups applied to running apps in any of the given spaces, including globally enabled groups
+func GetRunningSecGroups(allSecGroups []resource.SecurityGroup, spaceGUIDs []string) []resource.SecurityGroup {
+	var secGroups []resource.SecurityGroup
+	for _, secGroup := range allSecGroups {
+		if secGroup.GloballyEnabled.Running != nil && *secGroup.GloballyEnabled.Running {
+			secGroups = append(secGroups, secGroup)
+			continue
+		}
+		for _, space := range secGroup.Relationships.RunningSpaces.Data {
+			if containsString(spaceGUIDs, space.GUID) {
+				secGroups = append(secGroups, secGroup)
+				break
+			}
+		}
+	}
+	return secGroups
+}
+
+// GetSkippedRules - Returns the security group rules that GetFirewallRules leaves out of the firewall rules
+func GetSkippedRules(secGroups []resource.SecurityGroup) []SkippedRule {
+	var skippedRules []SkippedRule
+	for _, secGroup := range secGroups {
+		for _, secGroupRule := range secGroup.Rules {
+			var reason string
+			switch {
+			case strings.EqualFold(secGroupRule.Protocol, "all"):
+				continue
+			case secGroupRule.Ports == nil && strings.EqualFold(secGroupRule.Protocol, "icmp"):
+				reason = "Protocol icmp is not supported"
+			case secGroupRule.Ports == nil:
+				reason = "Rule has no ports"
+			default:
+				if _, err := PortExpand(*secGroupRule.Ports); err != nil {
+					reason = err.Error()
+				}
+			}
+			if reason != "" {
+				skippedRules = append(skippedRules, SkippedRule{SecurityGroup: secGroup.Name, Rule: secGroupRule, Reason: reason})
+			}
+		}
+	}
+	return skippedRules
+}
+
 // GetFirewallRules - Returns a concise list of firewall rules for all security groups
 func GetFirewallRules(source []string, secGroups []resource.SecurityGroup) FirewallRules {
 	var (
@@ -169,6 +312,87 @@ func GetFirewallRules(source []string, secGroups []resource.SecurityGroup) Firew
 	return compressDuplicateDestinations(firewallRules)
 }
 
+// MergeFirewallRules - combines firewall rules generated for different sources, such as several foundations, into
+// one set. Port ranges are expanded so sources are unioned per protocol, port and destination, destinations with the
+// same sources are grouped, and consecutive ports with the same sources and destinations are compressed into ranges
+func MergeFirewallRules(ruleSets ...FirewallRules) FirewallRules {
+	type destinationKey struct{ protocol, port, destination string }
+	type sourcesKey struct{ protocol, port, sources string }
+	type rangeKey struct{ protocol, destinations, sources string }
+	type portRange struct{ index, start, end int }
+	merged := FirewallRules{SchemaVersion: "1"}
+	var destinationOrder []destinationKey
+	sources := make(map[destinationKey][]string)
+	for _, ruleSet := range ruleSets {
+		if ruleSet.SchemaVersion != "" {
+			merged.SchemaVersion = ruleSet.SchemaVersion
+		}
+		for _, rule := range ruleSet.FirewallRules {
+			ports := []string{rule.Port}
+			if expanded, err := PortExpand(rule.Port); rule.Port != "" && err == nil {
+				ports = *expanded
+			}
+			for _, port := range ports {
+				for _, destination := range rule.Destination {
+					key := destinationKey{rule.Protocol, port, destination}
+					if _, ok := sources[key]; !ok {
+						destinationOrder = append(destinationOrder, key)
+					}
+					sources[key] = append(sources[key], rule.Source...)
+				}
+			}
+		}
+	}
+	var portRules []FirewallRule
+	rules := make(map[sourcesKey]int)
+	for _, key := range destinationOrder {
+		keySources := sources[key]
+		RemoveDuplicates(&keySources)
+		sort.Strings(keySources)
+		groupKey := sourcesKey{key.protocol, key.port, strings.Join(keySources, ",")}
+		if i, ok := rules[groupKey]; ok {
+			portRules[i].Destination = append(portRules[i].Destination, key.destination)
+			continue
+		}
+		rules[groupKey] = len(portRules)
+		portRules = append(portRules, FirewallRule{
+			Protocol:    key.protocol,
+			Port:        key.port,
+			Destination: []string{key.destination},
+			Source:      keySources,
+		})
+	}
+	sort.Stable(ByPort(portRules))
+	ranges := make(map[rangeKey]portRange)
+	for _, rule := range portRules {
+		destinations := append([]string{}, rule.Destination...)
+		sort.Strings(destinations)
+		key := rangeKey{rule.Protocol, strings.Join(destinations, ","), strings.Join(rule.Source, ",")}
+		port, err := strconv.Atoi(rule.Port)
+		if err != nil {
+			merged.FirewallRules = append(merged.FirewallRules, rule)
+			continue
+		}
+		if previous, ok := ranges[key]; ok && previous.end+1 == port {
+			merged.FirewallRules[previous.index].Port = fmt.Sprintf("%d-%d", previous.start, port)
+			ranges[key] = portRange{previous.index, previous.start, port}
+			continue
+		}
+		ranges[key] = portRange{len(merged.FirewallRules), port, port}
+		merged.FirewallRules = append(merged.FirewallRules, rule)
+	}
+	return merged
+}
+
+func containsString(xs []string, x string) bool {
+	for _, s := range xs {
+		if s == x {
+			return true
+		}
+	}
+	return false
+}
+
 func compressDuplicateDestinations(firewallRules FirewallRules) FirewallRules {
 	var firewallRulesResult []FirewallRule
 	schema := firewallRules.SchemaVersion
@@ -5,6 +5,8 @@ import (
 	"github.com/cloudfoundry/go-cfclient/v3/resource"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"os"
+	"path/filepath"
 	"sort"
 )
 
@@ -142,6 +144,21 @@ var _ = Describe("#ProcessRule", func() {
 		})
 	})
 
+	Context("when protocols differ only in case", func() {
+		It("merges the rules under the lowercase protocol", func() {
+			rules, err := utility.ProcessRule(resource.SecurityGroupRule{Ports: utility.StringPtr("443"), Protocol: "TCP", Destination: "1.1.1.1"}, []utility.FirewallRule{}, source)
+			Expect(err).To(BeNil())
+			rules, err = utility.ProcessRule(resource.SecurityGroupRule{Ports: utility.StringPtr("443"), Protocol: "tcp", Destination: "2.2.2.2"}, rules, source)
+			Expect(err).To(BeNil())
+			rules, err = utility.ProcessRule(resource.SecurityGroupRule{Protocol: "ALL", Destination: "3.3.3.3"}, rules, source)
+			Expect(err).To(BeNil())
+			Expect(rules).To(Equal([]utility.FirewallRule{
+				{Port: "443", Protocol: "tcp", Destination: []string{"1.1.1.1", "2.2.2.2"}, Source: source},
+				{Protocol: "all", Destination: []string{"3.3.3.3"}, Source: source},
+			}))
+		})
+	})
+
 	Context("when ports cannot be expanded", func() {
 		It("returns an error", func() {
 			var securityGroupRule1 = resource.SecurityGroupRule{
@@ -257,6 +274,82 @@ var _ = Describe("#GetUsedSecGroups", func() {
 	})
 })
 
+var _ = Describe("#GetRunningSecGroups", func() {
+	It("returns globally running security groups and those bound to the given spaces", func() {
+		var securityGroups = []resource.SecurityGroup{
+			{
+				Name: "global-running",
+				GloballyEnabled: resource.SecurityGroupGloballyEnabled{
+					Running: utility.BoolPtr(true),
+					Staging: utility.BoolPtr(false),
+				},
+			},
+			{
+				Name: "global-staging",
+				GloballyEnabled: resource.SecurityGroupGloballyEnabled{
+					Running: utility.BoolPtr(false),
+					Staging: utility.BoolPtr(true),
+				},
+			},
+			{
+				Name: "bound-running",
+				GloballyEnabled: resource.SecurityGroupGloballyEnabled{
+					Running: utility.BoolPtr(false),
+					Staging: utility.BoolPtr(false),
+				},
+				Relationships: resource.SecurityGroupsRelationships{
+					RunningSpaces: resource.ToManyRelationships{
+						Data: []resource.Relationship{{GUID: "space-2"}, {GUID: "space-1"}},
+					},
+				},
+			},
+			{
+				Name: "bound-elsewhere",
+				GloballyEnabled: resource.SecurityGroupGloballyEnabled{
+					Running: utility.BoolPtr(false),
+					Staging: utility.BoolPtr(false),
+				},
+				Relationships: resource.SecurityGroupsRelationships{
+					RunningSpaces: resource.ToManyRelationships{
+						Data: []resource.Relationship{{GUID: "space-3"}},
+					},
+					StagingSpaces: resource.ToManyRelationships{
+						Data: []resource.Relationship{{GUID: "space-1"}},
+					},
+				},
+			},
+		}
+		secGroups := utility.GetRunningSecGroups(securityGroups, []string{"space-1"})
+		Expect(secGroups).To(HaveLen(2))
+		Expect(secGroups[0].Name).To(Equal("global-running"))
+		Expect(secGroups[1].Name).To(Equal("bound-running"))
+	})
+})
+
+var _ = Describe("#GetSkippedRules", func() {
+	It("returns the rules that cannot become firewall rules with a reason", func() {
+		var securityGroups = []resource.SecurityGroup{
+			{
+				Name: "mixed",
+				Rules: []resource.SecurityGroupRule{
+					{Protocol: "tcp", Ports: utility.StringPtr("443"), Destination: "1.1.1.1"},
+					{Protocol: "all", Destination: "2.2.2.2"},
+					{Protocol: "tcp", Ports: utility.StringPtr("not_valid_ports"), Destination: "3.3.3.3"},
+					{Protocol: "icmp", Destination: "4.4.4.4"},
+					{Protocol: "udp", Destination: "5.5.5.5"},
+				},
+			},
+		}
+		skippedRules := utility.GetSkippedRules(securityGroups)
+		Expect(skippedRules).To(HaveLen(3))
+		Expect(skippedRules[0].SecurityGroup).To(Equal("mixed"))
+		Expect(skippedRules[0].Rule.Destination).To(Equal("3.3.3.3"))
+		Expect(skippedRules[0].Reason).To(Equal("Port not_valid_ports was invalid"))
+		Expect(skippedRules[1].Reason).To(Equal("Protocol icmp is not supported"))
+		Expect(skippedRules[2].Reason).To(Equal("Rule has no ports"))
+	})
+})
+
 var _ = Describe("#GetFirewallRules", func() {
 	var source = []string{"1.2.3.4", "2.3.4.5"}
 
@@ -471,6 +564,104 @@ var _ = Describe("#GetFirewallRules", func() {
 	})
 })
 
+var _ = Describe("#ExplodeFirewallRules", func() {
+	It("returns a tuple per source and destination", func() {
+		firewallRules := utility.FirewallRules{
+			FirewallRules: []utility.FirewallRule{
+				{Port: "80-81", Protocol: "tcp", Destination: []string{"2.2.2.2", "3.3.3.3"}, Source: []string{"1.1.1.1", "1.1.1.2"}},
+				{Protocol: "all", Destination: []string{"9.9.9.9"}},
+			},
+		}
+		Expect(utility.ExplodeFirewallRules(firewallRules)).To(Equal([]utility.FirewallTuple{
+			{Source: "1.1.1.1", Destination: "2.2.2.2", Protocol: "tcp", Port: "80-81"},
+			{Source: "1.1.1.1", Destination: "3.3.3.3", Protocol: "tcp", Port: "80-81"},
+			{Source: "1.1.1.2", Destination: "2.2.2.2", Protocol: "tcp", Port: "80-81"},
+			{Source: "1.1.1.2", Destination: "3.3.3.3", Protocol: "tcp", Port: "80-81"},
+			{Source: "", Destination: "9.9.9.9", Protocol: "all", Port: ""},
+		}))
+	})
+})
+
+var _ = Describe("#MergeFirewallRules", func() {
+	It("unions sources per destination and port and groups destinations with the same sources", func() {
+		dc1 := utility.FirewallRules{
+			SchemaVersion: "1",
+			FirewallRules: []utility.FirewallRule{
+				{Port: "443", Protocol: "tcp", Destination: []string{"2.2.2.2", "3.3.3.3"}, Source: []string{"1.1.1.1"}},
+				{Port: "53", Protocol: "udp", Destination: []string{"8.8.8.8"}, Source: []string{"1.1.1.1"}},
+			},
+		}
+		dc2 := utility.FirewallRules{
+			SchemaVersion: "1",
+			FirewallRules: []utility.FirewallRule{
+				{Port: "443", Protocol: "tcp", Destination: []string{"2.2.2.2", "4.4.4.4"}, Source: []string{"1.1.2.1", "1.1.1.1"}},
+				{Protocol: "all", Destination: []string{"9.9.9.9"}, Source: []string{"1.1.2.1"}},
+			},
+		}
+		Expect(utility.MergeFirewallRules(dc1, dc2)).To(Equal(utility.FirewallRules{
+			SchemaVersion: "1",
+			FirewallRules: []utility.FirewallRule{
+				{Protocol: "all", Destination: []string{"9.9.9.9"}, Source: []string{"1.1.2.1"}},
+				{Port: "443", Protocol: "tcp", Destination: []string{"2.2.2.2", "4.4.4.4"}, Source: []string{"1.1.1.1", "1.1.2.1"}},
+				{Port: "443", Protocol: "tcp", Destination: []string{"3.3.3.3"}, Source: []string{"1.1.1.1"}},
+				{Port: "53", Protocol: "udp", Destination: []string{"8.8.8.8"}, Source: []string{"1.1.1.1"}},
+			},
+		}))
+	})
+
+	It("unions sources per port when port ranges overlap, compressing consecutive ports back into ranges", func() {
+		dc1 := utility.FirewallRules{SchemaVersion: "1", FirewallRules: []utility.FirewallRule{
+			{Port: "8080-8082", Protocol: "tcp", Destination: []string{"2.2.2.2"}, Source: []string{"1.1.1.1"}},
+		}}
+		dc2 := utility.FirewallRules{SchemaVersion: "1", FirewallRules: []utility.FirewallRule{
+			{Port: "8081", Protocol: "tcp", Destination: []string{"2.2.2.2"}, Source: []string{"1.1.2.1"}},
+			{Port: "8083-8084", Protocol: "tcp", Destination: []string{"2.2.2.2"}, Source: []string{"1.1.2.1"}},
+		}}
+		Expect(utility.MergeFirewallRules(dc1, dc2).FirewallRules).To(Equal([]utility.FirewallRule{
+			{Port: "8080", Protocol: "tcp", Destination: []string{"2.2.2.2"}, Source: []string{"1.1.1.1"}},
+			{Port: "8081", Protocol: "tcp", Destination: []string{"2.2.2.2"}, Source: []string{"1.1.1.1", "1.1.2.1"}},
+			{Port: "8082", Protocol: "tcp", Destination: []string{"2.2.2.2"}, Source: []string{"1.1.1.1"}},
+			{Port: "8083-8084", Protocol: "tcp", Destination: []string{"2.2.2.2"}, Source: []string{"1.1.2.1"}},
+		}))
+		Expect(utility.MergeFirewallRules(dc1, dc1).FirewallRules).To(Equal(dc1.FirewallRules))
+	})
+
+	It("returns an empty policy when there is nothing to merge", func() {
+		Expect(utility.MergeFirewallRules().SchemaVersion).To(Equal("1"))
+	})
+})
+
+var _ = Describe("#GetProvenance", func() {
+	var secGroups = []resource.SecurityGroup{
+		{
+			Name: "web",
+			Rules: []resource.SecurityGroupRule{
+				{Protocol: "tcp", Ports: utility.StringPtr("80,443"), Destination: "2.2.2.2"},
+			},
+		},
+		{
+			Name: "web-range",
+			Rules: []resource.SecurityGroupRule{
+				{Protocol: "tcp", Ports: utility.StringPtr("440-450"), Destination: "2.2.2.2"},
+				{Protocol: "all", Destination: "9.9.9.9"},
+			},
+		},
+		{
+			Name: "dns",
+			Rules: []resource.SecurityGroupRule{
+				{Protocol: "udp", Ports: utility.StringPtr("443"), Destination: "2.2.2.2"},
+			},
+		},
+	}
+
+	It("returns the security groups contributing to a destination and port range", func() {
+		Expect(utility.GetProvenance("tcp", "2.2.2.2", "443", secGroups)).To(Equal([]string{"web", "web-range"}))
+		Expect(utility.GetProvenance("tcp", "2.2.2.2", "79-80", secGroups)).To(Equal([]string{"web"}))
+		Expect(utility.GetProvenance("all", "9.9.9.9", "", secGroups)).To(Equal([]string{"web-range"}))
+		Expect(utility.GetProvenance("tcp", "3.3.3.3", "443", secGroups)).To(BeEmpty())
+	})
+})
+
 var _ = Describe("#ByPort", func() {
 	It("sorts FirewallRules in order by port", func() {
 		var firewallRules = []utility.FirewallRule{
@@ -573,3 +764,27 @@ var _ = Describe("RemoveDuplicates", func() {
 	})
 
 })
+
+var _ = Describe("#WriteFileAtomic", func() {
+	It("replaces the file without leaving temporary files behind", func() {
+		tempDir, err := os.MkdirTemp("", "virgil-utility")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(tempDir)
+		path := filepath.Join(tempDir, "policy.yml")
+		Expect(os.WriteFile(path, []byte("old"), 0600)).To(Succeed())
+		Expect(utility.WriteFileAtomic(path, []byte("new"))).To(Succeed())
+		data, err := os.ReadFile(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("new"))
+		info, err := os.Stat(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0644)))
+		entries, err := os.ReadDir(tempDir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+	})
+
+	It("returns an error when the directory does not exist", func() {
+		Expect(utility.WriteFileAtomic(filepath.Join(os.TempDir(), "virgil-missing", "policy.yml"), nil)).ToNot(Succeed())
+	})
+})
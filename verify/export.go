@@ -0,0 +1,288 @@
+package verify
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"github.com/FidelityInternational/virgil/render"
+	"github.com/FidelityInternational/virgil/utility"
+	"gopkg.in/yaml.v2"
+	"strings"
+)
+
+// ExportFormats - the firewall export formats that can be verified against
+var ExportFormats = []string{"iptables", "virgil"}
+
+// Rule - an allow rule read from a firewall export, as a single tuple with the line it was read from
+type Rule struct {
+	utility.FirewallTuple
+	Line int
+	Text string
+}
+
+// String - describes the rule as protocol, port, source and destination, with its line when known
+func (r Rule) String() string {
+	description := describe(r.FirewallTuple)
+	if r.Line == 0 {
+		return description
+	}
+	return fmt.Sprintf("line %d: %s", r.Line, description)
+}
+
+// SkippedLine - an export line that allows traffic but cannot be compared, such as a negated match
+type SkippedLine struct {
+	Line   int
+	Text   string
+	Reason string
+}
+
+// Export - the allow rules read from a firewall export
+type Export struct {
+	Rules   []Rule
+	Skipped []SkippedLine
+}
+
+// DetectExportFormat - guesses the format of an export, virgil YAML or JSON has a schema_version
+func DetectExportFormat(data []byte) string {
+	if bytes.Contains(data, []byte("schema_version")) {
+		return "virgil"
+	}
+	return "iptables"
+}
+
+// ParseExport - parses a firewall export in the given format, chains limits iptables rules to the named chains
+func ParseExport(data []byte, format string, chains []string) (Export, error) {
+	switch format {
+	case "iptables":
+		return ParseIPTables(data, chains)
+	case "virgil":
+		return ParseVirgil(data)
+	}
+	return Export{}, fmt.Errorf("Export format %s is not supported, valid formats are %s", format, strings.Join(ExportFormats, ", "))
+}
+
+// ParseVirgil - parses a policy previously written by virgil in the yaml or json format
+func ParseVirgil(data []byte) (Export, error) {
+	var firewallRules utility.FirewallRules
+	if err := yaml.Unmarshal(data, &firewallRules); err != nil {
+		return Export{}, err
+	}
+	if firewallRules.SchemaVersion == "" {
+		return Export{}, fmt.Errorf("Export has no schema_version and is not a virgil policy")
+	}
+	var export Export
+	for _, tuple := range utility.ExplodeFirewallRules(firewallRules) {
+		export.Rules = append(export.Rules, Rule{FirewallTuple: tuple})
+	}
+	return export, nil
+}
+
+// ParseIPTables - parses the ACCEPT rules of iptables-save output, other targets are ignored.
+// Rules from every chain are read unless chains is given
+func ParseIPTables(data []byte, chains []string) (Export, error) {
+	var export Export
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(text, "-A ") {
+			continue
+		}
+		fields, err := splitFields(text)
+		if err != nil {
+			return Export{}, fmt.Errorf("Line %d could not be parsed: %s", line, err)
+		}
+		match, err := parseIPTablesRule(fields)
+		if err != nil {
+			return Export{}, fmt.Errorf("Line %d could not be parsed: %s", line, err)
+		}
+		if match.target != "ACCEPT" || !contains(chains, match.chain) {
+			continue
+		}
+		if match.negated != "" {
+			export.Skipped = append(export.Skipped, SkippedLine{Line: line, Text: text, Reason: fmt.Sprintf("negated %s match is not supported", match.negated)})
+			continue
+		}
+		if match.unsupported != "" {
+			export.Skipped = append(export.Skipped, SkippedLine{Line: line, Text: text, Reason: fmt.Sprintf("%s match is not supported", match.unsupported)})
+			continue
+		}
+		for _, source := range match.sources {
+			for _, destination := range match.destinations {
+				for _, port := range match.ports {
+					export.Rules = append(export.Rules, Rule{
+						FirewallTuple: utility.FirewallTuple{
+							Source:      source,
+							Destination: destination,
+							Protocol:    match.protocol,
+							Port:        port,
+						},
+						Line: line,
+						Text: text,
+					})
+				}
+			}
+		}
+	}
+	return export, scanner.Err()
+}
+
+// iptablesRule - the parts of an iptables rule that decide what traffic it allows. Interface matches are read but do
+// not narrow the rule, security groups do not name interfaces. unsupported holds the first match that narrows the
+// rule in a way virgil cannot represent, such as --state
+type iptablesRule struct {
+	chain, target, protocol, negated, unsupported string
+	sources, destinations, ports                  []string
+}
+
+func parseIPTablesRule(fields []string) (iptablesRule, error) {
+	rule := iptablesRule{protocol: "all", sources: []string{""}, destinations: []string{""}, ports: []string{""}}
+	negate := false
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		if field == "!" {
+			negate = true
+			continue
+		}
+		value := ""
+		if i+1 < len(fields) {
+			value = fields[i+1]
+		}
+		handled := true
+		switch field {
+		case "-A", "--append":
+			rule.chain = value
+		case "-j", "--jump":
+			rule.target = value
+		case "-p", "--protocol":
+			rule.protocol = protocolName(value)
+		case "-s", "--source", "--src-range":
+			rule.sources = addresses(value)
+		case "-d", "--destination", "--dst-range":
+			rule.destinations = addresses(value)
+		case "--dport", "--destination-port", "--dports", "--destination-ports":
+			rule.ports = nil
+			for _, port := range strings.Split(value, ",") {
+				rule.ports = append(rule.ports, strings.Replace(port, ":", "-", 1))
+			}
+		case "-m", "--match", "--comment":
+		case "-i", "--in-interface", "-o", "--out-interface":
+			negate = false
+		default:
+			if strings.HasPrefix(field, "-") && rule.unsupported == "" {
+				rule.unsupported = field
+			}
+			handled = false
+		}
+		if !handled {
+			negate = false
+			continue
+		}
+		if value == "" {
+			return rule, fmt.Errorf("%s has no value", field)
+		}
+		if negate && rule.negated == "" {
+			rule.negated = field
+		}
+		negate = false
+		i++
+	}
+	for _, list := range [][]string{rule.sources, rule.destinations} {
+		for _, address := range list {
+			if address == "" {
+				continue
+			}
+			if _, err := render.ParseAddress(address); err != nil {
+				return rule, err
+			}
+		}
+	}
+	for _, port := range rule.ports {
+		if port == "" {
+			continue
+		}
+		if _, _, err := render.SplitPortRange(port); err != nil {
+			return rule, err
+		}
+	}
+	return rule, nil
+}
+
+// addresses - splits an iptables address list, 0.0.0.0/0 is any address
+func addresses(value string) []string {
+	var list []string
+	for _, address := range strings.Split(value, ",") {
+		if address == "0.0.0.0/0" || address == "::/0" {
+			address = ""
+		}
+		list = append(list, address)
+	}
+	return list
+}
+
+// protocolName - converts iptables protocol numbers to the names used by security groups
+func protocolName(protocol string) string {
+	switch strings.ToLower(protocol) {
+	case "6":
+		return "tcp"
+	case "17":
+		return "udp"
+	case "0", "all":
+		return "all"
+	}
+	return strings.ToLower(protocol)
+}
+
+// splitFields - splits an iptables-save line into fields, keeping double quoted values such as comments together
+func splitFields(line string) ([]string, error) {
+	var (
+		fields  []string
+		field   strings.Builder
+		quoted  bool
+		escaped bool
+		started bool
+	)
+	for _, char := range line {
+		switch {
+		case escaped:
+			field.WriteRune(char)
+			escaped = false
+		case char == '\\':
+			escaped = true
+		case char == '"':
+			quoted = !quoted
+			started = true
+		case (char == ' ' || char == '\t') && !quoted:
+			if started {
+				fields = append(fields, field.String())
+				field.Reset()
+				started = false
+			}
+			continue
+		default:
+			field.WriteRune(char)
+		}
+		started = true
+	}
+	if quoted {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	if started {
+		fields = append(fields, field.String())
+	}
+	return fields, nil
+}
+
+func contains(list []string, value string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
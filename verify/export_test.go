@@ -0,0 +1,145 @@
+package verify_test
+
+import (
+	"github.com/FidelityInternational/virgil/utility"
+	"github.com/FidelityInternational/virgil/verify"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const iptablesSave = `# Generated by iptables-save v1.8.7
+*filter
+:INPUT ACCEPT [0:0]
+:FORWARD DROP [0:0]
+:OUTPUT ACCEPT [0:0]
+-A INPUT -p tcp -m tcp --dport 22 -j ACCEPT
+-A FORWARD -s 10.0.16.0/24 -d 10.1.0.0/16 -p tcp -m tcp --dport 443 -m comment --comment "cf web" -j ACCEPT
+-A FORWARD -s 10.0.16.1/32,10.0.16.2/32 -d 10.2.0.1/32 -p udp -m multiport --dports 53,8000:8002 -j ACCEPT
+-A FORWARD -m iprange --src-range 10.0.16.1-10.0.16.9 -d 0.0.0.0/0 -p 6 -j ACCEPT
+-A FORWARD -s 10.0.16.0/24 ! -d 10.0.0.0/8 -j ACCEPT
+-A FORWARD -s 10.0.16.0/24 -d 10.9.0.0/16 -j DROP
+COMMIT
+`
+
+var _ = Describe("Export", func() {
+	Describe("#ParseIPTables", func() {
+		It("reads each ACCEPT rule as tuples", func() {
+			export, err := verify.ParseIPTables([]byte(iptablesSave), nil)
+			Expect(err).ToNot(HaveOccurred())
+			var tuples []utility.FirewallTuple
+			for _, rule := range export.Rules {
+				tuples = append(tuples, rule.FirewallTuple)
+			}
+			Expect(tuples).To(Equal([]utility.FirewallTuple{
+				{Protocol: "tcp", Port: "22"},
+				{Source: "10.0.16.0/24", Destination: "10.1.0.0/16", Protocol: "tcp", Port: "443"},
+				{Source: "10.0.16.1/32", Destination: "10.2.0.1/32", Protocol: "udp", Port: "53"},
+				{Source: "10.0.16.1/32", Destination: "10.2.0.1/32", Protocol: "udp", Port: "8000-8002"},
+				{Source: "10.0.16.2/32", Destination: "10.2.0.1/32", Protocol: "udp", Port: "53"},
+				{Source: "10.0.16.2/32", Destination: "10.2.0.1/32", Protocol: "udp", Port: "8000-8002"},
+				{Source: "10.0.16.1-10.0.16.9", Protocol: "tcp"},
+			}))
+			Expect(export.Rules[1].Line).To(Equal(7))
+			Expect(export.Rules[1].Text).To(ContainSubstring(`--comment "cf web"`))
+		})
+
+		It("skips negated matches", func() {
+			export, err := verify.ParseIPTables([]byte(iptablesSave), nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(export.Skipped).To(HaveLen(1))
+			Expect(export.Skipped[0].Line).To(Equal(10))
+			Expect(export.Skipped[0].Reason).To(Equal("negated -d match is not supported"))
+		})
+
+		It("skips rules with matches that cannot be represented", func() {
+			export, err := verify.ParseIPTables([]byte(`*filter
+-A FORWARD -m state --state RELATED,ESTABLISHED -j ACCEPT
+-A FORWARD -m conntrack --ctstate RELATED,ESTABLISHED -j ACCEPT
+-A FORWARD -s 10.0.16.0/24 -p tcp -m tcp --sport 1024:65535 -j ACCEPT
+-A FORWARD -p icmp -m icmp --icmp-type 8 -j ACCEPT
+-A FORWARD -o eth1 -j DROP
+COMMIT
+`), nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(export.Rules).To(BeEmpty())
+			var reasons []string
+			for _, skipped := range export.Skipped {
+				reasons = append(reasons, skipped.Reason)
+			}
+			Expect(reasons).To(Equal([]string{
+				"--state match is not supported",
+				"--ctstate match is not supported",
+				"--sport match is not supported",
+				"--icmp-type match is not supported",
+			}))
+		})
+
+		It("reads rules with interface matches as allowing the traffic on any interface", func() {
+			export, err := verify.ParseIPTables([]byte(`*filter
+-A FORWARD -i eth0 -o eth1 -s 10.0.16.0/24 -d 10.1.0.0/16 -p tcp -m tcp --dport 443 -j ACCEPT
+-A FORWARD ! -i eth1 -d 10.2.0.1/32 -p udp -m udp --dport 53 -j ACCEPT
+COMMIT
+`), nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(export.Skipped).To(BeEmpty())
+			Expect(export.Rules).To(HaveLen(2))
+			Expect(export.Rules[0].FirewallTuple).To(Equal(utility.FirewallTuple{Source: "10.0.16.0/24", Destination: "10.1.0.0/16", Protocol: "tcp", Port: "443"}))
+			Expect(export.Rules[1].FirewallTuple).To(Equal(utility.FirewallTuple{Destination: "10.2.0.1/32", Protocol: "udp", Port: "53"}))
+		})
+
+		It("only reads the given chains", func() {
+			export, err := verify.ParseIPTables([]byte(iptablesSave), []string{"INPUT"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(export.Rules).To(HaveLen(1))
+			Expect(export.Rules[0].Port).To(Equal("22"))
+		})
+
+		It("returns an error for an invalid address", func() {
+			_, err := verify.ParseIPTables([]byte("-A FORWARD -d 10.1.0.0/99 -j ACCEPT\n"), nil)
+			Expect(err).To(MatchError("Line 1 could not be parsed: Address 10.1.0.0/99 was invalid"))
+		})
+
+		It("returns an error for an unterminated quote", func() {
+			_, err := verify.ParseIPTables([]byte(`-A FORWARD -m comment --comment "cf -j ACCEPT`+"\n"), nil)
+			Expect(err).To(MatchError("Line 1 could not be parsed: unterminated quote"))
+		})
+	})
+
+	Describe("#ParseVirgil", func() {
+		It("reads a virgil yaml policy", func() {
+			export, err := verify.ParseVirgil([]byte(`---
+schema_version: "1"
+firewall_rules:
+- port: "443"
+  destination:
+  - 10.1.0.0/16
+  protocol: tcp
+  source:
+  - 10.0.16.1
+  - 10.0.16.2
+`))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(export.Rules).To(HaveLen(2))
+			Expect(export.Rules[1].FirewallTuple).To(Equal(utility.FirewallTuple{Source: "10.0.16.2", Destination: "10.1.0.0/16", Protocol: "tcp", Port: "443"}))
+		})
+
+		It("returns an error when the file is not a virgil policy", func() {
+			_, err := verify.ParseVirgil([]byte("firewall_rules: []\n"))
+			Expect(err).To(MatchError("Export has no schema_version and is not a virgil policy"))
+		})
+	})
+
+	Describe("#ParseExport", func() {
+		It("returns an error for an unknown format", func() {
+			_, err := verify.ParseExport(nil, "pf", nil)
+			Expect(err).To(MatchError("Export format pf is not supported, valid formats are iptables, virgil"))
+		})
+	})
+
+	Describe("#DetectExportFormat", func() {
+		It("detects virgil policies by their schema version", func() {
+			Expect(verify.DetectExportFormat([]byte(`{"schema_version": "1"}`))).To(Equal("virgil"))
+			Expect(verify.DetectExportFormat([]byte(iptablesSave))).To(Equal("iptables"))
+		})
+	})
+})
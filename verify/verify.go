@@ -0,0 +1,291 @@
+package verify
+
+import (
+	"fmt"
+	"github.com/FidelityInternational/virgil/render"
+	"github.com/FidelityInternational/virgil/utility"
+	"io"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Report - the differences between the firewall rules CF needs and those a firewall export allows
+type Report struct {
+	// Missing - rules CF needs that the export rules do not allow between them, with the sources that are not allowed
+	Missing []utility.FirewallRule
+	// Unneeded - export rules that allow no traffic needed by any security group
+	Unneeded []Rule
+	// Skipped - export lines that could not be compared
+	Skipped []SkippedLine
+}
+
+// Verify - compares the firewall rules generated by virgil with a firewall export
+func Verify(required utility.FirewallRules, export Export) Report {
+	report := Report{Skipped: export.Skipped}
+	tuples := utility.ExplodeFirewallRules(required)
+
+	missing := make(map[utility.FirewallTuple][]string)
+	var missingOrder []utility.FirewallTuple
+	for _, tuple := range tuples {
+		if coveredByRules(export.Rules, tuple) {
+			continue
+		}
+		key := utility.FirewallTuple{Protocol: tuple.Protocol, Port: tuple.Port, Destination: tuple.Destination}
+		if _, ok := missing[key]; !ok {
+			missingOrder = append(missingOrder, key)
+		}
+		missing[key] = append(missing[key], tuple.Source)
+	}
+	for _, key := range missingOrder {
+		sources := missing[key]
+		render.SortAddresses(sources)
+		report.Missing = append(report.Missing, utility.FirewallRule{
+			Protocol:    key.Protocol,
+			Port:        key.Port,
+			Destination: []string{key.Destination},
+			Source:      sources,
+		})
+	}
+
+	for _, rule := range export.Rules {
+		needed := false
+		for _, tuple := range tuples {
+			if Overlaps(rule.FirewallTuple, tuple) {
+				needed = true
+				break
+			}
+		}
+		if !needed {
+			report.Unneeded = append(report.Unneeded, rule)
+		}
+	}
+	sort.SliceStable(report.Unneeded, func(i, j int) bool {
+		return report.Unneeded[i].Line < report.Unneeded[j].Line
+	})
+	return report
+}
+
+// Drifted - returns true when the firewall is missing rules or allows rules that are not needed
+func (r Report) Drifted() bool {
+	return len(r.Missing) > 0 || len(r.Unneeded) > 0
+}
+
+// WriteTo - writes the report as text, missing rules first as they break apps
+func (r Report) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	if len(r.Missing) == 0 {
+		fmt.Fprintln(&b, "Virgil\t- No rules needed by CF are missing from the firewall")
+	} else {
+		fmt.Fprintf(&b, "Virgil\t- %d rules needed by CF are missing from the firewall:\n", len(r.Missing))
+		for _, rule := range r.Missing {
+			fmt.Fprintf(&b, "  %s\n", describe(utility.FirewallTuple{
+				Protocol:    rule.Protocol,
+				Port:        rule.Port,
+				Source:      strings.Join(rule.Source, ","),
+				Destination: rule.Destination[0],
+			}))
+		}
+	}
+	if len(r.Unneeded) == 0 {
+		fmt.Fprintln(&b, "Virgil\t- No firewall rules are unneeded")
+	} else {
+		fmt.Fprintf(&b, "Virgil\t- %d firewall rules are not needed by any security group:\n", len(r.Unneeded))
+		for _, rule := range r.Unneeded {
+			fmt.Fprintf(&b, "  %s\n", rule)
+		}
+	}
+	for _, skipped := range r.Skipped {
+		fmt.Fprintf(&b, "Virgil\t- WARNING: line %d skipped - %s: %s\n", skipped.Line, skipped.Reason, skipped.Text)
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// Covers - returns true when the allow rule allows all of the traffic in tuple. An empty source,
+// destination or port matches anything and the all protocol matches every protocol
+func Covers(allow, tuple utility.FirewallTuple) bool {
+	if allow.Protocol != "all" && allow.Protocol != tuple.Protocol {
+		return false
+	}
+	return compareAddresses(allow.Source, tuple.Source, render.Address.Contains) &&
+		compareAddresses(allow.Destination, tuple.Destination, render.Address.Contains) &&
+		comparePorts(allow.Port, tuple.Port, func(aStart, aEnd, bStart, bEnd int) bool {
+			return aStart <= bStart && bEnd <= aEnd
+		})
+}
+
+// Overlaps - returns true when the allow rule allows any of the traffic in tuple
+func Overlaps(allow, tuple utility.FirewallTuple) bool {
+	if allow.Protocol != "all" && tuple.Protocol != "all" && allow.Protocol != tuple.Protocol {
+		return false
+	}
+	return compareAddresses(allow.Source, tuple.Source, render.Address.Overlaps) &&
+		compareAddresses(allow.Destination, tuple.Destination, render.Address.Overlaps) &&
+		comparePorts(allow.Port, tuple.Port, func(aStart, aEnd, bStart, bEnd int) bool {
+			return aStart <= bEnd && bStart <= aEnd
+		})
+}
+
+// coveredByRules - returns true when the rules allow all of the traffic in tuple between them. The tuple is split at
+// the port and address edges of the rules it overlaps, so traffic allowed by a rule per port or per address is
+// covered as well as traffic allowed by a single broader rule
+func coveredByRules(rules []Rule, tuple utility.FirewallTuple) bool {
+	var overlapping []utility.FirewallTuple
+	for _, rule := range rules {
+		if Covers(rule.FirewallTuple, tuple) {
+			return true
+		}
+		if Overlaps(rule.FirewallTuple, tuple) {
+			overlapping = append(overlapping, rule.FirewallTuple)
+		}
+	}
+	if len(overlapping) < 2 {
+		return false
+	}
+	var ports, destinations, sources []string
+	for _, allow := range overlapping {
+		ports = append(ports, allow.Port)
+		destinations = append(destinations, allow.Destination)
+		sources = append(sources, allow.Source)
+	}
+	for _, port := range splitPorts(tuple.Port, ports) {
+		for _, destination := range splitAddress(tuple.Destination, destinations) {
+			for _, source := range splitAddress(tuple.Source, sources) {
+				piece := utility.FirewallTuple{Protocol: tuple.Protocol, Port: port, Destination: destination, Source: source}
+				covered := false
+				for _, allow := range overlapping {
+					if Covers(allow, piece) {
+						covered = true
+						break
+					}
+				}
+				if !covered {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// splitPorts - splits port at the edges of the given ports, a port that is not set or cannot be parsed is not split
+func splitPorts(port string, edges []string) []string {
+	start, end, err := render.SplitPortRange(port)
+	if port == "" || err != nil {
+		return []string{port}
+	}
+	cuts := []int{end + 1}
+	for _, edge := range edges {
+		edgeStart, edgeEnd, err := portRange(edge)
+		if err != nil {
+			continue
+		}
+		for _, cut := range []int{edgeStart, edgeEnd + 1} {
+			if start < cut && cut <= end {
+				cuts = append(cuts, cut)
+			}
+		}
+	}
+	sort.Ints(cuts)
+	var ports []string
+	for _, cut := range cuts {
+		if cut == start {
+			continue
+		}
+		if cut-1 == start {
+			ports = append(ports, strconv.Itoa(start))
+		} else {
+			ports = append(ports, fmt.Sprintf("%d-%d", start, cut-1))
+		}
+		start = cut
+	}
+	return ports
+}
+
+// splitAddress - splits address at the edges of the given addresses, an address that is not set or cannot be
+// parsed is not split
+func splitAddress(address string, edges []string) []string {
+	parsed, err := render.ParseAddress(address)
+	if address == "" || err != nil {
+		return []string{address}
+	}
+	var cuts []netip.Addr
+	for _, edge := range edges {
+		parsedEdge, err := render.ParseAddress(edge)
+		if edge == "" || err != nil || !parsed.Overlaps(parsedEdge) {
+			continue
+		}
+		if parsed.Start.Less(parsedEdge.Start) {
+			cuts = append(cuts, parsedEdge.Start)
+		}
+		if parsedEdge.End.Less(parsed.End) {
+			cuts = append(cuts, parsedEdge.End.Next())
+		}
+	}
+	if len(cuts) == 0 {
+		return []string{address}
+	}
+	sort.Slice(cuts, func(i, j int) bool {
+		return cuts[i].Less(cuts[j])
+	})
+	var addresses []string
+	start := parsed.Start
+	for _, cut := range cuts {
+		if cut == start {
+			continue
+		}
+		addresses = append(addresses, render.Address{Kind: render.Range, Start: start, End: cut.Prev()}.String())
+		start = cut
+	}
+	return append(addresses, render.Address{Kind: render.Range, Start: start, End: parsed.End}.String())
+}
+
+func compareAddresses(a, b string, compare func(a, b render.Address) bool) bool {
+	if a == "" {
+		return true
+	}
+	if b == "" {
+		b = "0.0.0.0/0"
+	}
+	addressA, errA := render.ParseAddress(a)
+	addressB, errB := render.ParseAddress(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return compare(addressA, addressB)
+}
+
+func comparePorts(a, b string, compare func(aStart, aEnd, bStart, bEnd int) bool) bool {
+	aStart, aEnd, errA := portRange(a)
+	bStart, bEnd, errB := portRange(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return compare(aStart, aEnd, bStart, bEnd)
+}
+
+// portRange - returns the ports in a firewall rule port, an empty port is every port
+func portRange(port string) (int, int, error) {
+	if port == "" {
+		return 0, 65535, nil
+	}
+	return render.SplitPortRange(port)
+}
+
+// describe - returns a tuple as "protocol port source -> destination", empty fields are shown as any
+func describe(tuple utility.FirewallTuple) string {
+	parts := []string{tuple.Protocol}
+	if tuple.Port != "" {
+		parts = append(parts, tuple.Port)
+	}
+	source, destination := tuple.Source, tuple.Destination
+	if source == "" {
+		source = "any"
+	}
+	if destination == "" {
+		destination = "any"
+	}
+	return fmt.Sprintf("%s %s -> %s", strings.Join(parts, " "), source, destination)
+}
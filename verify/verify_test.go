@@ -0,0 +1,113 @@
+package verify_test
+
+import (
+	"bytes"
+	"github.com/FidelityInternational/virgil/utility"
+	"github.com/FidelityInternational/virgil/verify"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Verify", func() {
+	var required utility.FirewallRules
+
+	BeforeEach(func() {
+		required = utility.FirewallRules{
+			SchemaVersion: "1",
+			FirewallRules: []utility.FirewallRule{
+				{Protocol: "tcp", Port: "443", Destination: []string{"10.1.0.0/16"}, Source: []string{"10.0.16.1", "10.0.16.2"}},
+				{Protocol: "udp", Port: "53", Destination: []string{"10.2.0.1"}, Source: []string{"10.0.16.1", "10.0.16.2", "10.0.17.1"}},
+				{Protocol: "tcp", Port: "5432", Destination: []string{"10.3.0.5-10.3.0.9"}, Source: []string{"10.0.16.1"}},
+			},
+		}
+	})
+
+	Describe("#Verify", func() {
+		It("reports the rules missing from the firewall and the firewall rules that are not needed", func() {
+			export, err := verify.ParseIPTables([]byte(iptablesSave), []string{"FORWARD"})
+			Expect(err).ToNot(HaveOccurred())
+			report := verify.Verify(required, export)
+			Expect(report.Missing).To(Equal([]utility.FirewallRule{
+				{Protocol: "udp", Port: "53", Destination: []string{"10.2.0.1"}, Source: []string{"10.0.17.1"}},
+			}))
+			Expect(report.Unneeded).To(HaveLen(2))
+			Expect(report.Unneeded[0].String()).To(Equal("line 8: udp 8000-8002 10.0.16.1/32 -> 10.2.0.1/32"))
+			Expect(report.Skipped).To(HaveLen(1))
+			Expect(report.Drifted()).To(BeTrue())
+		})
+
+		It("covers rules allowed by several export rules between them", func() {
+			required.FirewallRules = []utility.FirewallRule{
+				{Protocol: "tcp", Port: "8080-8082", Destination: []string{"10.1.0.0/24"}, Source: []string{"10.0.16.1"}},
+			}
+			export, err := verify.ParseIPTables([]byte(`*filter
+-A FORWARD -s 10.0.16.0/24 -d 10.1.0.0/24 -p tcp -m tcp --dport 8080 -j ACCEPT
+-A FORWARD -s 10.0.16.0/24 -d 10.1.0.0/25 -p tcp -m tcp --dport 8081 -j ACCEPT
+-A FORWARD -s 10.0.16.0/24 -d 10.1.0.128/25 -p tcp -m tcp --dport 8081 -j ACCEPT
+-A FORWARD -s 10.0.16.0/24 -d 10.1.0.0/24 -p tcp -m multiport --dports 8082,9000 -j ACCEPT
+COMMIT
+`), nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(verify.Verify(required, export).Missing).To(BeEmpty())
+
+			export.Rules = export.Rules[:2]
+			Expect(verify.Verify(required, export).Missing).To(Equal([]utility.FirewallRule{
+				{Protocol: "tcp", Port: "8080-8082", Destination: []string{"10.1.0.0/24"}, Source: []string{"10.0.16.1"}},
+			}))
+		})
+
+		It("reports no drift for the policy virgil generated", func() {
+			var policy bytes.Buffer
+			policy.WriteString("---\nschema_version: \"1\"\nfirewall_rules:\n")
+			for _, rule := range required.FirewallRules {
+				policy.WriteString("- port: \"" + rule.Port + "\"\n  protocol: " + rule.Protocol + "\n  destination: [" + rule.Destination[0] + "]\n  source: [10.0.0.0/8]\n")
+			}
+			export, err := verify.ParseVirgil(policy.Bytes())
+			Expect(err).ToNot(HaveOccurred())
+			report := verify.Verify(required, export)
+			Expect(report.Missing).To(BeEmpty())
+			Expect(report.Unneeded).To(BeEmpty())
+			Expect(report.Drifted()).To(BeFalse())
+		})
+	})
+
+	Describe("#Covers", func() {
+		tuple := utility.FirewallTuple{Source: "10.0.16.1", Destination: "10.3.0.5-10.3.0.9", Protocol: "tcp", Port: "5432"}
+
+		It("matches wider addresses, ports and protocols", func() {
+			Expect(verify.Covers(utility.FirewallTuple{Protocol: "all"}, tuple)).To(BeTrue())
+			Expect(verify.Covers(utility.FirewallTuple{Protocol: "tcp", Destination: "10.3.0.0/24", Port: "5000-6000"}, tuple)).To(BeTrue())
+		})
+
+		It("does not match narrower addresses, ports or other protocols", func() {
+			Expect(verify.Covers(utility.FirewallTuple{Protocol: "tcp", Destination: "10.3.0.5"}, tuple)).To(BeFalse())
+			Expect(verify.Covers(utility.FirewallTuple{Protocol: "tcp", Port: "5433"}, tuple)).To(BeFalse())
+			Expect(verify.Covers(utility.FirewallTuple{Protocol: "udp"}, tuple)).To(BeFalse())
+			Expect(verify.Covers(utility.FirewallTuple{Protocol: "tcp"}, utility.FirewallTuple{Protocol: "all"})).To(BeFalse())
+		})
+	})
+
+	Describe("#Overlaps", func() {
+		It("matches rules allowing part of the traffic", func() {
+			tuple := utility.FirewallTuple{Destination: "10.1.0.0/16", Protocol: "all"}
+			Expect(verify.Overlaps(utility.FirewallTuple{Protocol: "tcp", Destination: "10.1.2.3", Port: "22"}, tuple)).To(BeTrue())
+			Expect(verify.Overlaps(utility.FirewallTuple{Protocol: "tcp", Destination: "10.2.0.0/16"}, tuple)).To(BeFalse())
+		})
+	})
+
+	Describe("#WriteTo", func() {
+		It("lists missing and unneeded rules", func() {
+			report := verify.Report{
+				Missing:  []utility.FirewallRule{{Protocol: "tcp", Port: "443", Destination: []string{"10.1.0.0/16"}, Source: []string{"10.0.16.1", "10.0.16.2"}}},
+				Unneeded: []verify.Rule{{FirewallTuple: utility.FirewallTuple{Protocol: "tcp", Port: "22"}, Line: 6}},
+			}
+			var output bytes.Buffer
+			_, err := report.WriteTo(&output)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(output.String()).To(Equal("Virgil\t- 1 rules needed by CF are missing from the firewall:\n" +
+				"  tcp 443 10.0.16.1,10.0.16.2 -> 10.1.0.0/16\n" +
+				"Virgil\t- 1 firewall rules are not needed by any security group:\n" +
+				"  line 6: tcp 22 any -> any\n"))
+		})
+	})
+})
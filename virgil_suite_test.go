@@ -0,0 +1,12 @@
+package virgil_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"testing"
+)
+
+func TestVirgil(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Virgil test suite")
+}
@@ -0,0 +1,101 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/FidelityInternational/virgil/render"
+	"github.com/FidelityInternational/virgil/utility"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+)
+
+// FileAction - writes the changed policy to Path with Renderer, replacing the file atomically
+type FileAction struct {
+	Path     string
+	Renderer render.Renderer
+}
+
+// Changed - renders the current policy to the file
+func (a FileAction) Changed(ctx context.Context, change Change) error {
+	var output bytes.Buffer
+	if err := a.Renderer.Render(&output, change.Current.FirewallRules, change.Current.Metadata); err != nil {
+		return err
+	}
+	return utility.WriteFileAtomic(a.Path, output.Bytes())
+}
+
+// HookAction - runs Command with "sh -c" when the policy changes. The diff is written to the
+// command's standard input as JSON and summarised in VIRGIL_* environment variables
+type HookAction struct {
+	Command    string
+	PolicyFile string
+}
+
+// Changed - runs the hook command, returning an error including its output when it fails
+func (a HookAction) Changed(ctx context.Context, change Change) error {
+	input, err := json.Marshal(change.Diff)
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, "sh", "-c", a.Command)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("VIRGIL_FOUNDATION=%s", change.Current.Metadata.Foundation),
+		fmt.Sprintf("VIRGIL_POLICY_FILE=%s", a.PolicyFile),
+		fmt.Sprintf("VIRGIL_ADDED_RULES=%s", strconv.Itoa(len(change.Diff.AddedRules))),
+		fmt.Sprintf("VIRGIL_REMOVED_RULES=%s", strconv.Itoa(len(change.Diff.RemovedRules))),
+		fmt.Sprintf("VIRGIL_ADDED_SOURCES=%s", strconv.Itoa(len(change.Diff.AddedSources))),
+		fmt.Sprintf("VIRGIL_REMOVED_SOURCES=%s", strconv.Itoa(len(change.Diff.RemovedSources))),
+	)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("Hook %s failed: %s: %s", a.Command, err, bytes.TrimSpace(output))
+	}
+	return nil
+}
+
+// webhookMaxEntries - the most rules or sources listed per section of a webhook message
+const webhookMaxEntries = 20
+
+// WebhookAction - POSTs a summary of the diff to URL as {"text": "..."}, the payload accepted by
+// both Slack and Microsoft Teams incoming webhooks
+type WebhookAction struct {
+	URL    string
+	Client *http.Client
+}
+
+// Changed - posts the diff summary to the webhook
+func (a WebhookAction) Changed(ctx context.Context, change Change) error {
+	title := "Firewall policy changed"
+	if change.Current.Metadata.Foundation != "" {
+		title = fmt.Sprintf("Firewall policy for %s changed", change.Current.Metadata.Foundation)
+	}
+	body, err := json.Marshal(map[string]string{
+		"text": fmt.Sprintf("%s\n```\n%s\n```", title, change.Diff.Summary(webhookMaxEntries)),
+	})
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, a.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	client := a.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("Webhook returned %s", response.Status)
+	}
+	return nil
+}
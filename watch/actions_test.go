@@ -0,0 +1,96 @@
+package watch_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/FidelityInternational/virgil"
+	"github.com/FidelityInternational/virgil/render"
+	"github.com/FidelityInternational/virgil/utility"
+	"github.com/FidelityInternational/virgil/watch"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+)
+
+var _ = Describe("Actions", func() {
+	var (
+		change  watch.Change
+		tempDir string
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = os.MkdirTemp("", "virgil-watch")
+		Expect(err).ToNot(HaveOccurred())
+		firewallRules := utility.FirewallRules{SchemaVersion: "1", FirewallRules: []utility.FirewallRule{
+			{Protocol: "tcp", Port: "443", Destination: []string{"10.1.0.0/16"}, Source: []string{"10.0.16.1"}},
+		}}
+		change = watch.Change{
+			Current: virgil.Result{FirewallRules: firewallRules, Metadata: render.Metadata{Foundation: "sys.example.com"}},
+			Diff:    watch.Compare(utility.FirewallRules{}, firewallRules),
+		}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tempDir)
+	})
+
+	Describe("FileAction", func() {
+		It("writes the rendered policy", func() {
+			path := filepath.Join(tempDir, "policy.yml")
+			Expect(os.WriteFile(path, []byte("old"), 0644)).To(Succeed())
+			Expect(watch.FileAction{Path: path, Renderer: render.YAMLRenderer{}}.Changed(context.Background(), change)).To(Succeed())
+			policy, err := os.ReadFile(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(policy)).To(HavePrefix("---\nschema_version: \"1\""))
+			entries, err := os.ReadDir(tempDir)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(HaveLen(1))
+		})
+	})
+
+	Describe("HookAction", func() {
+		It("runs the command with the diff on stdin and a summary in the environment", func() {
+			output := filepath.Join(tempDir, "hook")
+			hook := watch.HookAction{Command: "cat > " + output + " && echo $VIRGIL_FOUNDATION $VIRGIL_ADDED_RULES $VIRGIL_POLICY_FILE >> " + output, PolicyFile: "policy.yml"}
+			Expect(hook.Changed(context.Background(), change)).To(Succeed())
+			written, err := os.ReadFile(output)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(written)).To(ContainSubstring(`"added_rules":[{"protocol":"tcp","port":"443","destination":"10.1.0.0/16"}]`))
+			Expect(string(written)).To(HaveSuffix("sys.example.com 1 policy.yml\n"))
+		})
+
+		It("returns an error including the command output when it fails", func() {
+			hook := watch.HookAction{Command: "echo firewall locked >&2; exit 3"}
+			Expect(hook.Changed(context.Background(), change)).To(MatchError(ContainSubstring("exit status 3: firewall locked")))
+		})
+	})
+
+	Describe("WebhookAction", func() {
+		It("posts the diff summary as Slack and Teams compatible JSON", func() {
+			var payload map[string]string
+			webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+				Expect(r.Method).To(Equal(http.MethodPost))
+				Expect(r.Header.Get("Content-Type")).To(Equal("application/json"))
+				body, _ := io.ReadAll(r.Body)
+				Expect(json.Unmarshal(body, &payload)).To(Succeed())
+			}))
+			defer webhook.Close()
+			Expect(watch.WebhookAction{URL: webhook.URL}.Changed(context.Background(), change)).To(Succeed())
+			Expect(payload["text"]).To(Equal("Firewall policy for sys.example.com changed\n```\nAdded rules (1):\n  tcp 443 to 10.1.0.0/16\nAdded sources (1):\n  10.0.16.1\n```"))
+		})
+
+		It("returns an error when the webhook rejects the message", func() {
+			webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusForbidden)
+			}))
+			defer webhook.Close()
+			Expect(watch.WebhookAction{URL: webhook.URL}.Changed(context.Background(), change)).To(MatchError("Webhook returned 403 Forbidden"))
+		})
+	})
+})
@@ -0,0 +1,128 @@
+package watch
+
+import (
+	"fmt"
+	"github.com/FidelityInternational/virgil/render"
+	"github.com/FidelityInternational/virgil/utility"
+	"sort"
+	"strings"
+)
+
+// RuleChange - a protocol, port and destination allowed by one policy but not the other
+type RuleChange struct {
+	Protocol    string `json:"protocol"`
+	Port        string `json:"port"`
+	Destination string `json:"destination"`
+}
+
+// Diff - the differences between two policies, sources apply to every rule so are compared separately
+type Diff struct {
+	AddedSources   []string     `json:"added_sources"`
+	RemovedSources []string     `json:"removed_sources"`
+	AddedRules     []RuleChange `json:"added_rules"`
+	RemovedRules   []RuleChange `json:"removed_rules"`
+}
+
+// Compare - returns what changed between the previous and current firewall rules, ignoring ordering
+func Compare(previous, current utility.FirewallRules) Diff {
+	previousSources, previousRules := policySets(previous)
+	currentSources, currentRules := policySets(current)
+	return Diff{
+		AddedSources:   missingAddresses(currentSources, previousSources),
+		RemovedSources: missingAddresses(previousSources, currentSources),
+		AddedRules:     missingRules(currentRules, previousRules),
+		RemovedRules:   missingRules(previousRules, currentRules),
+	}
+}
+
+// Empty - returns true when the policies are equivalent
+func (d Diff) Empty() bool {
+	return len(d.AddedSources) == 0 && len(d.RemovedSources) == 0 && len(d.AddedRules) == 0 && len(d.RemovedRules) == 0
+}
+
+// Summary - returns a short human readable description of the changes, listing at most limit
+// entries per section when limit is positive
+func (d Diff) Summary(limit int) string {
+	var lines []string
+	section := func(title string, entries []string) {
+		if len(entries) == 0 {
+			return
+		}
+		lines = append(lines, fmt.Sprintf("%s (%d):", title, len(entries)))
+		for i, entry := range entries {
+			if limit > 0 && i == limit {
+				lines = append(lines, fmt.Sprintf("  ... and %d more", len(entries)-limit))
+				break
+			}
+			lines = append(lines, fmt.Sprintf("  %s", entry))
+		}
+	}
+	section("Added rules", ruleStrings(d.AddedRules))
+	section("Removed rules", ruleStrings(d.RemovedRules))
+	section("Added sources", d.AddedSources)
+	section("Removed sources", d.RemovedSources)
+	if len(lines) == 0 {
+		return "No changes"
+	}
+	return strings.Join(lines, "\n")
+}
+
+// String - returns the rule as it is written in summaries, e.g. "tcp 443 to 10.0.0.0/24"
+func (r RuleChange) String() string {
+	if r.Port == "" {
+		return fmt.Sprintf("%s to %s", r.Protocol, r.Destination)
+	}
+	return fmt.Sprintf("%s %s to %s", r.Protocol, r.Port, r.Destination)
+}
+
+func policySets(firewallRules utility.FirewallRules) (map[string]bool, map[RuleChange]bool) {
+	sources := make(map[string]bool)
+	rules := make(map[RuleChange]bool)
+	for _, rule := range firewallRules.FirewallRules {
+		for _, source := range rule.Source {
+			sources[source] = true
+		}
+		for _, destination := range rule.Destination {
+			rules[RuleChange{Protocol: strings.ToLower(rule.Protocol), Port: rule.Port, Destination: destination}] = true
+		}
+	}
+	return sources, rules
+}
+
+func missingAddresses(from, in map[string]bool) []string {
+	var missing []string
+	for address := range from {
+		if !in[address] {
+			missing = append(missing, address)
+		}
+	}
+	render.SortAddresses(missing)
+	return missing
+}
+
+func missingRules(from, in map[RuleChange]bool) []RuleChange {
+	var missing []RuleChange
+	for rule := range from {
+		if !in[rule] {
+			missing = append(missing, rule)
+		}
+	}
+	sort.Slice(missing, func(i, j int) bool {
+		if missing[i].Protocol != missing[j].Protocol {
+			return missing[i].Protocol < missing[j].Protocol
+		}
+		if missing[i].Port != missing[j].Port {
+			return missing[i].Port < missing[j].Port
+		}
+		return missing[i].Destination < missing[j].Destination
+	})
+	return missing
+}
+
+func ruleStrings(rules []RuleChange) []string {
+	var lines []string
+	for _, rule := range rules {
+		lines = append(lines, rule.String())
+	}
+	return lines
+}
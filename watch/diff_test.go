@@ -0,0 +1,74 @@
+package watch_test
+
+import (
+	"github.com/FidelityInternational/virgil/utility"
+	"github.com/FidelityInternational/virgil/watch"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("#Compare", func() {
+	var previous utility.FirewallRules
+
+	BeforeEach(func() {
+		previous = utility.FirewallRules{SchemaVersion: "1", FirewallRules: []utility.FirewallRule{
+			{Protocol: "tcp", Port: "443", Destination: []string{"10.1.0.0/16", "192.168.1.10"}, Source: []string{"10.0.16.1", "10.0.16.2"}},
+			{Protocol: "all", Port: "", Destination: []string{"172.16.0.0/12"}, Source: []string{"10.0.16.1", "10.0.16.2"}},
+		}}
+	})
+
+	It("ignores ordering", func() {
+		current := utility.FirewallRules{SchemaVersion: "1", FirewallRules: []utility.FirewallRule{
+			{Protocol: "all", Port: "", Destination: []string{"172.16.0.0/12"}, Source: []string{"10.0.16.2", "10.0.16.1"}},
+			{Protocol: "tcp", Port: "443", Destination: []string{"192.168.1.10", "10.1.0.0/16"}, Source: []string{"10.0.16.2", "10.0.16.1"}},
+		}}
+		Expect(watch.Compare(previous, current).Empty()).To(BeTrue())
+	})
+
+	It("returns added and removed rules and sources", func() {
+		current := utility.FirewallRules{SchemaVersion: "1", FirewallRules: []utility.FirewallRule{
+			{Protocol: "tcp", Port: "443", Destination: []string{"10.1.0.0/16"}, Source: []string{"10.0.16.1", "10.0.16.3"}},
+			{Protocol: "udp", Port: "53", Destination: []string{"10.2.0.1"}, Source: []string{"10.0.16.1", "10.0.16.3"}},
+			{Protocol: "all", Port: "", Destination: []string{"172.16.0.0/12"}, Source: []string{"10.0.16.1", "10.0.16.3"}},
+		}}
+		diff := watch.Compare(previous, current)
+		Expect(diff.Empty()).To(BeFalse())
+		Expect(diff.AddedRules).To(Equal([]watch.RuleChange{{Protocol: "udp", Port: "53", Destination: "10.2.0.1"}}))
+		Expect(diff.RemovedRules).To(Equal([]watch.RuleChange{{Protocol: "tcp", Port: "443", Destination: "192.168.1.10"}}))
+		Expect(diff.AddedSources).To(Equal([]string{"10.0.16.3"}))
+		Expect(diff.RemovedSources).To(Equal([]string{"10.0.16.2"}))
+	})
+
+	It("treats every rule as added when there is no previous policy", func() {
+		diff := watch.Compare(utility.FirewallRules{}, previous)
+		Expect(diff.AddedRules).To(HaveLen(3))
+		Expect(diff.AddedSources).To(HaveLen(2))
+		Expect(diff.RemovedRules).To(BeEmpty())
+	})
+})
+
+var _ = Describe("#Summary", func() {
+	It("lists the changes by section", func() {
+		diff := watch.Diff{
+			AddedRules:   []watch.RuleChange{{Protocol: "udp", Port: "53", Destination: "10.2.0.1"}, {Protocol: "all", Destination: "172.16.0.0/12"}},
+			AddedSources: []string{"10.0.16.3"},
+		}
+		Expect(diff.Summary(0)).To(Equal(`Added rules (2):
+  udp 53 to 10.2.0.1
+  all to 172.16.0.0/12
+Added sources (1):
+  10.0.16.3`))
+	})
+
+	It("limits the entries listed per section", func() {
+		diff := watch.Diff{RemovedSources: []string{"10.0.16.1", "10.0.16.2", "10.0.16.3"}}
+		Expect(diff.Summary(2)).To(Equal(`Removed sources (3):
+  10.0.16.1
+  10.0.16.2
+  ... and 1 more`))
+	})
+
+	It("says when nothing changed", func() {
+		Expect(watch.Diff{}.Summary(0)).To(Equal("No changes"))
+	})
+})
@@ -0,0 +1,119 @@
+package watch
+
+import (
+	"context"
+	"github.com/FidelityInternational/virgil"
+	"github.com/FidelityInternational/virgil/utility"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Change - a newly generated policy that differs from the previous one
+type Change struct {
+	Previous *utility.FirewallRules
+	Current  virgil.Result
+	Diff     Diff
+}
+
+// Action - something done when the policy changes, such as writing a file or sending a notification
+type Action interface {
+	Changed(ctx context.Context, change Change) error
+}
+
+// ActionFunc - adapts a function to the Action interface
+type ActionFunc func(ctx context.Context, change Change) error
+
+// Changed - calls the function
+func (f ActionFunc) Changed(ctx context.Context, change Change) error {
+	return f(ctx, change)
+}
+
+// ActionErrors - the errors returned by the actions of a single poll
+type ActionErrors []error
+
+// Error - joins the action errors
+func (e ActionErrors) Error() string {
+	var messages []string
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Watcher - regenerates the policy on an interval and runs its actions only when the policy changes
+type Watcher struct {
+	generator *virgil.Generator
+	interval  time.Duration
+	actions   []Action
+	mutex     sync.Mutex
+	handled   []*utility.FirewallRules
+}
+
+// New - returns a Watcher polling generator every interval
+func New(generator *virgil.Generator, interval time.Duration, actions ...Action) *Watcher {
+	return &Watcher{generator: generator, interval: interval, actions: actions, handled: make([]*utility.FirewallRules, len(actions))}
+}
+
+// Seed - sets the policy the first poll is compared with, typically read back from a previously
+// written file so restarting the watcher does not repeat notifications for an unchanged policy
+func (w *Watcher) Seed(firewallRules utility.FirewallRules) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	for i := range w.handled {
+		w.handled[i] = &firewallRules
+	}
+}
+
+// Poll - regenerates the policy once, running each action whose last handled policy differs from it.
+// Without a previous policy every rule is a change. Each action records the policy as handled only when
+// it succeeds, so only the actions that failed are retried on the next poll
+func (w *Watcher) Poll(ctx context.Context) (bool, error) {
+	result, err := w.generator.Generate(ctx)
+	if err != nil {
+		return false, err
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	var (
+		changed bool
+		errs    ActionErrors
+	)
+	for i, action := range w.actions {
+		var previous utility.FirewallRules
+		if w.handled[i] != nil {
+			previous = *w.handled[i]
+		}
+		diff := Compare(previous, result.FirewallRules)
+		if diff.Empty() && w.handled[i] != nil {
+			continue
+		}
+		changed = true
+		if err := action.Changed(ctx, Change{Previous: w.handled[i], Current: result, Diff: diff}); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		w.handled[i] = &result.FirewallRules
+	}
+	if len(errs) != 0 {
+		return changed, errs
+	}
+	return changed, nil
+}
+
+// Run - polls immediately and then every interval until ctx is done, errors are passed to onError
+// when it is not nil
+func (w *Watcher) Run(ctx context.Context, onError func(error)) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		if _, err := w.Poll(ctx); err != nil && onError != nil && ctx.Err() == nil {
+			onError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package watch_test
+
+import (
+	"context"
+	"errors"
+	"github.com/FidelityInternational/virgil"
+	"github.com/FidelityInternational/virgil/fakes"
+	"github.com/FidelityInternational/virgil/render"
+	"github.com/FidelityInternational/virgil/utility"
+	"github.com/FidelityInternational/virgil/watch"
+	"github.com/cloudfoundry-community/gogobosh"
+	"github.com/cloudfoundry/go-cfclient/v3/resource"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+)
+
+var _ = Describe("Watcher", func() {
+	var (
+		secGroups *fakes.SecurityGroupSource
+		cells     *fakes.CellSource
+		mutex     sync.Mutex
+		changes   []watch.Change
+		actionErr error
+		watcher   *watch.Watcher
+	)
+
+	record := watch.ActionFunc(func(ctx context.Context, change watch.Change) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		changes = append(changes, change)
+		return actionErr
+	})
+	recorded := func() int {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return len(changes)
+	}
+
+	BeforeEach(func() {
+		changes = nil
+		actionErr = nil
+		secGroups = &fakes.SecurityGroupSource{SecGroups: []resource.SecurityGroup{
+			fakes.SecurityGroup("web", fakes.Rule("tcp", "10.1.0.0/16", "443")),
+		}}
+		cells = fakes.NewCellSource("cf-123", gogobosh.VM{JobName: "diego_cell", IPs: []string{"10.0.16.1"}})
+		watcher = watch.New(virgil.NewGenerator(secGroups, cells), time.Minute, record)
+	})
+
+	Describe("#Poll", func() {
+		It("acts on the first policy and then only on changes", func() {
+			changed, err := watcher.Poll(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changed).To(BeTrue())
+			Expect(changes[0].Previous).To(BeNil())
+			Expect(changes[0].Diff.AddedRules).To(HaveLen(1))
+
+			changed, err = watcher.Poll(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changed).To(BeFalse())
+
+			cells.SetVMs("cf-123", []gogobosh.VM{
+				{JobName: "diego_cell", IPs: []string{"10.0.16.1"}},
+				{JobName: "diego_cell", IPs: []string{"10.0.16.2"}},
+			})
+			changed, err = watcher.Poll(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changed).To(BeTrue())
+			Expect(changes).To(HaveLen(2))
+			Expect(changes[1].Previous).ToNot(BeNil())
+			Expect(changes[1].Diff.AddedSources).To(Equal([]string{"10.0.16.2"}))
+			Expect(changes[1].Diff.AddedRules).To(BeEmpty())
+		})
+
+		It("compares the first poll with a seeded policy", func() {
+			watcher.Seed(utility.FirewallRules{SchemaVersion: "1", FirewallRules: []utility.FirewallRule{
+				{Protocol: "tcp", Port: "443", Destination: []string{"10.1.0.0/16"}, Source: []string{"10.0.16.1"}},
+			}})
+			changed, err := watcher.Poll(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changed).To(BeFalse())
+			Expect(changes).To(BeEmpty())
+		})
+
+		It("retries actions that failed on the next poll", func() {
+			actionErr = errors.New("disk full")
+			changed, err := watcher.Poll(context.Background())
+			Expect(changed).To(BeTrue())
+			Expect(err).To(MatchError("disk full"))
+			actionErr = nil
+			changed, err = watcher.Poll(context.Background())
+			Expect(changed).To(BeTrue())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changes).To(HaveLen(2))
+		})
+
+		It("only retries the actions that failed", func() {
+			var posts int
+			webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mutex.Lock()
+				defer mutex.Unlock()
+				posts++
+			}))
+			defer webhook.Close()
+			file := watch.FileAction{Path: "/nonexistent/policy.yml", Renderer: render.YAMLRenderer{}}
+			watcher = watch.New(virgil.NewGenerator(secGroups, cells), time.Minute, file, watch.WebhookAction{URL: webhook.URL})
+			for i := 0; i < 3; i++ {
+				changed, err := watcher.Poll(context.Background())
+				Expect(changed).To(BeTrue())
+				Expect(err).To(HaveOccurred())
+			}
+			mutex.Lock()
+			defer mutex.Unlock()
+			Expect(posts).To(Equal(1))
+		})
+
+		It("returns generation errors without acting", func() {
+			secGroups.Err = errors.New("CF unavailable")
+			_, err := watcher.Poll(context.Background())
+			Expect(err).To(MatchError("CF unavailable"))
+			Expect(changes).To(BeEmpty())
+		})
+	})
+
+	Describe("#Run", func() {
+		It("polls on the interval until the context is done", func() {
+			watcher = watch.New(virgil.NewGenerator(secGroups, cells), 10*time.Millisecond, record)
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			go func() {
+				watcher.Run(ctx, nil)
+				close(done)
+			}()
+			Eventually(secGroups.Calls).Should(BeNumerically(">=", 3))
+			secGroups.Set([]resource.SecurityGroup{fakes.SecurityGroup("web", fakes.Rule("tcp", "10.1.0.0/16", "8443"))})
+			Eventually(recorded).Should(Equal(2))
+			cancel()
+			Eventually(done).Should(BeClosed())
+		})
+	})
+})